@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/exporter"
 	"github.com/mesaglio/otel-front/internal/receiver"
 	"github.com/mesaglio/otel-front/internal/server"
 	"github.com/mesaglio/otel-front/internal/store"
@@ -26,15 +27,48 @@ var (
 )
 
 func main() {
-	// Parse command line flags
+	// Parse command line flags. Flags explicitly set override the config file.
+	defaults := config.Default()
 	var (
-		httpPort     = flag.Int("port", 8000, "HTTP server port")
-		otlpHTTPPort = flag.Int("otlp-http-port", 4318, "OTLP HTTP receiver port")
-		otlpGRPCPort = flag.Int("otlp-grpc-port", 4317, "OTLP gRPC receiver port")
-		debug        = flag.Bool("debug", false, "Enable debug logging")
-		noBrowser    = flag.Bool("no-browser", false, "Don't open browser automatically")
-		showVersion  = flag.Bool("version", false, "Show version information and exit")
+		configPath  = flag.String("config", "", "Load configuration from this YAML file (flags override it)")
+		noBrowser   = flag.Bool("no-browser", false, "Don't open browser automatically")
+		showVersion = flag.Bool("version", false, "Show version information and exit")
+		replayPath  = flag.String("replay", "", "Ingest the captured OTLP requests in this replay file before serving")
 	)
+	flag.String("bind", defaults.Server.BindAddress, "Address the HTTP API and OTLP receivers listen on, e.g. 127.0.0.1")
+	flag.Int("port", defaults.Server.HTTPPort, "HTTP server port")
+	flag.Int("otlp-http-port", defaults.Server.OTLPHTTPPort, "OTLP HTTP receiver port")
+	flag.Int("otlp-grpc-port", defaults.Server.OTLPGRPCPort, "OTLP gRPC receiver port")
+	flag.String("otlp-http-prefix", "", "Also serve OTLP HTTP endpoints under this base path, e.g. /otlp")
+	flag.Bool("debug", false, "Enable debug logging, gRPC server reflection and the admin server")
+	flag.Int("admin-port", defaults.Server.AdminPort, "Localhost port for pprof and /debug/stats when --debug is set")
+	flag.Int("batch-size", defaults.Server.BatchSize, "Buffer up to this many spans/logs/data points before writing (0 writes synchronously)")
+	flag.Duration("batch-interval", defaults.Server.BatchInterval, "Maximum time ingested data is buffered before writing")
+	flag.String("auth-token", "", "Require this bearer token on API and OTLP requests (disabled when empty)")
+	flag.Int("rate-limit", 0, "Maximum API requests per second per client IP (0 disables)")
+	flag.String("tls-cert", "", "PEM certificate file; enables TLS on the API and OTLP receivers together with --tls-key")
+	flag.String("tls-key", "", "PEM private key file for --tls-cert")
+	flag.Int("max-spans-per-trace", defaults.Server.MaxSpansPerTrace, "Spans kept per trace in one OTLP request; extra spans are discarded (0 disables)")
+	flag.Int("grpc-max-recv-mb", defaults.Server.GRPCMaxRecvMB, "Largest OTLP gRPC message accepted, in MiB (0 keeps the gRPC default of 4)")
+	flag.Int("max-query-limit", defaults.Server.MaxQueryLimit, "Largest limit accepted by API list endpoints; larger values are lowered to it (0 disables)")
+	flag.Duration("request-timeout", defaults.Server.RequestTimeout, "Cancel API requests still running after this long and answer 503 (0 disables)")
+	flag.Duration("read-header-timeout", defaults.Server.ReadHeaderTimeout, "Longest the API and OTLP HTTP servers wait for request headers (0 uses --read-timeout)")
+	flag.Duration("read-timeout", defaults.Server.ReadTimeout, "Longest the API and OTLP HTTP servers take to read a request (0 disables)")
+	flag.Duration("write-timeout", defaults.Server.WriteTimeout, "Longest the API and OTLP HTTP servers take to write a response (0 disables)")
+	flag.Duration("idle-timeout", defaults.Server.IdleTimeout, "How long idle keep-alive connections to the API and OTLP HTTP servers are kept (0 uses --read-timeout)")
+	flag.Bool("normalize-severity", defaults.Server.NormalizeSeverity, "Rewrite log severity text to TRACE/DEBUG/INFO/WARN/ERROR/FATAL, keeping the original in an attribute")
+	flag.Duration("default-lookback", defaults.Server.DefaultLookback, "Time range queried by /api/logs and /api/metrics when no start_time/end_time is given (0 queries everything)")
+	flag.Bool("template-operation-names", defaults.Server.TemplateOperationNames, "Replace numeric and UUID path segments in span names with {id}/{uuid}, keeping the original in an attribute")
+	flag.Bool("disable-otlp", false, "Don't start the OTLP receivers; only serve data already stored (e.g. from --db-path or a snapshot)")
+	flag.Bool("disable-api", false, "Don't start the HTTP API and UI; only ingest (e.g. into --db-path or snapshots)")
+	flag.String("on-missing-timestamp", defaults.Server.MissingTimestamp, "Records without a timestamp: now (use receipt time), reject or zero (store as-is)")
+	flag.String("db-path", "", "DuckDB database file (in-memory when empty)")
+	flag.String("db-memory-limit", "", "DuckDB memory limit, e.g. 2GB (DuckDB default when empty)")
+	flag.Int("db-threads", 0, "DuckDB worker threads (DuckDB default when 0)")
+	flag.Duration("rollup-interval", defaults.Database.RollupInterval, "How often to roll up metrics into 1m/1h tables (0 disables)")
+	flag.String("snapshot-dir", "", "Periodically snapshot all data to Parquet files in this directory (disabled when empty)")
+	flag.Duration("snapshot-interval", defaults.Database.SnapshotInterval, "How often to write a snapshot (0 only writes one on shutdown)")
+	flag.Bool("snapshot-restore", defaults.Database.SnapshotRestore, "Import the snapshot in --snapshot-dir on startup")
 	flag.Parse()
 
 	// Show version and exit if requested
@@ -47,10 +81,38 @@ func main() {
 		return
 	}
 
+	// Load configuration: defaults < OTEL_FRONT_* env < config file < flags
+	var cfg *config.Config
+	var err error
+	if *configPath != "" {
+		cfg, err = config.Load(*configPath)
+	} else {
+		cfg, err = config.FromEnv()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	config.ApplyFlags(cfg, flag.CommandLine)
+	cfg.Build = config.BuildInfo{Version: version, Commit: commit, Date: date}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Fail fast on a bad certificate rather than after the store is opened
+	if _, err := config.LoadTLSConfig(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid TLS configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := exporter.ParseTimestampPolicy(cfg.Server.MissingTimestamp); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --on-missing-timestamp: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize logger
 	var logger *zap.Logger
-	var err error
-	if *debug {
+	if cfg.Debug {
 		logger, err = zap.NewDevelopment()
 	} else {
 		logger, err = zap.NewProduction()
@@ -61,77 +123,137 @@ func main() {
 	}
 	defer logger.Sync()
 
-	// Load configuration
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			HTTPPort:     *httpPort,
-			OTLPHTTPPort: *otlpHTTPPort,
-			OTLPGRPCPort: *otlpGRPCPort,
-		},
-		Debug: *debug,
-	}
-
 	logger.Info("Starting OTEL Viewer",
 		zap.String("version", version),
 		zap.String("commit", commit),
+		zap.String("bind", cfg.Server.BindAddress),
 		zap.Int("http_port", cfg.Server.HTTPPort),
 		zap.Int("otlp_http_port", cfg.Server.OTLPHTTPPort),
 		zap.Int("otlp_grpc_port", cfg.Server.OTLPGRPCPort),
+		zap.Bool("auth_enabled", cfg.Server.AuthToken != ""),
+		zap.Bool("tls_enabled", cfg.Server.TLSCertFile != ""),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Initialize database store (DuckDB in-memory)
-	logger.Info("Initializing DuckDB in-memory database...")
-	dataStore, err := store.NewStore(ctx, logger)
+	// Initialize database store (DuckDB, in-memory unless a path is given)
+	logger.Info("Initializing DuckDB database...")
+	dataStore, err := store.NewStoreWithOptions(ctx, logger, store.Options{
+		Path:        cfg.Database.Path,
+		MemoryLimit: cfg.Database.MemoryLimit,
+		Threads:     cfg.Database.Threads,
+	})
 	if err != nil {
 		logger.Fatal("Failed to initialize store", zap.Error(err))
 	}
 	defer dataStore.Close()
 
+	// The HTTP server starts before migrations so probes can reach it; /readyz
+	// reports unavailable until the store is migrated and restored
+	otlpReceiver := receiver.NewOTLPReceiver(cfg, dataStore, logger)
+
+	var srv *server.Server
+	errChan := make(chan error, 1)
+	if cfg.Server.DisableAPI {
+		logger.Info("HTTP API disabled, only ingesting")
+	} else {
+		logger.Info("Starting HTTP server...")
+		srv, err = server.NewServer(cfg, dataStore, otlpReceiver, otlpReceiver, logger)
+		if err != nil {
+			logger.Fatal("Failed to create server", zap.Error(err))
+		}
+
+		// Start server in background
+		go func() {
+			if err := srv.Start(ctx); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+
 	logger.Info("Running database migrations...")
 	if err := dataStore.Migrate(ctx); err != nil {
 		logger.Fatal("Failed to run migrations", zap.Error(err))
 	}
 
-	// Initialize OTLP receiver
-	logger.Info("Starting OTLP receiver...")
-	otlpReceiver := receiver.NewOTLPReceiver(cfg.Server.OTLPHTTPPort, cfg.Server.OTLPGRPCPort, dataStore, logger)
-	if err := otlpReceiver.Start(ctx); err != nil {
-		logger.Fatal("Failed to start OTLP receiver", zap.Error(err))
+	// A database file already keeps its data; restoring into it would duplicate logs and metrics
+	if cfg.Database.SnapshotDir != "" && cfg.Database.SnapshotRestore && cfg.Database.Path == "" {
+		logger.Info("Restoring snapshot...", zap.String("dir", cfg.Database.SnapshotDir))
+		if err := dataStore.ImportFromParquet(ctx, cfg.Database.SnapshotDir); err != nil {
+			logger.Fatal("Failed to restore snapshot", zap.Error(err))
+		}
+	}
+	if *replayPath != "" {
+		logger.Info("Replaying OTLP file...", zap.String("file", *replayPath))
+		stats, err := otlpReceiver.ReplayFile(ctx, *replayPath)
+		if err != nil {
+			logger.Fatal("Failed to replay OTLP file", zap.Error(err))
+		}
+		logger.Info("Replayed OTLP file",
+			zap.Int("requests", stats.Requests),
+			zap.Int("spans", stats.Spans),
+			zap.Int("log_records", stats.LogRecords),
+			zap.Int("data_points", stats.DataPoints),
+		)
+	}
+	if srv != nil {
+		srv.SetReady()
 	}
 
-	// Initialize HTTP server
-	logger.Info("Starting HTTP server...")
-	srv, err := server.NewServer(cfg, dataStore, logger)
-	if err != nil {
-		logger.Fatal("Failed to create server", zap.Error(err))
+	// Periodically roll up metrics so large time ranges stay cheap to query
+	if cfg.Database.RollupInterval > 0 {
+		go runMetricsRollup(ctx, dataStore, cfg.Database.RollupInterval, logger)
 	}
 
-	// Start server in background
-	errChan := make(chan error, 1)
-	go func() {
-		if err := srv.Start(ctx); err != nil {
-			errChan <- err
+	if cfg.Database.SnapshotDir != "" && cfg.Database.SnapshotInterval > 0 {
+		go runSnapshots(ctx, dataStore, cfg.Database.SnapshotDir, cfg.Database.SnapshotInterval, logger)
+	}
+
+	// Initialize OTLP receiver
+	if cfg.Server.DisableOTLP {
+		logger.Info("OTLP receiver disabled, only serving stored data")
+	} else {
+		logger.Info("Starting OTLP receiver...")
+		if err := otlpReceiver.Start(ctx); err != nil {
+			logger.Fatal("Failed to start OTLP receiver", zap.Error(err))
 		}
-	}()
+	}
+
+	// pprof and runtime stats, only in debug mode and only on localhost
+	var admin *server.AdminServer
+	if cfg.Debug {
+		admin = server.NewAdminServer(cfg.Server.AdminPort, dataStore, logger)
+		go func() {
+			if err := admin.Start(); err != nil {
+				logger.Error("Admin server stopped", zap.Error(err))
+			}
+		}()
+	}
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	url := fmt.Sprintf("http://localhost:%d", cfg.Server.HTTPPort)
-	logger.Info("OTEL Viewer is running",
-		zap.String("url", url),
-	)
-	logger.Info("Send OTLP data to:",
-		zap.String("http", fmt.Sprintf("http://localhost:%d", cfg.Server.OTLPHTTPPort)),
-		zap.String("grpc", fmt.Sprintf("localhost:%d", cfg.Server.OTLPGRPCPort)),
-	)
+	scheme := "http"
+	if cfg.Server.TLSCertFile != "" {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://localhost:%d", scheme, cfg.Server.HTTPPort)
+	if srv != nil {
+		logger.Info("OTEL Viewer is running",
+			zap.String("url", url),
+		)
+	}
+	if !cfg.Server.DisableOTLP {
+		logger.Info("Send OTLP data to:",
+			zap.String("http", fmt.Sprintf("%s://localhost:%d", scheme, cfg.Server.OTLPHTTPPort)),
+			zap.String("grpc", fmt.Sprintf("localhost:%d", cfg.Server.OTLPGRPCPort)),
+		)
+	}
 
-	// Open browser automatically (unless disabled)
-	if !*noBrowser {
+	// Open browser automatically (unless disabled or there is no UI)
+	if !*noBrowser && srv != nil {
 		go func() {
 			// Wait a bit for server to be ready
 			time.Sleep(500 * time.Millisecond)
@@ -152,17 +274,71 @@ func main() {
 		logger.Info("Received signal, shutting down...", zap.String("signal", sig.String()))
 	}
 
-	// Graceful shutdown
+	// Graceful shutdown. The receiver stops first: it flushes pending data and
+	// ends live tails, which would otherwise hold the HTTP server open.
 	logger.Info("Shutting down server...")
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Error during shutdown", zap.Error(err))
+	if !cfg.Server.DisableOTLP {
+		if err := otlpReceiver.Stop(ctx); err != nil {
+			logger.Error("Error stopping OTLP receiver", zap.Error(err))
+		}
+	} else {
+		otlpReceiver.CloseLogTails()
 	}
-	if err := otlpReceiver.Stop(ctx); err != nil {
-		logger.Error("Error stopping OTLP receiver", zap.Error(err))
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Error("Error during shutdown", zap.Error(err))
+		}
+	}
+	if admin != nil {
+		if err := admin.Shutdown(ctx); err != nil {
+			logger.Error("Error stopping admin server", zap.Error(err))
+		}
+	}
+	// Capture everything received since the last periodic snapshot
+	if cfg.Database.SnapshotDir != "" {
+		if err := dataStore.ExportToParquet(context.Background(), cfg.Database.SnapshotDir); err != nil {
+			logger.Error("Failed to write final snapshot", zap.Error(err))
+		}
 	}
 	logger.Info("Server stopped")
 }
 
+// runMetricsRollup refreshes the metric rollup tables every interval until ctx is cancelled
+func runMetricsRollup(ctx context.Context, dataStore *store.Store, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, bucket := range []string{"1m", "1h"} {
+				if err := dataStore.Metrics.RollupMetrics(ctx, bucket); err != nil {
+					logger.Warn("Failed to roll up metrics", zap.String("bucket", bucket), zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// runSnapshots writes a Parquet snapshot to dir every interval until ctx is cancelled
+func runSnapshots(ctx context.Context, dataStore *store.Store, dir string, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := dataStore.ExportToParquet(ctx, dir); err != nil {
+				logger.Warn("Failed to write snapshot", zap.Error(err))
+			}
+		}
+	}
+}
+
 // openBrowser opens the specified URL in the default browser
 func openBrowser(url string) error {
 	var cmd string
@@ -0,0 +1,20 @@
+// Package auth checks the bearer token shared by the HTTP API and the OTLP
+// receivers, so both accept the same Authorization headers.
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+)
+
+// ValidBearerToken reports whether an Authorization header carries the expected
+// token. The scheme is matched case-insensitively and the token is compared in
+// constant time.
+func ValidBearerToken(header, token string) bool {
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	provided := strings.TrimSpace(header[len(prefix):])
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "testing"
+
+func TestValidBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "empty header", header: "", want: false},
+		{name: "valid token", header: "Bearer secret", want: true},
+		{name: "lowercase scheme", header: "bearer secret", want: true},
+		{name: "surrounding spaces", header: "Bearer  secret ", want: true},
+		{name: "wrong token", header: "Bearer nope", want: false},
+		{name: "token prefix", header: "Bearer secre", want: false},
+		{name: "wrong scheme", header: "Basic secret", want: false},
+		{name: "scheme only", header: "Bearer ", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidBearerToken(tt.header, "secret"); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
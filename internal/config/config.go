@@ -1,14 +1,319 @@
 package config
 
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
 // Config holds the application configuration
 type Config struct {
-	Server ServerConfig
-	Debug  bool
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Debug    bool           `yaml:"debug"`
+	Build    BuildInfo      `yaml:"-"` // Set by main from the build, never loaded
+}
+
+// BuildInfo identifies the running build
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	HTTPPort     int // Port for HTTP API and WebSocket
-	OTLPHTTPPort int // Port for OTLP HTTP receiver
-	OTLPGRPCPort int // Port for OTLP gRPC receiver
+	BindAddress            string        `yaml:"bind_address"`             // Interface the HTTP API and OTLP receivers listen on (empty for all)
+	HTTPPort               int           `yaml:"http_port"`                // Port for HTTP API and WebSocket
+	OTLPHTTPPort           int           `yaml:"otlp_http_port"`           // Port for OTLP HTTP receiver
+	OTLPGRPCPort           int           `yaml:"otlp_grpc_port"`           // Port for OTLP gRPC receiver
+	OTLPHTTPPrefix         string        `yaml:"otlp_http_prefix"`         // Extra base path for OTLP HTTP endpoints, e.g. "/otlp" (empty for none)
+	AuthToken              string        `yaml:"auth_token"`               // Bearer token required on API and OTLP requests (empty disables auth)
+	RateLimit              int           `yaml:"rate_limit"`               // API requests per second allowed per client IP (0 disables)
+	BatchSize              int           `yaml:"batch_size"`               // Spans/log records/data points buffered before a write (0 stores synchronously)
+	BatchInterval          time.Duration `yaml:"batch_interval"`           // Maximum time records stay buffered before a write
+	TLSCertFile            string        `yaml:"tls_cert"`                 // PEM certificate served by the API and OTLP receivers (empty for plaintext)
+	TLSKeyFile             string        `yaml:"tls_key"`                  // PEM private key for TLSCertFile
+	MissingTimestamp       string        `yaml:"on_missing_timestamp"`     // What to do with records without a timestamp: now, reject or zero
+	AdminPort              int           `yaml:"admin_port"`               // Localhost port for pprof and /debug/stats, served only in debug mode
+	MaxSpansPerTrace       int           `yaml:"max_spans_per_trace"`      // Spans kept per trace in one OTLP request; extra spans are discarded (0 for no limit)
+	GRPCMaxRecvMB          int           `yaml:"grpc_max_recv_mb"`         // Largest OTLP gRPC message accepted, in MiB (0 for the gRPC default of 4)
+	MaxQueryLimit          int           `yaml:"max_query_limit"`          // Largest limit accepted by list endpoints; larger values are lowered to it (0 for no limit)
+	RequestTimeout         time.Duration `yaml:"request_timeout"`          // Longest an API request may run before its queries are cancelled with 503 (0 for no limit)
+	ReadHeaderTimeout      time.Duration `yaml:"read_header_timeout"`      // Longest the API and OTLP HTTP servers wait for request headers, against slowloris clients (0 uses ReadTimeout)
+	ReadTimeout            time.Duration `yaml:"read_timeout"`             // Longest the API and OTLP HTTP servers take to read a request (0 for no limit)
+	WriteTimeout           time.Duration `yaml:"write_timeout"`            // Longest the API and OTLP HTTP servers take to write a response (0 for no limit)
+	IdleTimeout            time.Duration `yaml:"idle_timeout"`             // How long the API and OTLP HTTP servers keep idle keep-alive connections (0 uses ReadTimeout)
+	NormalizeSeverity      bool          `yaml:"normalize_severity"`       // Rewrite log severity text to TRACE/DEBUG/INFO/WARN/ERROR/FATAL, keeping the original in an attribute
+	DefaultLookback        time.Duration `yaml:"default_lookback"`         // Time range queried by /api/logs and /api/metrics when the client sends none (0 queries everything)
+	TemplateOperationNames bool          `yaml:"template_operation_names"` // Replace numeric and UUID path segments in span names with {id}/{uuid}, keeping the original in an attribute
+	DisableOTLP            bool          `yaml:"disable_otlp"`             // Don't start the OTLP receivers; only serve data already stored
+	DisableAPI             bool          `yaml:"disable_api"`              // Don't start the HTTP API and UI; only ingest
+}
+
+// DatabaseConfig holds DuckDB configuration
+type DatabaseConfig struct {
+	Path             string        `yaml:"path"`              // Database file path (empty for in-memory)
+	MemoryLimit      string        `yaml:"memory_limit"`      // DuckDB memory limit, e.g. "2GB" (empty for DuckDB default)
+	Threads          int           `yaml:"threads"`           // DuckDB worker threads (0 for DuckDB default)
+	RollupInterval   time.Duration `yaml:"rollup_interval"`   // How often metrics are rolled up into 1m/1h tables (0 disables)
+	SnapshotDir      string        `yaml:"snapshot_dir"`      // Directory for Parquet snapshots (empty disables snapshots)
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"` // How often a snapshot is written (0 only writes one on shutdown)
+	SnapshotRestore  bool          `yaml:"snapshot_restore"`  // Import the snapshot in SnapshotDir on startup
+}
+
+// ListenAddr returns the host:port to listen on for port
+func (s ServerConfig) ListenAddr(port int) string {
+	return net.JoinHostPort(s.BindAddress, strconv.Itoa(port))
+}
+
+// LoadTLSConfig loads the certificate/key pair used by all servers. It returns
+// nil when neither file is set (plaintext) and an error when only one is set or
+// the pair cannot be loaded.
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both a TLS certificate and key are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate %s and key %s: %w", certFile, keyFile, err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// Default returns the configuration used when nothing else is specified
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			HTTPPort:          8000,
+			OTLPHTTPPort:      4318,
+			OTLPGRPCPort:      4317,
+			BatchInterval:     200 * time.Millisecond,
+			MissingTimestamp:  "now",
+			AdminPort:         6060,
+			MaxSpansPerTrace:  50000,
+			GRPCMaxRecvMB:     16,
+			MaxQueryLimit:     10000,
+			RequestTimeout:    30 * time.Second,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       60 * time.Second,
+		},
+		Database: DatabaseConfig{
+			RollupInterval:   5 * time.Minute,
+			SnapshotInterval: 5 * time.Minute,
+			SnapshotRestore:  true,
+		},
+	}
+}
+
+// Load reads a YAML config file on top of the defaults and environment
+// (see FromEnv). Keys missing from the file keep their previous value; unknown
+// keys are rejected.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalWithOptions(data, cfg, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// envVars maps OTEL_FRONT_* environment variables onto config fields
+var envVars = []struct {
+	name  string
+	apply func(cfg *Config, value string) error
+}{
+	{"OTEL_FRONT_BIND", func(c *Config, v string) error { c.Server.BindAddress = v; return nil }},
+	{"OTEL_FRONT_HTTP_PORT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.HTTPPort) }},
+	{"OTEL_FRONT_OTLP_HTTP_PORT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.OTLPHTTPPort) }},
+	{"OTEL_FRONT_OTLP_GRPC_PORT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.OTLPGRPCPort) }},
+	{"OTEL_FRONT_OTLP_HTTP_PREFIX", func(c *Config, v string) error { c.Server.OTLPHTTPPrefix = v; return nil }},
+	{"OTEL_FRONT_AUTH_TOKEN", func(c *Config, v string) error { c.Server.AuthToken = v; return nil }},
+	{"OTEL_FRONT_RATE_LIMIT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.RateLimit) }},
+	{"OTEL_FRONT_BATCH_SIZE", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.BatchSize) }},
+	{"OTEL_FRONT_BATCH_INTERVAL", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.BatchInterval) }},
+	{"OTEL_FRONT_TLS_CERT", func(c *Config, v string) error { c.Server.TLSCertFile = v; return nil }},
+	{"OTEL_FRONT_TLS_KEY", func(c *Config, v string) error { c.Server.TLSKeyFile = v; return nil }},
+	{"OTEL_FRONT_ON_MISSING_TIMESTAMP", func(c *Config, v string) error { c.Server.MissingTimestamp = v; return nil }},
+	{"OTEL_FRONT_ADMIN_PORT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.AdminPort) }},
+	{"OTEL_FRONT_MAX_SPANS_PER_TRACE", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.MaxSpansPerTrace) }},
+	{"OTEL_FRONT_GRPC_MAX_RECV_MB", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.GRPCMaxRecvMB) }},
+	{"OTEL_FRONT_MAX_QUERY_LIMIT", func(c *Config, v string) error { return parseEnvInt(v, &c.Server.MaxQueryLimit) }},
+	{"OTEL_FRONT_REQUEST_TIMEOUT", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.RequestTimeout) }},
+	{"OTEL_FRONT_READ_HEADER_TIMEOUT", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.ReadHeaderTimeout) }},
+	{"OTEL_FRONT_READ_TIMEOUT", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.ReadTimeout) }},
+	{"OTEL_FRONT_WRITE_TIMEOUT", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.WriteTimeout) }},
+	{"OTEL_FRONT_IDLE_TIMEOUT", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.IdleTimeout) }},
+	{"OTEL_FRONT_NORMALIZE_SEVERITY", func(c *Config, v string) error { return parseEnvBool(v, &c.Server.NormalizeSeverity) }},
+	{"OTEL_FRONT_DEFAULT_LOOKBACK", func(c *Config, v string) error { return parseEnvDuration(v, &c.Server.DefaultLookback) }},
+	{"OTEL_FRONT_TEMPLATE_OPERATION_NAMES", func(c *Config, v string) error { return parseEnvBool(v, &c.Server.TemplateOperationNames) }},
+	{"OTEL_FRONT_DISABLE_OTLP", func(c *Config, v string) error { return parseEnvBool(v, &c.Server.DisableOTLP) }},
+	{"OTEL_FRONT_DISABLE_API", func(c *Config, v string) error { return parseEnvBool(v, &c.Server.DisableAPI) }},
+	{"OTEL_FRONT_DB_PATH", func(c *Config, v string) error { c.Database.Path = v; return nil }},
+	{"OTEL_FRONT_DB_MEMORY_LIMIT", func(c *Config, v string) error { c.Database.MemoryLimit = v; return nil }},
+	{"OTEL_FRONT_DB_THREADS", func(c *Config, v string) error { return parseEnvInt(v, &c.Database.Threads) }},
+	{"OTEL_FRONT_ROLLUP_INTERVAL", func(c *Config, v string) error { return parseEnvDuration(v, &c.Database.RollupInterval) }},
+	{"OTEL_FRONT_SNAPSHOT_DIR", func(c *Config, v string) error { c.Database.SnapshotDir = v; return nil }},
+	{"OTEL_FRONT_SNAPSHOT_INTERVAL", func(c *Config, v string) error { return parseEnvDuration(v, &c.Database.SnapshotInterval) }},
+	{"OTEL_FRONT_SNAPSHOT_RESTORE", func(c *Config, v string) error { return parseEnvBool(v, &c.Database.SnapshotRestore) }},
+	{"OTEL_FRONT_DEBUG", func(c *Config, v string) error { return parseEnvBool(v, &c.Debug) }},
+}
+
+// FromEnv returns the defaults overridden by any OTEL_FRONT_* environment
+// variables that are set. Precedence overall is defaults < env < file < flags.
+func FromEnv() (*Config, error) {
+	cfg := Default()
+	for _, env := range envVars {
+		value, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+		if err := env.apply(cfg, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", env.name, err)
+		}
+	}
+	return cfg, nil
+}
+
+func parseEnvInt(value string, dst *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+func parseEnvDuration(value string, dst *time.Duration) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*dst = d
+	return nil
+}
+
+func parseEnvBool(value string, dst *bool) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+// Validate reports settings that cannot be combined
+func (c *Config) Validate() error {
+	if c.Server.DisableOTLP && c.Server.DisableAPI {
+		return fmt.Errorf("disable_otlp and disable_api cannot both be set: nothing would be served")
+	}
+	return nil
+}
+
+// ApplyFlags overrides cfg with the flags explicitly set on fs, so command-line
+// values win over the config file while unset flags leave it untouched
+func ApplyFlags(cfg *Config, fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			return
+		}
+		value := getter.Get()
+
+		switch f.Name {
+		case "bind":
+			cfg.Server.BindAddress = value.(string)
+		case "port":
+			cfg.Server.HTTPPort = value.(int)
+		case "otlp-http-port":
+			cfg.Server.OTLPHTTPPort = value.(int)
+		case "otlp-grpc-port":
+			cfg.Server.OTLPGRPCPort = value.(int)
+		case "otlp-http-prefix":
+			cfg.Server.OTLPHTTPPrefix = value.(string)
+		case "auth-token":
+			cfg.Server.AuthToken = value.(string)
+		case "rate-limit":
+			cfg.Server.RateLimit = value.(int)
+		case "batch-size":
+			cfg.Server.BatchSize = value.(int)
+		case "batch-interval":
+			cfg.Server.BatchInterval = value.(time.Duration)
+		case "tls-cert":
+			cfg.Server.TLSCertFile = value.(string)
+		case "tls-key":
+			cfg.Server.TLSKeyFile = value.(string)
+		case "on-missing-timestamp":
+			cfg.Server.MissingTimestamp = value.(string)
+		case "admin-port":
+			cfg.Server.AdminPort = value.(int)
+		case "max-spans-per-trace":
+			cfg.Server.MaxSpansPerTrace = value.(int)
+		case "grpc-max-recv-mb":
+			cfg.Server.GRPCMaxRecvMB = value.(int)
+		case "max-query-limit":
+			cfg.Server.MaxQueryLimit = value.(int)
+		case "request-timeout":
+			cfg.Server.RequestTimeout = value.(time.Duration)
+		case "read-header-timeout":
+			cfg.Server.ReadHeaderTimeout = value.(time.Duration)
+		case "read-timeout":
+			cfg.Server.ReadTimeout = value.(time.Duration)
+		case "write-timeout":
+			cfg.Server.WriteTimeout = value.(time.Duration)
+		case "idle-timeout":
+			cfg.Server.IdleTimeout = value.(time.Duration)
+		case "normalize-severity":
+			cfg.Server.NormalizeSeverity = value.(bool)
+		case "default-lookback":
+			cfg.Server.DefaultLookback = value.(time.Duration)
+		case "template-operation-names":
+			cfg.Server.TemplateOperationNames = value.(bool)
+		case "disable-otlp":
+			cfg.Server.DisableOTLP = value.(bool)
+		case "disable-api":
+			cfg.Server.DisableAPI = value.(bool)
+		case "db-path":
+			cfg.Database.Path = value.(string)
+		case "db-memory-limit":
+			cfg.Database.MemoryLimit = value.(string)
+		case "db-threads":
+			cfg.Database.Threads = value.(int)
+		case "rollup-interval":
+			cfg.Database.RollupInterval = value.(time.Duration)
+		case "snapshot-dir":
+			cfg.Database.SnapshotDir = value.(string)
+		case "snapshot-interval":
+			cfg.Database.SnapshotInterval = value.(time.Duration)
+		case "snapshot-restore":
+			cfg.Database.SnapshotRestore = value.(bool)
+		case "debug":
+			cfg.Debug = value.(bool)
+		}
+	})
 }
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  http_port: 9000
+  auth_token: secret
+  batch_interval: 1s
+database:
+  path: /tmp/otel.db
+debug: true
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Server.HTTPPort != 9000 {
+		t.Errorf("Expected HTTP port 9000, got %d", cfg.Server.HTTPPort)
+	}
+	if cfg.Server.AuthToken != "secret" {
+		t.Errorf("Expected auth token 'secret', got %q", cfg.Server.AuthToken)
+	}
+	if cfg.Server.BatchInterval != time.Second {
+		t.Errorf("Expected batch interval 1s, got %v", cfg.Server.BatchInterval)
+	}
+	if cfg.Database.Path != "/tmp/otel.db" {
+		t.Errorf("Expected db path /tmp/otel.db, got %q", cfg.Database.Path)
+	}
+	if !cfg.Debug {
+		t.Error("Expected debug to be enabled")
+	}
+
+	// Keys missing from the file keep their defaults
+	if cfg.Server.OTLPGRPCPort != 4317 {
+		t.Errorf("Expected default OTLP gRPC port 4317, got %d", cfg.Server.OTLPGRPCPort)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  http_prot: 9000\n")
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
+
+func TestApplyFlagsPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  http_port: 9000
+  otlp_grpc_port: 5317
+database:
+  path: /tmp/file.db
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	defaults := Default()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", defaults.Server.HTTPPort, "")
+	fs.Int("otlp-grpc-port", defaults.Server.OTLPGRPCPort, "")
+	fs.String("db-path", "", "")
+	if err := fs.Parse([]string{"--port", "9100", "--db-path", ""}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	ApplyFlags(cfg, fs)
+
+	// Explicit flags win, even when set to their default value
+	if cfg.Server.HTTPPort != 9100 {
+		t.Errorf("Expected flag HTTP port 9100, got %d", cfg.Server.HTTPPort)
+	}
+	if cfg.Database.Path != "" {
+		t.Errorf("Expected flag db path to clear the file value, got %q", cfg.Database.Path)
+	}
+	// Unset flags leave the file value alone
+	if cfg.Server.OTLPGRPCPort != 5317 {
+		t.Errorf("Expected file OTLP gRPC port 5317, got %d", cfg.Server.OTLPGRPCPort)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("OTEL_FRONT_HTTP_PORT", "9200")
+	t.Setenv("OTEL_FRONT_OTLP_GRPC_PORT", "5317")
+	t.Setenv("OTEL_FRONT_AUTH_TOKEN", "env-token")
+	t.Setenv("OTEL_FRONT_ROLLUP_INTERVAL", "1m")
+	t.Setenv("OTEL_FRONT_DEBUG", "true")
+
+	cfg, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Failed to read env config: %v", err)
+	}
+
+	if cfg.Server.HTTPPort != 9200 {
+		t.Errorf("Expected HTTP port 9200, got %d", cfg.Server.HTTPPort)
+	}
+	if cfg.Server.OTLPGRPCPort != 5317 {
+		t.Errorf("Expected OTLP gRPC port 5317, got %d", cfg.Server.OTLPGRPCPort)
+	}
+	if cfg.Server.AuthToken != "env-token" {
+		t.Errorf("Expected auth token 'env-token', got %q", cfg.Server.AuthToken)
+	}
+	if cfg.Database.RollupInterval != time.Minute {
+		t.Errorf("Expected rollup interval 1m, got %v", cfg.Database.RollupInterval)
+	}
+	if !cfg.Debug {
+		t.Error("Expected debug to be enabled")
+	}
+	// Unset variables keep their defaults
+	if cfg.Server.OTLPHTTPPort != 4318 {
+		t.Errorf("Expected default OTLP HTTP port 4318, got %d", cfg.Server.OTLPHTTPPort)
+	}
+}
+
+func TestFromEnvInvalidValue(t *testing.T) {
+	t.Setenv("OTEL_FRONT_HTTP_PORT", "not-a-port")
+
+	if _, err := FromEnv(); err == nil {
+		t.Error("Expected an error for an invalid port")
+	}
+}
+
+func TestEnvFileFlagPrecedence(t *testing.T) {
+	t.Setenv("OTEL_FRONT_HTTP_PORT", "9200")
+	t.Setenv("OTEL_FRONT_OTLP_HTTP_PORT", "5318")
+	t.Setenv("OTEL_FRONT_OTLP_GRPC_PORT", "5317")
+
+	// The file overrides two of the env values
+	path := writeConfigFile(t, "server:\n  http_port: 9000\n  otlp_grpc_port: 6317\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	// A flag overrides one of those again
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 8000, "")
+	if err := fs.Parse([]string{"--port", "9100"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	ApplyFlags(cfg, fs)
+
+	if cfg.Server.HTTPPort != 9100 {
+		t.Errorf("Expected flag to win with 9100, got %d", cfg.Server.HTTPPort)
+	}
+	if cfg.Server.OTLPGRPCPort != 6317 {
+		t.Errorf("Expected file to win with 6317, got %d", cfg.Server.OTLPGRPCPort)
+	}
+	if cfg.Server.OTLPHTTPPort != 5318 {
+		t.Errorf("Expected env to win with 5318, got %d", cfg.Server.OTLPHTTPPort)
+	}
+}
+
+func TestValidateDisabledModes(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected defaults to be valid, got %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("disable-otlp", false, "")
+	fs.Bool("disable-api", false, "")
+	if err := fs.Parse([]string{"--disable-otlp"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	ApplyFlags(cfg, fs)
+	if !cfg.Server.DisableOTLP || cfg.Server.DisableAPI {
+		t.Fatalf("Expected only the OTLP receiver disabled, got %+v", cfg.Server)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a read-only setup to be valid, got %v", err)
+	}
+
+	cfg.Server.DisableAPI = true
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected an error with both the receiver and the API disabled")
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		bind     string
+		expected string
+	}{
+		{"0.0.0.0", "0.0.0.0:4317"},
+		{"127.0.0.1", "127.0.0.1:4317"},
+		{"", ":4317"},
+		{"::1", "[::1]:4317"},
+	}
+
+	for _, tt := range tests {
+		if got := (ServerConfig{BindAddress: tt.bind}).ListenAddr(4317); got != tt.expected {
+			t.Errorf("ListenAddr with bind %q: expected %s, got %s", tt.bind, tt.expected, got)
+		}
+	}
+}
+
+// writeTestKeyPair writes a self-signed certificate and its key as PEM files
+func writeTestKeyPair(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t)
+
+	tlsConfig, err := LoadTLSConfig("", "")
+	if err != nil || tlsConfig != nil {
+		t.Errorf("Expected plaintext without cert and key, got %v (err: %v)", tlsConfig, err)
+	}
+
+	tlsConfig, err = LoadTLSConfig(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to load key pair: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Errorf("Expected 1 certificate, got %d", len(tlsConfig.Certificates))
+	}
+
+	if _, err := LoadTLSConfig(certFile, ""); err == nil {
+		t.Error("Expected an error when the key is missing")
+	}
+	// Swapped files don't form a valid pair
+	if _, err := LoadTLSConfig(keyFile, certFile); err == nil {
+		t.Error("Expected an error for an invalid key pair")
+	}
+}
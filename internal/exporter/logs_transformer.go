@@ -1,7 +1,9 @@
 package exporter
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mesaglio/otel-front/internal/store"
@@ -9,9 +11,11 @@ import (
 	"go.opentelemetry.io/collector/pdata/plog"
 )
 
-// TransformLogs converts OTLP logs to internal log model
-func TransformLogs(ld plog.Logs) ([]*store.LogRecord, error) {
+// TransformLogs converts OTLP logs to internal log model. Records rejected by
+// opts.MissingTimestamp are omitted.
+func TransformLogs(ld plog.Logs, opts Options) ([]*store.LogRecord, error) {
 	logs := make([]*store.LogRecord, 0)
+	now := time.Now()
 
 	// Iterate through resource logs
 	for i := 0; i < ld.ResourceLogs().Len(); i++ {
@@ -27,16 +31,42 @@ func TransformLogs(ld plog.Logs) ([]*store.LogRecord, error) {
 			for k := 0; k < sl.LogRecords().Len(); k++ {
 				lr := sl.LogRecords().At(k)
 
+				// Per the OTLP spec, fall back to the observed time before applying the policy
+				ts := lr.Timestamp()
+				if ts == 0 {
+					ts = lr.ObservedTimestamp()
+				}
+				timestamp, _, ok := opts.resolveTimestamp(ts, now)
+				if !ok {
+					continue
+				}
+
 				log := &store.LogRecord{
-					Timestamp:          time.Unix(0, int64(lr.Timestamp())),
+					Timestamp:          timestamp,
+					EstimatedTimestamp: lr.Timestamp() == 0 && timestamp.UnixNano() != 0,
 					SeverityText:       lr.SeverityText(),
 					SeverityNumber:     int(lr.SeverityNumber()),
-					Body:               logBodyToString(lr.Body()),
+					Body:               logBodyToString(lr.Body(), 0),
+					EventName:          lr.EventName(),
 					ServiceName:        serviceName,
 					Attributes:         attributesToMap(lr.Attributes()),
 					ResourceAttributes: resourceAttrs,
 				}
 
+				if opts.NormalizeSeverity {
+					normalizeSeverity(log)
+				}
+
+				// Keep the original structure of map bodies alongside the readable text
+				if lr.Body().Type() == pcommon.ValueTypeMap {
+					log.BodyJSON = attributesToMap(lr.Body().Map())
+				}
+
+				if observed := lr.ObservedTimestamp(); observed != 0 {
+					observedTime := time.Unix(0, int64(observed))
+					log.ObservedTimestamp = &observedTime
+				}
+
 				// Extract trace and span IDs if present
 				if !lr.TraceID().IsEmpty() {
 					traceID := lr.TraceID().String()
@@ -55,8 +85,100 @@ func TransformLogs(ld plog.Logs) ([]*store.LogRecord, error) {
 	return logs, nil
 }
 
-// logBodyToString converts log body to string
-func logBodyToString(body pcommon.Value) string {
+// originalSeverityTextKey holds the SDK's severity text when normalization replaced it
+const originalSeverityTextKey = "log.original_severity_text"
+
+// severityTexts are the canonical severity names, indexed by (SeverityNumber-1)/4
+var severityTexts = [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// severityAliases maps common lowercase spellings to the base severity number
+// of each range, for records that only set severity text
+var severityAliases = map[string]plog.SeverityNumber{
+	"trace":       plog.SeverityNumberTrace,
+	"debug":       plog.SeverityNumberDebug,
+	"info":        plog.SeverityNumberInfo,
+	"information": plog.SeverityNumberInfo,
+	"notice":      plog.SeverityNumberInfo,
+	"warn":        plog.SeverityNumberWarn,
+	"warning":     plog.SeverityNumberWarn,
+	"error":       plog.SeverityNumberError,
+	"err":         plog.SeverityNumberError,
+	"fatal":       plog.SeverityNumberFatal,
+	"critical":    plog.SeverityNumberFatal,
+	"crit":        plog.SeverityNumberFatal,
+	"panic":       plog.SeverityNumberFatal,
+}
+
+// severityTextForNumber returns the canonical text for an OTLP severity number
+// (1-4 TRACE, 5-8 DEBUG, ..., 21-24 FATAL), or "" if it is unspecified or out of range
+func severityTextForNumber(number int) string {
+	if number < 1 || number > 4*len(severityTexts) {
+		return ""
+	}
+	return severityTexts[(number-1)/4]
+}
+
+// standardSeverityNumber returns the severity number of a standard OTLP short
+// name (TRACE, TRACE2 ... FATAL4), or false for any other text
+func standardSeverityNumber(text string) (int, bool) {
+	for i, base := range severityTexts {
+		suffix, ok := strings.CutPrefix(text, base)
+		if !ok {
+			continue
+		}
+		switch suffix {
+		case "":
+			return i*4 + 1, true
+		case "2", "3", "4":
+			return i*4 + int(suffix[0]-'0'), true
+		}
+	}
+	return 0, false
+}
+
+// normalizeSeverity rewrites empty or nonstandard severity text to TRACE, DEBUG,
+// INFO, WARN, ERROR or FATAL so filtering by text is reliable. The text is
+// derived from the severity number, or from a known alias when only text was
+// sent, in which case the number is filled in too. Standard short names such as
+// INFO2 are kept as sent. A replaced non-empty text is kept in the
+// originalSeverityTextKey attribute; text that can't be mapped is left as-is.
+func normalizeSeverity(log *store.LogRecord) {
+	original := log.SeverityText
+	if number, ok := standardSeverityNumber(original); ok {
+		if log.SeverityNumber == 0 {
+			log.SeverityNumber = number
+		}
+		return
+	}
+	canonical := severityTextForNumber(log.SeverityNumber)
+	if canonical == "" {
+		number, ok := severityAliases[strings.ToLower(strings.TrimSpace(original))]
+		if !ok {
+			return
+		}
+		log.SeverityNumber = int(number)
+		canonical = severityTextForNumber(log.SeverityNumber)
+	}
+
+	if original == canonical {
+		return
+	}
+	log.SeverityText = canonical
+	if original != "" {
+		if log.Attributes == nil {
+			log.Attributes = map[string]interface{}{}
+		}
+		log.Attributes[originalSeverityTextKey] = original
+	}
+}
+
+// logBodyToString converts log body to string. depth is the nesting level of
+// body; like attribute values, nesting beyond maxAttributeDepth is truncated.
+func logBodyToString(body pcommon.Value, depth int) string {
+	if depth >= maxAttributeDepth && (body.Type() == pcommon.ValueTypeMap || body.Type() == pcommon.ValueTypeSlice) {
+		return truncatedAttributeValue
+	}
+
 	switch body.Type() {
 	case pcommon.ValueTypeStr:
 		return body.Str()
@@ -67,8 +189,11 @@ func logBodyToString(body pcommon.Value) string {
 	case pcommon.ValueTypeBool:
 		return fmt.Sprintf("%t", body.Bool())
 	case pcommon.ValueTypeMap:
-		// Convert map to JSON-like string
-		attrs := attributesToMap(body.Map())
+		// Render maps as JSON so the text fallback stays parseable
+		attrs := attributesToMapAt(body.Map(), depth)
+		if data, err := json.Marshal(attrs); err == nil {
+			return string(data)
+		}
 		return fmt.Sprintf("%v", attrs)
 	case pcommon.ValueTypeSlice:
 		slice := body.Slice()
@@ -77,7 +202,7 @@ func logBodyToString(body pcommon.Value) string {
 			if i > 0 {
 				result += ", "
 			}
-			result += logBodyToString(slice.At(i))
+			result += logBodyToString(slice.At(i), depth+1)
 		}
 		result += "]"
 		return result
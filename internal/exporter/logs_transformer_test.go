@@ -0,0 +1,238 @@
+package exporter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestTransformLogsWithMapBody(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "test-service")
+
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	lr.SetSeverityText("INFO")
+	body := lr.Body().SetEmptyMap()
+	body.PutStr("event", "order.created")
+	body.PutInt("order_id", 42)
+
+	logs, err := TransformLogs(ld, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 log, got %d", len(logs))
+	}
+
+	log := logs[0]
+	if log.BodyJSON["event"] != "order.created" {
+		t.Errorf("Expected body_json event 'order.created', got %v", log.BodyJSON["event"])
+	}
+
+	// The text fallback should itself be valid JSON
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(log.Body), &parsed); err != nil {
+		t.Errorf("Expected body to be JSON, got %q: %v", log.Body, err)
+	}
+}
+
+func TestTransformLogsWithStringBody(t *testing.T) {
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Body().SetStr("plain message")
+
+	logs, err := TransformLogs(ld, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform logs: %v", err)
+	}
+	if logs[0].Body != "plain message" {
+		t.Errorf("Expected body 'plain message', got %q", logs[0].Body)
+	}
+	if logs[0].BodyJSON != nil {
+		t.Errorf("Expected no body_json for string bodies, got %v", logs[0].BodyJSON)
+	}
+}
+
+func TestTransformLogsEventName(t *testing.T) {
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetEventName("browser.page_view")
+	lr.Body().SetStr("page viewed")
+
+	logs, err := TransformLogs(ld, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform logs: %v", err)
+	}
+	if logs[0].EventName != "browser.page_view" {
+		t.Errorf("Expected event name browser.page_view, got %q", logs[0].EventName)
+	}
+	if logs[0].Body != "page viewed" {
+		t.Errorf("Expected the body to stay separate from the event name, got %q", logs[0].Body)
+	}
+}
+
+func TestTransformLogsMissingTimestamp(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+
+	observed := time.Unix(1700000000, 0)
+	withObserved := records.AppendEmpty()
+	withObserved.SetObservedTimestamp(pcommon.NewTimestampFromTime(observed))
+	withObserved.Body().SetStr("observed only")
+
+	unset := records.AppendEmpty()
+	unset.Body().SetStr("no timestamps")
+
+	stamped := records.AppendEmpty()
+	stamped.SetTimestamp(pcommon.NewTimestampFromTime(observed))
+	stamped.Body().SetStr("timestamped")
+
+	before := time.Now()
+	logs, err := TransformLogs(ld, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform logs: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("Expected 3 logs, got %d", len(logs))
+	}
+
+	if !logs[0].Timestamp.Equal(observed) || !logs[0].EstimatedTimestamp {
+		t.Errorf("Expected observed timestamp flagged as estimated, got %v (estimated: %t)", logs[0].Timestamp, logs[0].EstimatedTimestamp)
+	}
+	if logs[0].ObservedTimestamp == nil || !logs[0].ObservedTimestamp.Equal(observed) {
+		t.Errorf("Expected observed timestamp %v to be kept, got %v", observed, logs[0].ObservedTimestamp)
+	}
+	if logs[1].ObservedTimestamp != nil || logs[2].ObservedTimestamp != nil {
+		t.Errorf("Expected no observed timestamp when none was sent")
+	}
+	if logs[1].Timestamp.Before(before) || !logs[1].EstimatedTimestamp {
+		t.Errorf("Expected receipt time flagged as estimated, got %v (estimated: %t)", logs[1].Timestamp, logs[1].EstimatedTimestamp)
+	}
+	if !logs[2].Timestamp.Equal(observed) || logs[2].EstimatedTimestamp {
+		t.Errorf("Expected exporter timestamp kept, got %v (estimated: %t)", logs[2].Timestamp, logs[2].EstimatedTimestamp)
+	}
+}
+
+func TestTransformLogsDeeplyNestedValues(t *testing.T) {
+	const depth = 100
+
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+
+	// A 100-deep map attribute
+	nested := lr.Attributes().PutEmptyMap("payload")
+	for i := 0; i < depth; i++ {
+		nested = nested.PutEmptyMap("child")
+	}
+	nested.PutStr("leaf", "value")
+
+	// A 100-deep slice body
+	slice := lr.Body().SetEmptySlice()
+	for i := 0; i < depth; i++ {
+		slice = slice.AppendEmpty().SetEmptySlice()
+	}
+	slice.AppendEmpty().SetStr("leaf")
+
+	logs, err := TransformLogs(ld, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 log, got %d", len(logs))
+	}
+
+	value := logs[0].Attributes["payload"]
+	levels := 0
+	for {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		value = m["child"]
+		levels++
+	}
+	if value != truncatedAttributeValue || levels != maxAttributeDepth {
+		t.Errorf("Expected %q after %d levels, got %v after %d", truncatedAttributeValue, maxAttributeDepth, value, levels)
+	}
+
+	// The attributes must still be storable as JSON
+	if _, err := json.Marshal(logs[0].Attributes); err != nil {
+		t.Errorf("Failed to marshal truncated attributes: %v", err)
+	}
+
+	body := logs[0].Body
+	if !strings.Contains(body, truncatedAttributeValue) || strings.Contains(body, "leaf") {
+		t.Errorf("Expected body to be truncated before the leaf, got %q", body)
+	}
+}
+
+func TestTransformLogsNormalizeSeverity(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		number         plog.SeverityNumber
+		expectedText   string
+		expectedNumber int
+		original       string
+	}{
+		{"unspecified number keeps text", "custom", plog.SeverityNumberUnspecified, "custom", 0, ""},
+		{"empty text from number", "", plog.SeverityNumberInfo, "INFO", 9, ""},
+		{"lowercase alias", "warning", plog.SeverityNumberWarn, "WARN", 13, "warning"},
+		{"last trace", "", plog.SeverityNumberTrace4, "TRACE", 4, ""},
+		{"first debug", "", plog.SeverityNumberDebug, "DEBUG", 5, ""},
+		{"last info", "Info", plog.SeverityNumberInfo4, "INFO", 12, "Info"},
+		{"13 is warn", "", 13, "WARN", 13, ""},
+		{"last warn", "", plog.SeverityNumberWarn4, "WARN", 16, ""},
+		{"17 is error", "oops", 17, "ERROR", 17, "oops"},
+		{"last fatal", "", plog.SeverityNumberFatal4, "FATAL", 24, ""},
+		{"number wins over nonstandard text", "Debug", plog.SeverityNumberError, "ERROR", 17, "Debug"},
+		{"standard text kept", "DEBUG", plog.SeverityNumberError, "DEBUG", 17, ""},
+		{"standard numbered text kept", "INFO2", plog.SeverityNumberInfo2, "INFO2", 10, ""},
+		{"standard text fills number", "WARN3", plog.SeverityNumberUnspecified, "WARN3", 15, ""},
+		{"text only alias", "Warn", plog.SeverityNumberUnspecified, "WARN", 13, "Warn"},
+		{"canonical text unchanged", "ERROR", plog.SeverityNumberError, "ERROR", 17, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			lr.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+			lr.SetSeverityText(tt.text)
+			lr.SetSeverityNumber(tt.number)
+
+			logs, err := TransformLogs(ld, Options{NormalizeSeverity: true})
+			if err != nil {
+				t.Fatalf("Failed to transform logs: %v", err)
+			}
+			log := logs[0]
+			if log.SeverityText != tt.expectedText || log.SeverityNumber != tt.expectedNumber {
+				t.Errorf("Expected %s (%d), got %s (%d)", tt.expectedText, tt.expectedNumber, log.SeverityText, log.SeverityNumber)
+			}
+			original, ok := log.Attributes[originalSeverityTextKey]
+			if tt.original == "" && ok {
+				t.Errorf("Expected no original severity attribute, got %v", original)
+			}
+			if tt.original != "" && original != tt.original {
+				t.Errorf("Expected original severity %q, got %v", tt.original, original)
+			}
+		})
+	}
+
+	// Disabled by default
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetSeverityText("warning")
+	lr.SetSeverityNumber(plog.SeverityNumberWarn)
+	logs, _ := TransformLogs(ld, Options{})
+	if logs[0].SeverityText != "warning" {
+		t.Errorf("Expected severity text stored verbatim without normalization, got %s", logs[0].SeverityText)
+	}
+}
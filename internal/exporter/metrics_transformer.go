@@ -7,9 +7,13 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
-// TransformMetrics converts OTLP metrics to internal metric model
-func TransformMetrics(md pmetric.Metrics) ([]*store.MetricRecord, error) {
+// TransformMetrics converts OTLP metrics to internal metric model.
+// Metrics with an empty or unsupported type cannot be converted; they are
+// skipped and reported through the returned dropped count. Data points rejected
+// by opts.MissingTimestamp are omitted.
+func TransformMetrics(md pmetric.Metrics, opts Options) ([]*store.MetricRecord, int, error) {
 	metrics := make([]*store.MetricRecord, 0)
+	dropped := 0
 
 	// Iterate through resource metrics
 	for i := 0; i < md.ResourceMetrics().Len(); i++ {
@@ -25,29 +29,48 @@ func TransformMetrics(md pmetric.Metrics) ([]*store.MetricRecord, error) {
 			for k := 0; k < sm.Metrics().Len(); k++ {
 				metric := sm.Metrics().At(k)
 				metricName := metric.Name()
+				unit := metric.Unit()
+				description := metric.Description()
 
 				// Convert based on metric type
 				switch metric.Type() {
 				case pmetric.MetricTypeGauge:
-					metrics = append(metrics, transformGauge(metric.Gauge(), metricName, serviceName, resourceAttrs)...)
+					metrics = append(metrics, transformGauge(metric.Gauge(), metricName, unit, description, serviceName, resourceAttrs)...)
 				case pmetric.MetricTypeSum:
-					metrics = append(metrics, transformSum(metric.Sum(), metricName, serviceName, resourceAttrs)...)
+					metrics = append(metrics, transformSum(metric.Sum(), metricName, unit, description, serviceName, resourceAttrs)...)
 				case pmetric.MetricTypeHistogram:
-					metrics = append(metrics, transformHistogram(metric.Histogram(), metricName, serviceName, resourceAttrs)...)
+					metrics = append(metrics, transformHistogram(metric.Histogram(), metricName, unit, description, serviceName, resourceAttrs)...)
 				case pmetric.MetricTypeExponentialHistogram:
-					metrics = append(metrics, transformExponentialHistogram(metric.ExponentialHistogram(), metricName, serviceName, resourceAttrs)...)
+					metrics = append(metrics, transformExponentialHistogram(metric.ExponentialHistogram(), metricName, unit, description, serviceName, resourceAttrs)...)
 				case pmetric.MetricTypeSummary:
-					metrics = append(metrics, transformSummary(metric.Summary(), metricName, serviceName, resourceAttrs)...)
+					metrics = append(metrics, transformSummary(metric.Summary(), metricName, unit, description, serviceName, resourceAttrs)...)
+				default:
+					dropped++
 				}
 			}
 		}
 	}
 
-	return metrics, nil
+	// Apply the missing timestamp policy to data points without a timestamp
+	now := time.Now()
+	kept := metrics[:0]
+	for _, metric := range metrics {
+		if metric.Timestamp.UnixNano() == 0 {
+			timestamp, estimated, ok := opts.resolveTimestamp(0, now)
+			if !ok {
+				continue
+			}
+			metric.Timestamp = timestamp
+			metric.EstimatedTimestamp = estimated
+		}
+		kept = append(kept, metric)
+	}
+
+	return kept, dropped, nil
 }
 
 // transformGauge converts gauge metric to metric records
-func transformGauge(gauge pmetric.Gauge, metricName, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
+func transformGauge(gauge pmetric.Gauge, metricName, unit, description, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
 	records := make([]*store.MetricRecord, 0, gauge.DataPoints().Len())
 
 	for i := 0; i < gauge.DataPoints().Len(); i++ {
@@ -55,13 +78,15 @@ func transformGauge(gauge pmetric.Gauge, metricName, serviceName string, resourc
 		value := extractNumericValue(dp)
 
 		record := &store.MetricRecord{
-			Timestamp:  time.Unix(0, int64(dp.Timestamp())),
-			MetricName: metricName,
-			MetricType: "gauge",
+			Timestamp:   time.Unix(0, int64(dp.Timestamp())),
+			MetricName:  metricName,
+			MetricType:  "gauge",
 			ServiceName: serviceName,
-			Value:      &value,
-			Attributes: mergeAttributes(resourceAttrs, attributesToMap(dp.Attributes())),
-			Exemplars:  convertExemplars(dp.Exemplars()),
+			Value:       &value,
+			Unit:        unit,
+			Description: description,
+			Attributes:  mergeAttributes(resourceAttrs, attributesToMap(dp.Attributes())),
+			Exemplars:   convertExemplars(dp.Exemplars()),
 		}
 
 		records = append(records, record)
@@ -71,7 +96,7 @@ func transformGauge(gauge pmetric.Gauge, metricName, serviceName string, resourc
 }
 
 // transformSum converts sum metric to metric records
-func transformSum(sum pmetric.Sum, metricName, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
+func transformSum(sum pmetric.Sum, metricName, unit, description, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
 	records := make([]*store.MetricRecord, 0, sum.DataPoints().Len())
 
 	for i := 0; i < sum.DataPoints().Len(); i++ {
@@ -79,13 +104,17 @@ func transformSum(sum pmetric.Sum, metricName, serviceName string, resourceAttrs
 		value := extractNumericValue(dp)
 
 		record := &store.MetricRecord{
-			Timestamp:  time.Unix(0, int64(dp.Timestamp())),
-			MetricName: metricName,
-			MetricType: "sum",
+			Timestamp:   time.Unix(0, int64(dp.Timestamp())),
+			MetricName:  metricName,
+			MetricType:  "sum",
 			ServiceName: serviceName,
-			Value:      &value,
-			Attributes: mergeAttributes(resourceAttrs, attributesToMap(dp.Attributes())),
-			Exemplars:  convertExemplars(dp.Exemplars()),
+			Value:       &value,
+			Unit:        unit,
+			Description: description,
+			Attributes:  mergeAttributes(resourceAttrs, attributesToMap(dp.Attributes())),
+			Exemplars:   convertExemplars(dp.Exemplars()),
+			IsMonotonic: sum.IsMonotonic(),
+			Temporality: temporalityName(sum.AggregationTemporality()),
 		}
 
 		records = append(records, record)
@@ -94,42 +123,43 @@ func transformSum(sum pmetric.Sum, metricName, serviceName string, resourceAttrs
 	return records
 }
 
+// temporalityName is the stored name of an aggregation temporality, "" when unspecified
+func temporalityName(temporality pmetric.AggregationTemporality) string {
+	switch temporality {
+	case pmetric.AggregationTemporalityCumulative:
+		return "cumulative"
+	case pmetric.AggregationTemporalityDelta:
+		return "delta"
+	}
+	return ""
+}
+
 // transformHistogram converts histogram metric to metric records
-func transformHistogram(hist pmetric.Histogram, metricName, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
+func transformHistogram(hist pmetric.Histogram, metricName, unit, description, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
 	records := make([]*store.MetricRecord, 0, hist.DataPoints().Len())
 
 	for i := 0; i < hist.DataPoints().Len(); i++ {
 		dp := hist.DataPoints().At(i)
 
-		// Store histogram data in attributes
-		attrs := attributesToMap(dp.Attributes())
-		attrs["count"] = dp.Count()
-		attrs["sum"] = dp.Sum()
-
-		// Store bucket counts
-		buckets := make([]map[string]interface{}, 0, dp.BucketCounts().Len())
-		for j := 0; j < dp.BucketCounts().Len(); j++ {
-			bucket := map[string]interface{}{
-				"count": dp.BucketCounts().At(j),
-			}
-			if j < dp.ExplicitBounds().Len() {
-				bucket["upper_bound"] = dp.ExplicitBounds().At(j)
-			}
-			buckets = append(buckets, bucket)
-		}
-		attrs["buckets"] = buckets
-
 		// Use sum as the value
 		value := dp.Sum()
 
 		record := &store.MetricRecord{
-			Timestamp:  time.Unix(0, int64(dp.Timestamp())),
-			MetricName: metricName,
-			MetricType: "histogram",
+			Timestamp:   time.Unix(0, int64(dp.Timestamp())),
+			MetricName:  metricName,
+			MetricType:  "histogram",
 			ServiceName: serviceName,
-			Value:      &value,
-			Attributes: mergeAttributes(resourceAttrs, attrs),
-			Exemplars:  convertExemplars(dp.Exemplars()),
+			Value:       &value,
+			Unit:        unit,
+			Description: description,
+			Attributes:  mergeAttributes(resourceAttrs, attributesToMap(dp.Attributes())),
+			Exemplars:   convertExemplars(dp.Exemplars()),
+			Histogram: &store.HistogramData{
+				Bounds:       dp.ExplicitBounds().AsRaw(),
+				BucketCounts: dp.BucketCounts().AsRaw(),
+				Count:        dp.Count(),
+				Sum:          dp.Sum(),
+			},
 		}
 
 		records = append(records, record)
@@ -139,7 +169,7 @@ func transformHistogram(hist pmetric.Histogram, metricName, serviceName string,
 }
 
 // transformExponentialHistogram converts exponential histogram metric to metric records
-func transformExponentialHistogram(hist pmetric.ExponentialHistogram, metricName, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
+func transformExponentialHistogram(hist pmetric.ExponentialHistogram, metricName, unit, description, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
 	records := make([]*store.MetricRecord, 0, hist.DataPoints().Len())
 
 	for i := 0; i < hist.DataPoints().Len(); i++ {
@@ -155,13 +185,15 @@ func transformExponentialHistogram(hist pmetric.ExponentialHistogram, metricName
 		value := dp.Sum()
 
 		record := &store.MetricRecord{
-			Timestamp:  time.Unix(0, int64(dp.Timestamp())),
-			MetricName: metricName,
-			MetricType: "exponential_histogram",
+			Timestamp:   time.Unix(0, int64(dp.Timestamp())),
+			MetricName:  metricName,
+			MetricType:  "exponential_histogram",
 			ServiceName: serviceName,
-			Value:      &value,
-			Attributes: mergeAttributes(resourceAttrs, attrs),
-			Exemplars:  convertExemplars(dp.Exemplars()),
+			Value:       &value,
+			Unit:        unit,
+			Description: description,
+			Attributes:  mergeAttributes(resourceAttrs, attrs),
+			Exemplars:   convertExemplars(dp.Exemplars()),
 		}
 
 		records = append(records, record)
@@ -171,7 +203,7 @@ func transformExponentialHistogram(hist pmetric.ExponentialHistogram, metricName
 }
 
 // transformSummary converts summary metric to metric records
-func transformSummary(summary pmetric.Summary, metricName, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
+func transformSummary(summary pmetric.Summary, metricName, unit, description, serviceName string, resourceAttrs map[string]interface{}) []*store.MetricRecord {
 	records := make([]*store.MetricRecord, 0, summary.DataPoints().Len())
 
 	for i := 0; i < summary.DataPoints().Len(); i++ {
@@ -197,12 +229,14 @@ func transformSummary(summary pmetric.Summary, metricName, serviceName string, r
 		value := dp.Sum()
 
 		record := &store.MetricRecord{
-			Timestamp:  time.Unix(0, int64(dp.Timestamp())),
-			MetricName: metricName,
-			MetricType: "summary",
+			Timestamp:   time.Unix(0, int64(dp.Timestamp())),
+			MetricName:  metricName,
+			MetricType:  "summary",
 			ServiceName: serviceName,
-			Value:      &value,
-			Attributes: mergeAttributes(resourceAttrs, attrs),
+			Value:       &value,
+			Unit:        unit,
+			Description: description,
+			Attributes:  mergeAttributes(resourceAttrs, attrs),
 		}
 
 		records = append(records, record)
@@ -18,6 +18,8 @@ func TestTransformHistogramWithNoAttributes(t *testing.T) {
 	sm := rm.ScopeMetrics().AppendEmpty()
 	metric := sm.Metrics().AppendEmpty()
 	metric.SetName("http.server.duration")
+	metric.SetUnit("s")
+	metric.SetDescription("Duration of HTTP server requests")
 	metric.SetEmptyHistogram()
 
 	dp := metric.Histogram().DataPoints().AppendEmpty()
@@ -28,7 +30,7 @@ func TestTransformHistogramWithNoAttributes(t *testing.T) {
 	dp.BucketCounts().FromRaw([]uint64{1, 2, 3, 2, 1, 1})
 	// Sin atributos en el data point — esto causaba el panic
 
-	records, err := TransformMetrics(md)
+	records, _, err := TransformMetrics(md, Options{})
 	if err != nil {
 		t.Fatalf("TransformMetrics returned error: %v", err)
 	}
@@ -41,6 +43,12 @@ func TestTransformHistogramWithNoAttributes(t *testing.T) {
 	if records[0].ServiceName != "test-service" {
 		t.Errorf("expected ServiceName 'test-service', got %s", records[0].ServiceName)
 	}
+	if records[0].Unit != "s" {
+		t.Errorf("expected Unit 's', got %s", records[0].Unit)
+	}
+	if records[0].Description != "Duration of HTTP server requests" {
+		t.Errorf("expected Description to be preserved, got %s", records[0].Description)
+	}
 }
 
 // TestTransformExponentialHistogramWithNoAttributes verifica el mismo bug
@@ -62,7 +70,7 @@ func TestTransformExponentialHistogramWithNoAttributes(t *testing.T) {
 	dp.SetScale(1)
 	// Sin atributos en el data point
 
-	records, err := TransformMetrics(md)
+	records, _, err := TransformMetrics(md, Options{})
 	if err != nil {
 		t.Fatalf("TransformMetrics returned error: %v", err)
 	}
@@ -94,7 +102,7 @@ func TestTransformSummaryWithNoAttributes(t *testing.T) {
 	qv.SetValue(9.5)
 	// Sin atributos en el data point
 
-	records, err := TransformMetrics(md)
+	records, _, err := TransformMetrics(md, Options{})
 	if err != nil {
 		t.Fatalf("TransformMetrics returned error: %v", err)
 	}
@@ -105,3 +113,114 @@ func TestTransformSummaryWithNoAttributes(t *testing.T) {
 		t.Errorf("expected MetricType 'summary', got %s", records[0].MetricType)
 	}
 }
+
+// TestTransformMetricsDropsUnknownType verifica que las métricas sin tipo se
+// descartan y se reportan en el contador en lugar de perderse en silencio.
+func TestTransformMetricsDropsUnknownType(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test-service")
+
+	sm := rm.ScopeMetrics().AppendEmpty()
+	untyped := sm.Metrics().AppendEmpty()
+	untyped.SetName("untyped.metric")
+	// Sin SetEmpty* — el tipo queda en MetricTypeEmpty
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("queue.size")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	dp.SetIntValue(3)
+
+	records, dropped, err := TransformMetrics(md, Options{})
+	if err != nil {
+		t.Fatalf("TransformMetrics returned error: %v", err)
+	}
+	if dropped != 1 {
+		t.Errorf("expected 1 dropped metric, got %d", dropped)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].MetricName != "queue.size" {
+		t.Errorf("expected MetricName 'queue.size', got %s", records[0].MetricName)
+	}
+}
+
+// TestTransformHistogramBuckets verifica que los buckets se guardan en
+// HistogramData y no ensucian el mapa de atributos.
+func TestTransformHistogramBuckets(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test-service")
+
+	metric := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	metric.SetName("http.server.duration")
+	dp := metric.SetEmptyHistogram().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	dp.SetCount(6)
+	dp.SetSum(120.0)
+	dp.ExplicitBounds().FromRaw([]float64{10, 50})
+	dp.BucketCounts().FromRaw([]uint64{1, 3, 2})
+	dp.Attributes().PutStr("http.method", "GET")
+
+	records, _, err := TransformMetrics(md, Options{})
+	if err != nil {
+		t.Fatalf("TransformMetrics returned error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	record := records[0]
+	for _, key := range []string{"buckets", "count", "sum"} {
+		if _, ok := record.Attributes[key]; ok {
+			t.Errorf("expected attribute %q to be absent", key)
+		}
+	}
+	if record.Attributes["http.method"] != "GET" {
+		t.Errorf("expected http.method attribute to be kept, got %v", record.Attributes["http.method"])
+	}
+
+	h := record.Histogram
+	if h == nil {
+		t.Fatal("expected Histogram to be populated")
+	}
+	if len(h.Bounds) != 2 || len(h.BucketCounts) != 3 {
+		t.Fatalf("expected 2 bounds and 3 buckets, got %v / %v", h.Bounds, h.BucketCounts)
+	}
+	if h.BucketCounts[1] != 3 || h.Count != 6 || h.Sum != 120.0 {
+		t.Errorf("unexpected histogram data: %+v", h)
+	}
+}
+
+// TestTransformSumTemporality verifica que una suma acumulativa y monótona
+// conserva su temporalidad y monotonía.
+func TestTransformSumTemporality(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test-service")
+
+	metric := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	metric.SetName("http.server.request.count")
+	sum := metric.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	dp.SetIntValue(42)
+
+	records, _, err := TransformMetrics(md, Options{})
+	if err != nil {
+		t.Fatalf("TransformMetrics returned error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if !records[0].IsMonotonic {
+		t.Error("expected IsMonotonic to be true")
+	}
+	if records[0].Temporality != "cumulative" {
+		t.Errorf("expected Temporality 'cumulative', got %q", records[0].Temporality)
+	}
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Exporters that omit timestamps send 0, which would otherwise be stored as
+// 1970-01-01 and fall outside every time-range query. TimestampPolicy decides
+// what happens to such records.
+
+// TimestampPolicy selects how records without a timestamp are handled
+type TimestampPolicy string
+
+const (
+	// TimestampNow replaces a missing timestamp with the receipt time and flags the record as estimated
+	TimestampNow TimestampPolicy = "now"
+	// TimestampReject drops records without a timestamp
+	TimestampReject TimestampPolicy = "reject"
+	// TimestampZero stores the unset timestamp as-is (the Unix epoch)
+	TimestampZero TimestampPolicy = "zero"
+)
+
+// ParseTimestampPolicy validates a policy name; empty selects TimestampNow
+func ParseTimestampPolicy(name string) (TimestampPolicy, error) {
+	switch policy := TimestampPolicy(name); policy {
+	case "":
+		return TimestampNow, nil
+	case TimestampNow, TimestampReject, TimestampZero:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid missing timestamp policy %q (expected now, reject or zero)", name)
+	}
+}
+
+// Options configures the OTLP transformers. The zero value uses TimestampNow,
+// keeps every span and stores severity text verbatim.
+type Options struct {
+	MissingTimestamp TimestampPolicy
+	// MaxSpansPerTrace caps the spans kept per trace in one request; further
+	// spans are discarded and the trace is marked truncated. Zero means no limit.
+	MaxSpansPerTrace int
+	// NormalizeSeverity maps log severity to canonical text (TRACE ... FATAL),
+	// keeping the original text in an attribute
+	NormalizeSeverity bool
+	// TemplateOperationNames replaces numeric and UUID path segments in span
+	// names with placeholders, keeping the original name in an attribute
+	TemplateOperationNames bool
+}
+
+// resolveTimestamp applies the missing timestamp policy to ts. It returns the
+// time to store, whether it was estimated, and false if the record is rejected.
+func (o Options) resolveTimestamp(ts pcommon.Timestamp, now time.Time) (time.Time, bool, bool) {
+	if ts != 0 {
+		return time.Unix(0, int64(ts)), false, true
+	}
+
+	switch o.MissingTimestamp {
+	case TimestampReject:
+		return time.Time{}, false, false
+	case TimestampZero:
+		return time.Unix(0, 0), false, true
+	default:
+		return now, true, true
+	}
+}
+
+// resolveSpanTimes returns a span's start and end, whether they were estimated,
+// and false if the span is rejected. When only one timestamp is set it is used
+// for both, so a missing timestamp never produces a bogus duration.
+func (o Options) resolveSpanTimes(span ptrace.Span, now time.Time) (time.Time, time.Time, bool, bool) {
+	start, end := span.StartTimestamp(), span.EndTimestamp()
+	if start != 0 && end != 0 {
+		return time.Unix(0, int64(start)), time.Unix(0, int64(end)), false, true
+	}
+
+	switch o.MissingTimestamp {
+	case TimestampReject:
+		return time.Time{}, time.Time{}, false, false
+	case TimestampZero:
+		return time.Unix(0, int64(start)), time.Unix(0, int64(end)), false, true
+	}
+
+	switch {
+	case start == 0 && end == 0:
+		return now, now, true, true
+	case start == 0:
+		return time.Unix(0, int64(end)), time.Unix(0, int64(end)), true, true
+	default:
+		return time.Unix(0, int64(start)), time.Unix(0, int64(start)), true, true
+	}
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestParseTimestampPolicy(t *testing.T) {
+	for name, want := range map[string]TimestampPolicy{
+		"":       TimestampNow,
+		"now":    TimestampNow,
+		"reject": TimestampReject,
+		"zero":   TimestampZero,
+	} {
+		got, err := ParseTimestampPolicy(name)
+		if err != nil || got != want {
+			t.Errorf("Expected %q to parse as %q, got %q (err: %v)", name, want, got, err)
+		}
+	}
+
+	if _, err := ParseTimestampPolicy("later"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestMissingTimestampPolicies(t *testing.T) {
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID([16]byte{1})
+	span.SetSpanID([8]byte{1})
+	span.SetName("untimed")
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("untimed")
+
+	metrics := pmetric.NewMetrics()
+	gauge := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	gauge.SetName("untimed")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+
+	tests := []struct {
+		policy    TimestampPolicy
+		kept      bool
+		epoch     bool
+		estimated bool
+	}{
+		{TimestampNow, true, false, true},
+		{TimestampReject, false, false, false},
+		{TimestampZero, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			opts := Options{MissingTimestamp: tt.policy}
+			check := func(kind string, n int, ts time.Time, estimated bool) {
+				if !tt.kept {
+					if n != 0 {
+						t.Errorf("Expected %s to be rejected, got %d", kind, n)
+					}
+					return
+				}
+				if n != 1 {
+					t.Fatalf("Expected 1 %s, got %d", kind, n)
+				}
+				if isEpoch := ts.UnixNano() == 0; isEpoch != tt.epoch {
+					t.Errorf("Expected %s epoch timestamp %t, got %v", kind, tt.epoch, ts)
+				}
+				if estimated != tt.estimated {
+					t.Errorf("Expected %s estimated %t, got %t", kind, tt.estimated, estimated)
+				}
+			}
+
+			storedTraces, _, err := TransformTraces(traces, opts)
+			if err != nil {
+				t.Fatalf("Failed to transform traces: %v", err)
+			}
+			var spanTime time.Time
+			var spanEstimated bool
+			spanCount := 0
+			for _, trace := range storedTraces {
+				for _, span := range trace.Spans {
+					spanTime, spanEstimated = span.StartTime, span.EstimatedTimestamp
+					spanCount++
+				}
+			}
+			check("span", spanCount, spanTime, spanEstimated)
+
+			storedLogs, err := TransformLogs(logs, opts)
+			if err != nil {
+				t.Fatalf("Failed to transform logs: %v", err)
+			}
+			if len(storedLogs) > 0 {
+				check("log", len(storedLogs), storedLogs[0].Timestamp, storedLogs[0].EstimatedTimestamp)
+			} else {
+				check("log", 0, time.Time{}, false)
+			}
+
+			storedMetrics, _, err := TransformMetrics(metrics, opts)
+			if err != nil {
+				t.Fatalf("Failed to transform metrics: %v", err)
+			}
+			if len(storedMetrics) > 0 {
+				check("data point", len(storedMetrics), storedMetrics[0].Timestamp, storedMetrics[0].EstimatedTimestamp)
+			} else {
+				check("data point", 0, time.Time{}, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"encoding/hex"
+	"sort"
+
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TraceToOTLP rebuilds OTLP traces from a stored trace (the inverse of
+// TransformTraces). Spans are grouped into one resource per service and one
+// scope per instrumentation scope. Resource attributes other than service.name
+// are not stored per span, so they are not restored.
+func TraceToOTLP(trace *store.Trace) ptrace.Traces {
+	td := ptrace.NewTraces()
+
+	resources := make(map[string]ptrace.ResourceSpans)
+	scopes := make(map[string]ptrace.ScopeSpans)
+
+	// Keep the output stable regardless of the order spans were stored in
+	spans := make([]store.Span, len(trace.Spans))
+	copy(spans, trace.Spans)
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+
+	for _, span := range spans {
+		rs, ok := resources[span.ServiceName]
+		if !ok {
+			rs = td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("service.name", span.ServiceName)
+			resources[span.ServiceName] = rs
+		}
+
+		scopeKey := span.ServiceName + "\x00" + span.ScopeName + "\x00" + span.ScopeVersion
+		ss, ok := scopes[scopeKey]
+		if !ok {
+			ss = rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName(span.ScopeName)
+			ss.Scope().SetVersion(span.ScopeVersion)
+			scopes[scopeKey] = ss
+		}
+
+		fillOTLPSpan(ss.Spans().AppendEmpty(), &span)
+	}
+
+	return td
+}
+
+// fillOTLPSpan copies a stored span into an OTLP span
+func fillOTLPSpan(dest ptrace.Span, span *store.Span) {
+	dest.SetTraceID(parseTraceID(span.TraceID))
+	dest.SetSpanID(parseSpanID(span.SpanID))
+	if span.ParentSpanID != nil {
+		dest.SetParentSpanID(parseSpanID(*span.ParentSpanID))
+	}
+	dest.SetName(span.OperationName)
+	dest.SetKind(stringToSpanKind(span.SpanKind))
+	dest.SetStartTimestamp(pcommon.NewTimestampFromTime(span.StartTime))
+	dest.SetEndTimestamp(pcommon.NewTimestampFromTime(span.EndTime))
+	dest.Status().SetCode(ptrace.StatusCode(span.StatusCode))
+	if span.StatusMessage != nil {
+		dest.Status().SetMessage(*span.StatusMessage)
+	}
+	putAttributes(dest.Attributes(), span.Attributes)
+	dest.SetDroppedAttributesCount(uint32(span.DroppedAttributesCount))
+	dest.SetDroppedEventsCount(uint32(span.DroppedEventsCount))
+	dest.SetDroppedLinksCount(uint32(span.DroppedLinksCount))
+	dest.TraceState().FromRaw(span.TraceState)
+	if span.Sampled {
+		dest.SetFlags(traceFlagSampled)
+	}
+
+	for _, event := range span.Events {
+		e := dest.Events().AppendEmpty()
+		e.SetName(event.Name)
+		e.SetTimestamp(pcommon.NewTimestampFromTime(event.Timestamp))
+		putAttributes(e.Attributes(), event.Attributes)
+	}
+
+	for _, link := range span.Links {
+		l := dest.Links().AppendEmpty()
+		l.SetTraceID(parseTraceID(link.TraceID))
+		l.SetSpanID(parseSpanID(link.SpanID))
+		putAttributes(l.Attributes(), link.Attributes)
+	}
+}
+
+// putAttributes copies a stored attribute map into OTLP attributes. Values that
+// don't map to an OTLP type are skipped.
+func putAttributes(dest pcommon.Map, attrs map[string]interface{}) {
+	for k, v := range attrs {
+		if err := dest.PutEmpty(k).FromRaw(v); err != nil {
+			dest.Remove(k)
+		}
+	}
+}
+
+// parseTraceID decodes a hex trace ID; invalid IDs yield the empty ID
+func parseTraceID(id string) pcommon.TraceID {
+	var traceID pcommon.TraceID
+	if b, err := hex.DecodeString(id); err == nil && len(b) == len(traceID) {
+		copy(traceID[:], b)
+	}
+	return traceID
+}
+
+// parseSpanID decodes a hex span ID; invalid IDs yield the empty ID
+func parseSpanID(id string) pcommon.SpanID {
+	var spanID pcommon.SpanID
+	if b, err := hex.DecodeString(id); err == nil && len(b) == len(spanID) {
+		copy(spanID[:], b)
+	}
+	return spanID
+}
+
+// stringToSpanKind converts a span kind string back to the OTLP enum
+func stringToSpanKind(kind string) ptrace.SpanKind {
+	switch kind {
+	case "internal":
+		return ptrace.SpanKindInternal
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	default:
+		return ptrace.SpanKindUnspecified
+	}
+}
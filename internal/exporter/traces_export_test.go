@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestTraceToOTLPRoundTrip(t *testing.T) {
+	// Create OTLP traces with a parent and a child span
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("test-instrumentation")
+	ss.Scope().SetVersion("1.2.3")
+
+	traceID := pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	parentID := pcommon.SpanID([8]byte{1, 1, 1, 1, 1, 1, 1, 1})
+	childID := pcommon.SpanID([8]byte{2, 2, 2, 2, 2, 2, 2, 2})
+	start := time.Unix(1700000000, 123456789)
+
+	parent := ss.Spans().AppendEmpty()
+	parent.SetTraceID(traceID)
+	parent.SetSpanID(parentID)
+	parent.SetName("POST /checkout")
+	parent.SetKind(ptrace.SpanKindServer)
+	parent.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	parent.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(50 * time.Millisecond)))
+	parent.Status().SetCode(ptrace.StatusCodeError)
+	parent.Status().SetMessage("payment declined")
+	parent.Attributes().PutStr("http.method", "POST")
+	parent.Attributes().PutBool("retry", true)
+	event := parent.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(10 * time.Millisecond)))
+	event.Attributes().PutStr("exception.type", "PaymentError")
+
+	child := ss.Spans().AppendEmpty()
+	child.SetTraceID(traceID)
+	child.SetSpanID(childID)
+	child.SetParentSpanID(parentID)
+	child.SetName("charge card")
+	child.SetKind(ptrace.SpanKindClient)
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(start.Add(5 * time.Millisecond)))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(40 * time.Millisecond)))
+	child.SetDroppedEventsCount(3)
+	child.TraceState().FromRaw("vendor=abc")
+	child.SetFlags(0x01)
+	link := child.Links().AppendEmpty()
+	link.SetTraceID(pcommon.TraceID([16]byte{9}))
+	link.SetSpanID(pcommon.SpanID([8]byte{9}))
+
+	storeTraces, _, err := TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if len(storeTraces) != 1 {
+		t.Fatalf("Expected 1 trace, got %d", len(storeTraces))
+	}
+
+	// Export, then go through OTLP JSON as a client would
+	body, err := (&ptrace.JSONMarshaler{}).MarshalTraces(TraceToOTLP(storeTraces[0]))
+	if err != nil {
+		t.Fatalf("Failed to marshal traces: %v", err)
+	}
+	exported, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(body)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal exported traces: %v", err)
+	}
+
+	if exported.SpanCount() != 2 {
+		t.Fatalf("Expected 2 spans, got %d", exported.SpanCount())
+	}
+	if exported.ResourceSpans().Len() != 1 {
+		t.Fatalf("Expected 1 resource, got %d", exported.ResourceSpans().Len())
+	}
+
+	outRS := exported.ResourceSpans().At(0)
+	if name, _ := outRS.Resource().Attributes().Get("service.name"); name.Str() != "checkout" {
+		t.Errorf("Expected service.name checkout, got %s", name.Str())
+	}
+	outSS := outRS.ScopeSpans().At(0)
+	if outSS.Scope().Name() != "test-instrumentation" || outSS.Scope().Version() != "1.2.3" {
+		t.Errorf("Expected scope test-instrumentation 1.2.3, got %s %s", outSS.Scope().Name(), outSS.Scope().Version())
+	}
+
+	// Spans are exported in start time order
+	outParent := outSS.Spans().At(0)
+	outChild := outSS.Spans().At(1)
+
+	if outParent.TraceID() != traceID || outParent.SpanID() != parentID {
+		t.Errorf("Expected parent IDs %s/%s, got %s/%s", traceID, parentID, outParent.TraceID(), outParent.SpanID())
+	}
+	if !outParent.ParentSpanID().IsEmpty() {
+		t.Errorf("Expected root span without parent, got %s", outParent.ParentSpanID())
+	}
+	if outParent.Name() != "POST /checkout" || outParent.Kind() != ptrace.SpanKindServer {
+		t.Errorf("Expected server span POST /checkout, got %s %s", outParent.Kind(), outParent.Name())
+	}
+	if outParent.StartTimestamp() != parent.StartTimestamp() || outParent.EndTimestamp() != parent.EndTimestamp() {
+		t.Errorf("Expected timestamps to round-trip, got %s - %s", outParent.StartTimestamp(), outParent.EndTimestamp())
+	}
+	if outParent.Status().Code() != ptrace.StatusCodeError || outParent.Status().Message() != "payment declined" {
+		t.Errorf("Expected error status 'payment declined', got %s %q", outParent.Status().Code(), outParent.Status().Message())
+	}
+	if method, _ := outParent.Attributes().Get("http.method"); method.Str() != "POST" {
+		t.Errorf("Expected http.method POST, got %s", method.AsString())
+	}
+	if retry, _ := outParent.Attributes().Get("retry"); !retry.Bool() {
+		t.Errorf("Expected retry attribute true, got %s", retry.AsString())
+	}
+	if outParent.Events().Len() != 1 || outParent.Events().At(0).Name() != "exception" {
+		t.Fatalf("Expected exception event, got %d events", outParent.Events().Len())
+	}
+	if outParent.Events().At(0).Timestamp() != event.Timestamp() {
+		t.Errorf("Expected event timestamp to round-trip, got %s", outParent.Events().At(0).Timestamp())
+	}
+
+	if outChild.ParentSpanID() != parentID {
+		t.Errorf("Expected child parent %s, got %s", parentID, outChild.ParentSpanID())
+	}
+	if outChild.Kind() != ptrace.SpanKindClient {
+		t.Errorf("Expected client span, got %s", outChild.Kind())
+	}
+	if outChild.TraceState().AsRaw() != "vendor=abc" || outChild.Flags() != 0x01 {
+		t.Errorf("Expected trace state 'vendor=abc' and sampled flag, got %q and %#x", outChild.TraceState().AsRaw(), outChild.Flags())
+	}
+	if outChild.DroppedEventsCount() != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", outChild.DroppedEventsCount())
+	}
+	if outChild.Links().Len() != 1 || outChild.Links().At(0).SpanID() != link.SpanID() {
+		t.Errorf("Expected link to %s to round-trip", link.SpanID())
+	}
+}
+
+func TestStringToSpanKind(t *testing.T) {
+	kinds := []ptrace.SpanKind{
+		ptrace.SpanKindUnspecified,
+		ptrace.SpanKindInternal,
+		ptrace.SpanKindServer,
+		ptrace.SpanKindClient,
+		ptrace.SpanKindProducer,
+		ptrace.SpanKindConsumer,
+	}
+
+	for _, kind := range kinds {
+		if got := stringToSpanKind(spanKindToString(kind)); got != kind {
+			t.Errorf("Expected %s to round-trip, got %s", kind, got)
+		}
+	}
+}
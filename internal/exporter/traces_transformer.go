@@ -2,6 +2,8 @@ package exporter
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/mesaglio/otel-front/internal/store"
@@ -9,10 +11,34 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
-// TransformTraces converts OTLP traces to internal trace model
-func TransformTraces(td ptrace.Traces) ([]*store.Trace, error) {
+// traceFlagSampled is the W3C sampled bit in the low byte of OTLP span flags
+const traceFlagSampled = 0x01
+
+// originalOperationNameKey holds the span name when templating replaced it
+const originalOperationNameKey = "span.original_name"
+
+// uuidSegment matches a path segment that is a canonical UUID
+var uuidSegment = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// TraceStats reports the spans TransformTraces adjusted or discarded
+type TraceStats struct {
+	// NegativeDurations counts spans whose end preceded their start (e.g. clock
+	// skew); their duration is clamped to 0
+	NegativeDurations int
+	// TruncatedSpans counts spans discarded because their trace exceeded
+	// Options.MaxSpansPerTrace
+	TruncatedSpans int
+}
+
+// TransformTraces converts OTLP traces to internal trace model. Spans rejected
+// by opts.MissingTimestamp are omitted; spans beyond opts.MaxSpansPerTrace are
+// discarded and their trace is marked Truncated.
+func TransformTraces(td ptrace.Traces, opts Options) ([]*store.Trace, TraceStats, error) {
 	traces := make(map[string]*store.Trace)
 	allSpans := make(map[string][]store.Span)
+	heads := make(map[string]traceHead)
+	var stats TraceStats
+	now := time.Now()
 
 	// Iterate through resource spans
 	for i := 0; i < td.ResourceSpans().Len(); i++ {
@@ -23,6 +49,8 @@ func TransformTraces(td ptrace.Traces) ([]*store.Trace, error) {
 		// Iterate through scope spans
 		for j := 0; j < rs.ScopeSpans().Len(); j++ {
 			ss := rs.ScopeSpans().At(j)
+			scopeName := ss.Scope().Name()
+			scopeVersion := ss.Scope().Version()
 
 			// Iterate through spans
 			for k := 0; k < ss.Spans().Len(); k++ {
@@ -30,20 +58,50 @@ func TransformTraces(td ptrace.Traces) ([]*store.Trace, error) {
 				traceID := span.TraceID().String()
 				spanID := span.SpanID().String()
 
+				// Stop growing a pathological trace before converting more of its spans
+				if opts.MaxSpansPerTrace > 0 && len(allSpans[traceID]) >= opts.MaxSpansPerTrace {
+					traces[traceID].Truncated = true
+					stats.TruncatedSpans++
+					continue
+				}
+
+				startTime, endTime, estimated, ok := opts.resolveSpanTimes(span, now)
+				if !ok {
+					continue
+				}
+				durationMs := endTime.Sub(startTime).Milliseconds()
+				if durationMs < 0 {
+					durationMs = 0
+					stats.NegativeDurations++
+				}
+
 				// Convert span
 				convertedSpan := store.Span{
-					SpanID:        spanID,
-					TraceID:       traceID,
-					ServiceName:   serviceName,
-					OperationName: span.Name(),
-					SpanKind:      spanKindToString(span.Kind()),
-					StartTime:     time.Unix(0, int64(span.StartTimestamp())),
-					EndTime:       time.Unix(0, int64(span.EndTimestamp())),
-					DurationMs:    int64(span.EndTimestamp()-span.StartTimestamp()) / 1e6,
-					StatusCode:    int(span.Status().Code()),
-					Attributes:    attributesToMap(span.Attributes()),
-					Events:        convertEvents(span.Events()),
-					Links:         convertLinks(span.Links()),
+					SpanID:                 spanID,
+					TraceID:                traceID,
+					ServiceName:            serviceName,
+					OperationName:          span.Name(),
+					SpanKind:               spanKindToString(span.Kind()),
+					StartTime:              startTime,
+					EndTime:                endTime,
+					DurationMs:             durationMs,
+					StatusCode:             int(span.Status().Code()),
+					Attributes:             attributesToMap(span.Attributes()),
+					Events:                 convertEvents(span.Events()),
+					Links:                  convertLinks(span.Links()),
+					ScopeName:              scopeName,
+					ScopeVersion:           scopeVersion,
+					DroppedAttributesCount: int(span.DroppedAttributesCount()),
+					DroppedEventsCount:     int(span.DroppedEventsCount()),
+					DroppedLinksCount:      int(span.DroppedLinksCount()),
+					EstimatedTimestamp:     estimated,
+					TraceState:             span.TraceState().AsRaw(),
+					Sampled:                span.Flags()&traceFlagSampled != 0,
+					ResourceAttributes:     resourceAttrs,
+				}
+
+				if opts.TemplateOperationNames {
+					templateOperationName(&convertedSpan)
 				}
 
 				// Set parent span ID if exists
@@ -61,38 +119,21 @@ func TransformTraces(td ptrace.Traces) ([]*store.Trace, error) {
 				// Add span to the trace's span list
 				allSpans[traceID] = append(allSpans[traceID], convertedSpan)
 
-				// Create or update trace
-				if _, exists := traces[traceID]; !exists {
-					traces[traceID] = &store.Trace{
-						TraceID:       traceID,
-						ServiceName:   serviceName,
-						OperationName: span.Name(),
-						StartTime:     convertedSpan.StartTime,
-						EndTime:       convertedSpan.EndTime,
-						DurationMs:    convertedSpan.DurationMs,
-						SpanCount:     1,
-						ErrorCount:    0,
-						StatusCode:    convertedSpan.StatusCode,
-						Attributes:    mergeAttributes(resourceAttrs, convertedSpan.Attributes),
-					}
-				} else {
-					// Update trace timing and counts
-					trace := traces[traceID]
-					trace.SpanCount++
-
-					// Update start/end times
-					if convertedSpan.StartTime.Before(trace.StartTime) {
-						trace.StartTime = convertedSpan.StartTime
-					}
-					if convertedSpan.EndTime.After(trace.EndTime) {
-						trace.EndTime = convertedSpan.EndTime
-						trace.DurationMs = trace.EndTime.Sub(trace.StartTime).Milliseconds()
-					}
-
-					// Count errors
-					if convertedSpan.StatusCode == int(ptrace.StatusCodeError) {
-						trace.ErrorCount++
-					}
+				// Name the trace after its root span, or its earliest span until a
+				// root is seen; timing and counts are computed below
+				trace, exists := traces[traceID]
+				if !exists {
+					trace = &store.Trace{TraceID: traceID}
+					traces[traceID] = trace
+				}
+				head := traceHead{root: convertedSpan.ParentSpanID == nil, start: startTime}
+				if !exists || head.before(heads[traceID]) {
+					heads[traceID] = head
+					trace.ServiceName = serviceName
+					trace.OperationName = convertedSpan.OperationName
+					trace.StatusCode = convertedSpan.StatusCode
+					trace.Attributes = mergeAttributes(resourceAttrs, convertedSpan.Attributes)
+					trace.ResourceAttributes = resourceAttrs
 				}
 			}
 		}
@@ -102,10 +143,98 @@ func TransformTraces(td ptrace.Traces) ([]*store.Trace, error) {
 	result := make([]*store.Trace, 0, len(traces))
 	for traceID, trace := range traces {
 		trace.Spans = allSpans[traceID]
+		summarizeTraceTiming(trace)
 		result = append(result, trace)
 	}
 
-	return result, nil
+	return result, stats, nil
+}
+
+// traceHead is the span a trace is currently named after
+type traceHead struct {
+	root  bool
+	start time.Time
+}
+
+// before reports whether h should name the trace instead of other: a root span
+// wins over a child, and among equals the earlier start wins
+func (h traceHead) before(other traceHead) bool {
+	if h.root != other.root {
+		return h.root
+	}
+	return h.start.Before(other.start)
+}
+
+// summarizeTraceTiming sets the trace window from the earliest start and latest
+// end across all spans, so children that appear to start before their parent
+// (clock skew between services) still fall inside it. It also counts spans and errors.
+func summarizeTraceTiming(trace *store.Trace) {
+	trace.SpanCount = len(trace.Spans)
+	trace.ErrorCount = 0
+
+	for i, span := range trace.Spans {
+		if i == 0 || span.StartTime.Before(trace.StartTime) {
+			trace.StartTime = span.StartTime
+		}
+		if i == 0 || span.EndTime.After(trace.EndTime) {
+			trace.EndTime = span.EndTime
+		}
+		if span.StatusCode == int(ptrace.StatusCodeError) {
+			trace.ErrorCount++
+		}
+	}
+
+	trace.DurationMs = trace.EndTime.Sub(trace.StartTime).Milliseconds()
+	if trace.DurationMs < 0 {
+		trace.DurationMs = 0
+	}
+}
+
+// templateOperationName replaces numeric path segments in a span name with
+// {id} and UUID segments with {uuid}, so "GET /api/users/123" becomes
+// "GET /api/users/{id}" and each route is listed once. A changed name keeps the
+// original in the originalOperationNameKey attribute.
+func templateOperationName(span *store.Span) {
+	original := span.OperationName
+	if !strings.Contains(original, "/") {
+		return
+	}
+
+	segments := strings.Split(original, "/")
+	changed := false
+	for i, segment := range segments {
+		switch {
+		case isNumeric(segment):
+			segments[i] = "{id}"
+		case uuidSegment.MatchString(segment):
+			segments[i] = "{uuid}"
+		default:
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	span.OperationName = strings.Join(segments, "/")
+	if span.Attributes == nil {
+		span.Attributes = map[string]interface{}{}
+	}
+	span.Attributes[originalOperationNameKey] = original
+}
+
+// isNumeric reports whether s is a non-empty run of ASCII digits
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // convertEvents converts OTLP events to internal event model
@@ -144,22 +273,40 @@ func convertLinks(links ptrace.SpanLinkSlice) []store.SpanLink {
 	return result
 }
 
+// maxAttributeDepth is how deeply nested maps and slices inside an attribute
+// value are converted; deeper values are replaced by truncatedAttributeValue so
+// a crafted payload cannot exhaust the stack
+const maxAttributeDepth = 32
+
+// truncatedAttributeValue replaces values nested deeper than maxAttributeDepth
+const truncatedAttributeValue = "[truncated: nested too deep]"
+
 // attributesToMap converts OTLP attributes to a map
 func attributesToMap(attrs pcommon.Map) map[string]interface{} {
+	return attributesToMapAt(attrs, 0)
+}
+
+// attributesToMapAt converts an attribute map found at the given nesting depth
+func attributesToMapAt(attrs pcommon.Map, depth int) map[string]interface{} {
 	if attrs.Len() == 0 {
 		return make(map[string]interface{})
 	}
 
 	result := make(map[string]interface{}, attrs.Len())
 	attrs.Range(func(k string, v pcommon.Value) bool {
-		result[k] = valueToInterface(v)
+		result[k] = valueToInterface(v, depth)
 		return true
 	})
 	return result
 }
 
-// valueToInterface converts OTLP value to Go interface
-func valueToInterface(v pcommon.Value) interface{} {
+// valueToInterface converts OTLP value to Go interface. depth is the nesting
+// level of v; maps and slices beyond maxAttributeDepth are not converted.
+func valueToInterface(v pcommon.Value, depth int) interface{} {
+	if depth >= maxAttributeDepth && (v.Type() == pcommon.ValueTypeMap || v.Type() == pcommon.ValueTypeSlice) {
+		return truncatedAttributeValue
+	}
+
 	switch v.Type() {
 	case pcommon.ValueTypeStr:
 		return v.Str()
@@ -170,12 +317,12 @@ func valueToInterface(v pcommon.Value) interface{} {
 	case pcommon.ValueTypeBool:
 		return v.Bool()
 	case pcommon.ValueTypeMap:
-		return attributesToMap(v.Map())
+		return attributesToMapAt(v.Map(), depth+1)
 	case pcommon.ValueTypeSlice:
 		slice := v.Slice()
 		result := make([]interface{}, 0, slice.Len())
 		for i := 0; i < slice.Len(); i++ {
-			result = append(result, valueToInterface(slice.At(i)))
+			result = append(result, valueToInterface(slice.At(i), depth+1))
 		}
 		return result
 	case pcommon.ValueTypeBytes:
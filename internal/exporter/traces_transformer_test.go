@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mesaglio/otel-front/internal/store"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
@@ -18,6 +19,7 @@ func TestTransformTracesToStore(t *testing.T) {
 
 	ss := rs.ScopeSpans().AppendEmpty()
 	ss.Scope().SetName("test-instrumentation")
+	ss.Scope().SetVersion("0.42.0")
 
 	// Create span
 	span := ss.Spans().AppendEmpty()
@@ -33,9 +35,13 @@ func TestTransformTracesToStore(t *testing.T) {
 	span.Status().SetCode(ptrace.StatusCodeOk)
 	span.Attributes().PutStr("http.method", "GET")
 	span.Attributes().PutInt("http.status_code", 200)
+	span.SetDroppedAttributesCount(2)
+	span.SetDroppedLinksCount(1)
+	span.TraceState().FromRaw("congo=t61rcWkgMzE")
+	span.SetFlags(0x01)
 
 	// Transform to store format
-	storeTraces, err := TransformTraces(traces)
+	storeTraces, _, err := TransformTraces(traces, Options{})
 	if err != nil {
 		t.Fatalf("Failed to transform traces: %v", err)
 	}
@@ -69,6 +75,19 @@ func TestTransformTracesToStore(t *testing.T) {
 			if span.SpanID != "0102030405060708" {
 				t.Errorf("Expected span_id '0102030405060708', got %s", span.SpanID)
 			}
+			if span.ScopeName != "test-instrumentation" {
+				t.Errorf("Expected scope_name 'test-instrumentation', got %s", span.ScopeName)
+			}
+			if span.ScopeVersion != "0.42.0" {
+				t.Errorf("Expected scope_version '0.42.0', got %s", span.ScopeVersion)
+			}
+			if span.DroppedAttributesCount != 2 || span.DroppedLinksCount != 1 {
+				t.Errorf("Expected dropped attributes 2 and links 1, got %d and %d",
+					span.DroppedAttributesCount, span.DroppedLinksCount)
+			}
+			if span.TraceState != "congo=t61rcWkgMzE" || !span.Sampled {
+				t.Errorf("Expected trace state 'congo=t61rcWkgMzE' and sampled, got %q and %t", span.TraceState, span.Sampled)
+			}
 		}
 	}
 }
@@ -95,7 +114,7 @@ func TestTransformSpanWithEvent(t *testing.T) {
 	event.Attributes().PutStr("event.type", "info")
 
 	// Transform
-	storeTraces, err := TransformTraces(traces)
+	storeTraces, _, err := TransformTraces(traces, Options{})
 	if err != nil {
 		t.Fatalf("Failed to transform traces: %v", err)
 	}
@@ -111,3 +130,370 @@ func TestTransformSpanWithEvent(t *testing.T) {
 		}
 	}
 }
+
+func TestTransformTracesClockSkew(t *testing.T) {
+	traces := ptrace.NewTraces()
+	traceID := pcommon.TraceID([16]byte{1})
+	parentID := pcommon.SpanID([8]byte{1})
+	base := time.Unix(1700000000, 0)
+
+	// Parent from the frontend service
+	frontend := traces.ResourceSpans().AppendEmpty()
+	frontend.Resource().Attributes().PutStr("service.name", "frontend")
+	parent := frontend.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	parent.SetTraceID(traceID)
+	parent.SetSpanID(parentID)
+	parent.SetName("GET /checkout")
+	parent.SetStartTimestamp(pcommon.NewTimestampFromTime(base))
+	parent.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(100 * time.Millisecond)))
+
+	// Child from a backend whose clock runs 20ms behind, exported in a later resource
+	backend := traces.ResourceSpans().AppendEmpty()
+	backend.Resource().Attributes().PutStr("service.name", "backend")
+	backendSpans := backend.ScopeSpans().AppendEmpty().Spans()
+	child := backendSpans.AppendEmpty()
+	child.SetTraceID(traceID)
+	child.SetSpanID(pcommon.SpanID([8]byte{2}))
+	child.SetParentSpanID(parentID)
+	child.SetName("charge")
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(-20 * time.Millisecond)))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(150 * time.Millisecond)))
+	child.Status().SetCode(ptrace.StatusCodeError)
+
+	// A span whose end precedes its start
+	broken := backendSpans.AppendEmpty()
+	broken.SetTraceID(traceID)
+	broken.SetSpanID(pcommon.SpanID([8]byte{3}))
+	broken.SetParentSpanID(parentID)
+	broken.SetName("broken")
+	broken.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(50 * time.Millisecond)))
+	broken.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(40 * time.Millisecond)))
+
+	storeTraces, stats, err := TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if len(storeTraces) != 1 {
+		t.Fatalf("Expected 1 trace, got %d", len(storeTraces))
+	}
+
+	if stats.NegativeDurations != 1 {
+		t.Errorf("Expected 1 negative duration, got %d", stats.NegativeDurations)
+	}
+
+	trace := storeTraces[0]
+	if !trace.StartTime.Equal(base.Add(-20 * time.Millisecond)) {
+		t.Errorf("Expected trace to start at the skewed child, got %v", trace.StartTime)
+	}
+	if !trace.EndTime.Equal(base.Add(150 * time.Millisecond)) {
+		t.Errorf("Expected trace to end with the child, got %v", trace.EndTime)
+	}
+	if trace.DurationMs != 170 {
+		t.Errorf("Expected trace duration 170ms, got %d", trace.DurationMs)
+	}
+	if trace.SpanCount != 3 {
+		t.Errorf("Expected 3 spans, got %d", trace.SpanCount)
+	}
+	if trace.ErrorCount != 1 {
+		t.Errorf("Expected 1 error, got %d", trace.ErrorCount)
+	}
+
+	for _, span := range trace.Spans {
+		if span.OperationName == "broken" && span.DurationMs != 0 {
+			t.Errorf("Expected negative duration to be clamped to 0, got %d", span.DurationMs)
+		}
+	}
+}
+
+func TestTransformTracesNamedAfterRoot(t *testing.T) {
+	traces := ptrace.NewTraces()
+	traceID := pcommon.TraceID([16]byte{1})
+	rootID := pcommon.SpanID([8]byte{1})
+	base := time.Unix(1700000000, 0)
+
+	// The backend's child spans arrive before the frontend's root
+	backend := traces.ResourceSpans().AppendEmpty()
+	backend.Resource().Attributes().PutStr("service.name", "backend")
+	backendSpans := backend.ScopeSpans().AppendEmpty().Spans()
+	for i, name := range []string{"SELECT orders", "charge"} {
+		child := backendSpans.AppendEmpty()
+		child.SetTraceID(traceID)
+		child.SetSpanID(pcommon.SpanID([8]byte{byte(i + 2)}))
+		child.SetParentSpanID(rootID)
+		child.SetName(name)
+		// Starts before the root, as with clock skew
+		child.SetStartTimestamp(pcommon.NewTimestampFromTime(base.Add(-time.Duration(i+1) * time.Millisecond)))
+		child.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(50 * time.Millisecond)))
+		child.Status().SetCode(ptrace.StatusCodeError)
+	}
+
+	frontend := traces.ResourceSpans().AppendEmpty()
+	frontend.Resource().Attributes().PutStr("service.name", "frontend")
+	root := frontend.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	root.SetTraceID(traceID)
+	root.SetSpanID(rootID)
+	root.SetName("GET /checkout")
+	root.SetStartTimestamp(pcommon.NewTimestampFromTime(base))
+	root.SetEndTimestamp(pcommon.NewTimestampFromTime(base.Add(100 * time.Millisecond)))
+	root.Status().SetCode(ptrace.StatusCodeOk)
+
+	storeTraces, _, err := TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	trace := storeTraces[0]
+	if trace.OperationName != "GET /checkout" || trace.ServiceName != "frontend" {
+		t.Errorf("Expected trace named after the root span, got %s %s", trace.ServiceName, trace.OperationName)
+	}
+	if trace.StatusCode != int(ptrace.StatusCodeOk) {
+		t.Errorf("Expected the root's status code, got %d", trace.StatusCode)
+	}
+	if trace.Attributes["service.name"] != "frontend" {
+		t.Errorf("Expected the root's resource attributes, got %v", trace.Attributes)
+	}
+	if len(trace.ResourceAttributes) != 1 || trace.ResourceAttributes["service.name"] != "frontend" {
+		t.Errorf("Expected only the root's resource attributes kept separately, got %v", trace.ResourceAttributes)
+	}
+	for _, span := range trace.Spans {
+		if span.SpanID == rootID.String() && span.ResourceAttributes["service.name"] != "frontend" {
+			t.Errorf("Expected the root span to carry its resource attributes, got %v", span.ResourceAttributes)
+		}
+	}
+
+	// Without the root, the earliest span names the trace
+	traces.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		name, _ := rs.Resource().Attributes().Get("service.name")
+		return name.Str() == "frontend"
+	})
+	storeTraces, _, err = TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if name := storeTraces[0].OperationName; name != "charge" {
+		t.Errorf("Expected the earliest span to name a trace without root, got %s", name)
+	}
+}
+
+func TestTransformTracesEndBeforeStart(t *testing.T) {
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	start := time.Unix(1700000000, 0)
+	span.SetTraceID(pcommon.TraceID([16]byte{1}))
+	span.SetSpanID(pcommon.SpanID([8]byte{1}))
+	span.SetName("backwards")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(-time.Second)))
+
+	storeTraces, stats, err := TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if stats.NegativeDurations != 1 {
+		t.Errorf("Expected 1 negative duration, got %d", stats.NegativeDurations)
+	}
+	if len(storeTraces) != 1 || len(storeTraces[0].Spans) != 1 {
+		t.Fatalf("Expected the span to be kept, got %v", storeTraces)
+	}
+
+	// Unsigned timestamp arithmetic would wrap around to ~584 years here
+	trace := storeTraces[0]
+	if trace.Spans[0].DurationMs != 0 {
+		t.Errorf("Expected span duration 0, got %d", trace.Spans[0].DurationMs)
+	}
+	if trace.DurationMs != 0 {
+		t.Errorf("Expected trace duration 0, got %d", trace.DurationMs)
+	}
+}
+
+func TestTransformTracesMissingTimestamps(t *testing.T) {
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	end := time.Unix(1700000000, 0)
+
+	unset := spans.AppendEmpty()
+	unset.SetTraceID(pcommon.TraceID([16]byte{1}))
+	unset.SetSpanID(pcommon.SpanID([8]byte{1}))
+	unset.SetName("no timestamps")
+
+	endOnly := spans.AppendEmpty()
+	endOnly.SetTraceID(pcommon.TraceID([16]byte{2}))
+	endOnly.SetSpanID(pcommon.SpanID([8]byte{2}))
+	endOnly.SetName("end only")
+	endOnly.SetEndTimestamp(pcommon.NewTimestampFromTime(end))
+
+	before := time.Now()
+	storeTraces, stats, err := TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if stats.NegativeDurations != 0 {
+		t.Errorf("Expected no negative durations, got %d", stats.NegativeDurations)
+	}
+
+	spansByName := map[string]store.Span{}
+	for _, trace := range storeTraces {
+		for _, span := range trace.Spans {
+			spansByName[span.OperationName] = span
+		}
+		if trace.StartTime.Year() == 1970 {
+			t.Errorf("Expected trace %s not to start in 1970", trace.TraceID)
+		}
+	}
+
+	span := spansByName["no timestamps"]
+	if span.StartTime.Before(before) || !span.EstimatedTimestamp || span.DurationMs != 0 {
+		t.Errorf("Expected receipt time with zero duration, got %v (estimated: %t, duration: %d)", span.StartTime, span.EstimatedTimestamp, span.DurationMs)
+	}
+
+	span = spansByName["end only"]
+	if !span.StartTime.Equal(end) || !span.EndTime.Equal(end) || !span.EstimatedTimestamp {
+		t.Errorf("Expected start to fall back to the end time, got %v - %v (estimated: %t)", span.StartTime, span.EndTime, span.EstimatedTimestamp)
+	}
+}
+
+func TestTransformTracesMaxSpansPerTrace(t *testing.T) {
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	start := time.Unix(1700000000, 0)
+
+	big, small := pcommon.TraceID([16]byte{1}), pcommon.TraceID([16]byte{2})
+	for i := 0; i < 10; i++ {
+		traceID := big
+		if i >= 8 {
+			traceID = small
+		}
+		span := spans.AppendEmpty()
+		span.SetTraceID(traceID)
+		span.SetSpanID(pcommon.SpanID([8]byte{byte(i + 1)}))
+		span.SetName("work")
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Millisecond)))
+	}
+
+	storeTraces, stats, err := TransformTraces(traces, Options{MaxSpansPerTrace: 5})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	if stats.TruncatedSpans != 3 {
+		t.Errorf("Expected 3 truncated spans, got %d", stats.TruncatedSpans)
+	}
+
+	for _, trace := range storeTraces {
+		switch trace.TraceID {
+		case big.String():
+			if !trace.Truncated || len(trace.Spans) != 5 || trace.SpanCount != 5 {
+				t.Errorf("Expected big trace truncated to 5 spans, got truncated=%v spans=%d", trace.Truncated, len(trace.Spans))
+			}
+		case small.String():
+			if trace.Truncated || len(trace.Spans) != 2 {
+				t.Errorf("Expected small trace untouched, got truncated=%v spans=%d", trace.Truncated, len(trace.Spans))
+			}
+		}
+	}
+
+	_, stats, _ = TransformTraces(traces, Options{})
+	if stats.TruncatedSpans != 0 {
+		t.Errorf("Expected no truncation without a limit, got %d", stats.TruncatedSpans)
+	}
+}
+
+func TestAttributeNestingDepthLimit(t *testing.T) {
+	attrs := pcommon.NewMap()
+	nested := attrs.PutEmptyMap("root")
+	for i := 0; i < maxAttributeDepth+8; i++ {
+		nested = nested.PutEmptyMap("child")
+	}
+	nested.PutStr("leaf", "value")
+
+	value := attributesToMap(attrs)["root"]
+	depth := 0
+	for {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		value = m["child"]
+		depth++
+	}
+
+	if value != truncatedAttributeValue {
+		t.Fatalf("Expected nesting to end in %q, got %v", truncatedAttributeValue, value)
+	}
+	if depth != maxAttributeDepth {
+		t.Errorf("Expected %d converted levels, got %d", maxAttributeDepth, depth)
+	}
+
+	// Shallow values are converted in full
+	shallow := pcommon.NewMap()
+	shallow.PutEmptySlice("list").AppendEmpty().SetEmptyMap().PutStr("k", "v")
+	list := attributesToMap(shallow)["list"].([]interface{})
+	if list[0].(map[string]interface{})["k"] != "v" {
+		t.Errorf("Expected shallow nested value to be kept, got %v", list)
+	}
+}
+
+func TestTransformTracesTemplateOperationNames(t *testing.T) {
+	names := []struct {
+		name     string
+		expected string
+	}{
+		{"GET /api/users/123", "GET /api/users/{id}"},
+		{"GET /api/orders/3f2c9a1e-8b7d-4c6e-9f01-a2b3c4d5e6f7/items/7", "GET /api/orders/{uuid}/items/{id}"},
+		{"GET /api/users/me", "GET /api/users/me"},
+		{"SELECT users", "SELECT users"},
+	}
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	start := time.Unix(1700000000, 0)
+	for i, n := range names {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID([16]byte{byte(i + 1)}))
+		span.SetSpanID(pcommon.SpanID([8]byte{byte(i + 1)}))
+		span.SetName(n.name)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Millisecond)))
+	}
+
+	storeTraces, _, err := TransformTraces(traces, Options{TemplateOperationNames: true})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	byOriginal := make(map[string]*store.Trace, len(storeTraces))
+	for _, trace := range storeTraces {
+		original, ok := trace.Spans[0].Attributes[originalOperationNameKey].(string)
+		if !ok {
+			original = trace.Spans[0].OperationName
+		}
+		byOriginal[original] = trace
+	}
+
+	for _, n := range names {
+		trace, ok := byOriginal[n.name]
+		if !ok {
+			t.Errorf("Expected a trace for %q", n.name)
+			continue
+		}
+		if trace.Spans[0].OperationName != n.expected {
+			t.Errorf("Expected %q to become %q, got %q", n.name, n.expected, trace.Spans[0].OperationName)
+		}
+		if trace.OperationName != n.expected {
+			t.Errorf("Expected trace operation %q, got %q", n.expected, trace.OperationName)
+		}
+		_, kept := trace.Spans[0].Attributes[originalOperationNameKey]
+		if kept != (n.name != n.expected) {
+			t.Errorf("Expected original name attribute only on templated spans, %q has it: %v", n.name, kept)
+		}
+	}
+
+	// Disabled by default
+	storeTraces, _, err = TransformTraces(traces, Options{})
+	if err != nil {
+		t.Fatalf("Failed to transform traces: %v", err)
+	}
+	for _, trace := range storeTraces {
+		if _, ok := trace.Spans[0].Attributes[originalOperationNameKey]; ok {
+			t.Errorf("Expected %q left as-is without the option", trace.Spans[0].OperationName)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package pubsub fans out newly ingested telemetry to live subscribers such as
+// the log tail stream.
+package pubsub
+
+import "sync"
+
+// Event is a published item with its hub-assigned ID. IDs increase by one per
+// item, so a subscriber can resume after the last ID it saw.
+type Event[T any] struct {
+	ID   uint64
+	Data T
+}
+
+// Hub broadcasts published items to all subscribers and keeps the most recent
+// ones so reconnecting subscribers can catch up. Publishing never blocks: a
+// subscriber that falls more than its buffer behind misses events.
+type Hub[T any] struct {
+	mu     sync.Mutex
+	nextID uint64
+	recent []Event[T]
+	replay int
+	subs   map[chan Event[T]]struct{}
+	closed bool
+}
+
+// NewHub creates a hub that keeps the last replay events for resuming subscribers
+func NewHub[T any](replay int) *Hub[T] {
+	return &Hub[T]{
+		nextID: 1,
+		replay: replay,
+		subs:   make(map[chan Event[T]]struct{}),
+	}
+}
+
+// Publish assigns IDs to items and delivers them to every subscriber
+func (h *Hub[T]) Publish(items ...T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	for _, item := range items {
+		event := Event[T]{ID: h.nextID, Data: item}
+		h.nextID++
+
+		if h.replay > 0 {
+			h.recent = append(h.recent, event)
+			if len(h.recent) > h.replay {
+				h.recent = h.recent[len(h.recent)-h.replay:]
+			}
+		}
+
+		for ch := range h.subs {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every event published from now on,
+// preceded by the retained events after afterID (none when afterID is 0).
+// cancel must be called to release the subscription; the channel is closed by
+// cancel or when the hub is closed.
+func (h *Hub[T]) Subscribe(afterID uint64, buffer int) (<-chan Event[T], func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event[T]
+	if afterID > 0 {
+		for _, event := range h.recent {
+			if event.ID > afterID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan Event[T], buffer+len(missed))
+	for _, event := range missed {
+		ch <- event
+	}
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.subs[ch] = struct{}{}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subs[ch]; ok {
+				delete(h.subs, ch)
+				close(ch)
+			}
+		})
+	}
+	return ch, cancel
+}
+
+// Close ends every subscription; later publishes are ignored
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
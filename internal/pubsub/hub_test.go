@@ -0,0 +1,86 @@
+package pubsub
+
+import "testing"
+
+func receive(t *testing.T, ch <-chan Event[string], n int) []Event[string] {
+	t.Helper()
+	events := make([]Event[string], 0, n)
+	for i := 0; i < n; i++ {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		default:
+			t.Fatalf("Expected %d events, got %d", n, len(events))
+		}
+	}
+	return events
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub[string](10)
+
+	first, cancelFirst := hub.Subscribe(0, 10)
+	defer cancelFirst()
+	second, cancelSecond := hub.Subscribe(0, 10)
+
+	hub.Publish("a", "b")
+	for _, ch := range []<-chan Event[string]{first, second} {
+		events := receive(t, ch, 2)
+		if events[0].ID != 1 || events[0].Data != "a" || events[1].ID != 2 || events[1].Data != "b" {
+			t.Errorf("Expected events 1:a and 2:b, got %+v", events)
+		}
+	}
+
+	// A cancelled subscription is closed and receives nothing more
+	cancelSecond()
+	hub.Publish("c")
+	if _, ok := <-second; ok {
+		t.Error("Expected the cancelled subscription to be closed")
+	}
+	if events := receive(t, first, 1); events[0].Data != "c" {
+		t.Errorf("Expected event c, got %+v", events[0])
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	hub := NewHub[string](3)
+	hub.Publish("a", "b", "c", "d", "e")
+
+	// Only the last 3 events are retained
+	ch, cancel := hub.Subscribe(1, 10)
+	defer cancel()
+	events := receive(t, ch, 3)
+	if events[0].ID != 3 || events[2].ID != 5 {
+		t.Errorf("Expected events 3 to 5, got %+v", events)
+	}
+
+	ch, cancel = hub.Subscribe(4, 10)
+	defer cancel()
+	if events := receive(t, ch, 1); events[0].Data != "e" {
+		t.Errorf("Expected to resume with event e, got %+v", events[0])
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub[string](0)
+	ch, cancel := hub.Subscribe(0, 1)
+	defer cancel()
+
+	// Publishing never blocks on a full subscriber
+	hub.Publish("a", "b", "c")
+	if events := receive(t, ch, 1); events[0].Data != "a" {
+		t.Errorf("Expected the buffered event a, got %+v", events[0])
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub[string](10)
+	ch, cancel := hub.Subscribe(0, 10)
+	defer cancel()
+
+	hub.Close()
+	if _, ok := <-ch; ok {
+		t.Error("Expected subscriptions to be closed with the hub")
+	}
+	hub.Publish("ignored")
+}
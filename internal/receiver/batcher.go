@@ -0,0 +1,223 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/pubsub"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// defaultBatchInterval is used when batching is enabled without an interval
+const defaultBatchInterval = 200 * time.Millisecond
+
+// batchFlushTimeout bounds the writes of one flush, so a stuck database can't
+// hold up the flusher (and, once the queue fills, ingestion) indefinitely
+const batchFlushTimeout = 30 * time.Second
+
+// batchQueueSize bounds the number of pending export requests; handlers block
+// (applying backpressure to clients) once it is full
+const batchQueueSize = 256
+
+// errQueueFull is returned by enqueue when the queue stayed full until the
+// request's context ended. Clients are told to retry rather than that their
+// data was rejected.
+var errQueueFull = errors.New("ingest queue full")
+
+// batchItem holds the transformed records of a single export request
+type batchItem struct {
+	traces  []*store.Trace
+	logs    []*store.LogRecord
+	metrics []*store.MetricRecord
+}
+
+// size is the number of spans, log records and data points in the item
+func (b batchItem) size() int {
+	n := len(b.logs) + len(b.metrics)
+	for _, trace := range b.traces {
+		n += len(trace.Spans)
+	}
+	return n
+}
+
+// batcher accumulates export requests and writes them to the store in batches,
+// every interval or once maxItems spans/log records/data points are pending
+type batcher struct {
+	store    *store.Store
+	logger   *zap.Logger
+	ingested *ingestCounter
+	failed   *insertFailures
+	logHub   *pubsub.Hub[*store.LogRecord]
+
+	interval time.Duration
+	maxItems int
+
+	queue     chan batchItem
+	done      chan struct{}
+	closeOnce sync.Once
+	started   atomic.Bool // run has been started, by start or by close
+}
+
+func newBatcher(store *store.Store, logger *zap.Logger, ingested *ingestCounter, failed *insertFailures, logHub *pubsub.Hub[*store.LogRecord], interval time.Duration, maxItems int) *batcher {
+	return &batcher{
+		store:    store,
+		logger:   logger,
+		ingested: ingested,
+		failed:   failed,
+		logHub:   logHub,
+		interval: interval,
+		maxItems: maxItems,
+		queue:    make(chan batchItem, batchQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// start runs the background flusher
+func (b *batcher) start() {
+	if b.started.CompareAndSwap(false, true) {
+		go b.run()
+	}
+}
+
+// enqueue hands an item to the flusher, waiting for queue space until ctx is done
+func (b *batcher) enqueue(ctx context.Context, item batchItem) error {
+	select {
+	case b.queue <- item:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errQueueFull, ctx.Err())
+	}
+}
+
+// close stops accepting items and waits until everything queued is flushed.
+// If start was never called, the queue is drained here instead of waiting for a
+// flusher that doesn't exist. Callers must ensure no enqueue is in flight (i.e.
+// servers are stopped first).
+func (b *batcher) close() {
+	b.closeOnce.Do(func() {
+		close(b.queue)
+	})
+	if b.started.CompareAndSwap(false, true) {
+		b.run()
+		return
+	}
+	<-b.done
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	var pending batchItem
+	pendingItems := 0
+
+	for {
+		select {
+		case item, ok := <-b.queue:
+			if !ok {
+				b.flush(pending)
+				return
+			}
+			pending.traces = append(pending.traces, item.traces...)
+			pending.logs = append(pending.logs, item.logs...)
+			pending.metrics = append(pending.metrics, item.metrics...)
+			pendingItems += item.size()
+			if pendingItems >= b.maxItems {
+				b.flush(pending)
+				pending, pendingItems = batchItem{}, 0
+			}
+		case <-ticker.C:
+			b.flush(pending)
+			pending, pendingItems = batchItem{}, 0
+		}
+	}
+}
+
+// flush writes one batch per signal type. When a batch fails, the records it
+// did not store are retried one at a time, so a bad record only loses itself
+// rather than the other clients' data sharing the batch.
+func (b *batcher) flush(item batchItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), batchFlushTimeout)
+	defer cancel()
+
+	if len(item.traces) > 0 {
+		if err := b.store.Traces.InsertTraces(ctx, item.traces); err != nil {
+			b.logger.Warn("Failed to store trace batch, storing traces one by one",
+				zap.Int("traces", len(item.traces)), zap.Error(err))
+			for _, trace := range item.traces {
+				if err := b.store.Traces.InsertTrace(ctx, trace); err != nil {
+					b.failed.spans.Add(int64(len(trace.Spans)))
+					b.logger.Error("Failed to store trace", zap.String("trace_id", trace.TraceID), zap.Error(err))
+					continue
+				}
+				b.ingested.add(len(trace.Spans))
+			}
+		} else {
+			spans := 0
+			for _, trace := range item.traces {
+				spans += len(trace.Spans)
+			}
+			b.ingested.add(spans)
+			b.logger.Debug("Stored traces", zap.Int("count", len(item.traces)))
+		}
+	}
+
+	if len(item.logs) > 0 {
+		logs := make([]store.LogRecord, len(item.logs))
+		for i, log := range item.logs {
+			logs[i] = *log
+		}
+		// Chunks committed before a failure stay stored, so they are counted
+		// and tailed like a full success; the rest are retried one by one
+		stored, err := b.store.Logs.InsertLogs(ctx, logs)
+		if stored > 0 {
+			b.ingested.add(stored)
+			b.logHub.Publish(item.logs[:stored]...)
+			b.logger.Debug("Stored logs", zap.Int("count", stored))
+		}
+		if err != nil {
+			b.logger.Warn("Failed to store log batch, storing logs one by one",
+				zap.Int("logs", len(logs)-stored), zap.Error(err))
+			for _, log := range item.logs[stored:] {
+				if err := b.store.Logs.InsertLog(ctx, log); err != nil {
+					b.failed.logs.Add(1)
+					b.logger.Error("Failed to store log", zap.Error(err))
+					continue
+				}
+				b.ingested.add(1)
+				b.logHub.Publish(log)
+			}
+		}
+	}
+
+	if len(item.metrics) > 0 {
+		metrics := make([]store.MetricRecord, len(item.metrics))
+		for i, metric := range item.metrics {
+			metrics[i] = *metric
+		}
+		stored, err := b.store.Metrics.InsertMetrics(ctx, metrics)
+		if stored > 0 {
+			b.ingested.add(stored)
+			b.logger.Debug("Stored metrics", zap.Int("count", stored))
+		}
+		if err != nil {
+			b.logger.Warn("Failed to store metric batch, storing data points one by one",
+				zap.Int("metrics", len(metrics)-stored), zap.Error(err))
+			for _, metric := range item.metrics[stored:] {
+				if err := b.store.Metrics.InsertMetric(ctx, metric); err != nil {
+					b.failed.dataPoints.Add(1)
+					b.logger.Error("Failed to store metric", zap.String("metric", metric.MetricName), zap.Error(err))
+					continue
+				}
+				b.ingested.add(1)
+			}
+		}
+	}
+}
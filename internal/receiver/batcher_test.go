@@ -0,0 +1,269 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchedTracesDrainOnStop(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// A long interval and large batch size keep everything queued until Stop
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+	r.batcher.start()
+
+	for i := byte(1); i <= 3; i++ {
+		traces := ptrace.NewTraces()
+		span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID([16]byte{i}))
+		span.SetSpanID(pcommon.SpanID([8]byte{i}))
+		span.SetName("batched")
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+
+		rejected, err := r.processTraces(ctx, traces)
+		if err != nil || rejected != 0 {
+			t.Fatalf("Expected traces to be queued, got rejected=%d err=%v", rejected, err)
+		}
+	}
+
+	count, err := dataStore.Traces.CountTraces(ctx, store.TraceFilters{})
+	if err != nil {
+		t.Fatalf("Failed to count traces: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no traces stored before flush, got %d", count)
+	}
+
+	if err := r.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop receiver: %v", err)
+	}
+
+	count, err = dataStore.Traces.CountTraces(ctx, store.TraceFilters{})
+	if err != nil {
+		t.Fatalf("Failed to count traces: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 traces after drain, got %d", count)
+	}
+}
+
+func TestBatchFlushCountsPartialLogs(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	// One log per transaction, so the logs before a bad one stay stored
+	dataStore, err := store.NewStoreWithOptions(ctx, logger, store.Options{InsertChunkSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+	events, cancel := r.SubscribeLogs(0)
+	defer cancel()
+
+	// Attributes that can't be encoded store invalid JSON, failing the third log
+	r.batcher.flush(batchItem{logs: []*store.LogRecord{
+		{Timestamp: time.Now(), Body: "first", ServiceName: "api"},
+		{Timestamp: time.Now(), Body: "second", ServiceName: "api"},
+		{Timestamp: time.Now(), Body: "bad", ServiceName: "api", Attributes: map[string]interface{}{"c": make(chan int)}},
+	}})
+
+	if failed := r.FailedInserts()["logs"]; failed != 1 {
+		t.Errorf("Expected 1 failed log, got %d", failed)
+	}
+	for _, body := range []string{"first", "second"} {
+		select {
+		case event := <-events:
+			if event.Data.Body != body {
+				t.Errorf("Expected tailed log %q, got %q", body, event.Data.Body)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected stored log %q to be tailed", body)
+		}
+	}
+}
+
+func TestBatchFlushIsolatesBadRecords(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+
+	// Attributes that can't be encoded store invalid JSON, failing the whole batch
+	now := time.Now()
+	bad := map[string]interface{}{"c": make(chan int)}
+	trace := func(id string, attributes map[string]interface{}) *store.Trace {
+		return &store.Trace{
+			TraceID: id, ServiceName: "api", OperationName: "GET /", StartTime: now, EndTime: now,
+			Spans: []store.Span{{
+				TraceID: id, SpanID: id + "-span", ServiceName: "api", OperationName: "GET /",
+				StartTime: now, EndTime: now, Attributes: attributes,
+			}},
+		}
+	}
+	r.batcher.flush(batchItem{
+		traces: []*store.Trace{trace("good-1", nil), trace("bad", bad), trace("good-2", nil)},
+		logs: []*store.LogRecord{
+			{Timestamp: now, Body: "good", ServiceName: "api"},
+			{Timestamp: now, Body: "bad", ServiceName: "api", Attributes: bad},
+		},
+	})
+
+	failed := r.FailedInserts()
+	if failed["spans"] != 1 || failed["logs"] != 1 {
+		t.Errorf("Expected only the bad span and log to fail, got %v", failed)
+	}
+	traces, err := dataStore.Traces.CountTraces(ctx, store.TraceFilters{})
+	if err != nil {
+		t.Fatalf("Failed to count traces: %v", err)
+	}
+	if traces != 2 {
+		t.Errorf("Expected the 2 good traces stored, got %d", traces)
+	}
+	logs, err := dataStore.Logs.GetLogs(ctx, store.LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Body != "good" {
+		t.Errorf("Expected the good log stored, got %v", logs)
+	}
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// As with --disable-otlp, the receiver is never started
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+	if err := r.batcher.enqueue(ctx, batchItem{logs: []*store.LogRecord{
+		{Timestamp: time.Now(), Body: "queued", ServiceName: "api"},
+	}}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- r.Stop(ctx) }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Failed to stop receiver: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop hung on a batcher that was never started")
+	}
+
+	logs, err := dataStore.Logs.GetLogs(ctx, store.LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("Expected the queued log to be flushed on Stop, got %d", len(logs))
+	}
+}
+
+func TestFullQueueAsksClientsToRetry(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// Without a running flusher nothing drains the queue once it is full
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+	for i := 0; i < batchQueueSize; i++ {
+		if err := r.batcher.enqueue(ctx, batchItem{}); err != nil {
+			t.Fatalf("Failed to fill the queue: %v", err)
+		}
+	}
+
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID([16]byte{1}))
+	span.SetSpanID(pcommon.SpanID([8]byte{1}))
+	span.SetName("backpressure")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	request := ptraceotlp.NewExportRequestFromTraces(traces)
+
+	body, err := request.MarshalProto()
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body)).WithContext(reqCtx)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	r.httpHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After over HTTP, got %d (Retry-After %q)", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	grpcCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = (&traceService{receiver: r}).Export(grpcCtx, request)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable over gRPC, got %v", err)
+	}
+
+	if failed := r.FailedInserts()["spans"]; failed != 2 {
+		t.Errorf("Expected both unqueued spans counted as failed, got %d", failed)
+	}
+}
@@ -0,0 +1,54 @@
+package receiver
+
+import (
+	"sync"
+	"time"
+)
+
+// ingestWindowSeconds is the window the ingest rate is averaged over
+const ingestWindowSeconds = 60
+
+// ingestCounter counts ingested items in one-second slots over a rolling window
+type ingestCounter struct {
+	mu     sync.Mutex
+	counts [ingestWindowSeconds]int64
+	// seconds holds the unix second each slot was last written for
+	seconds [ingestWindowSeconds]int64
+	now     func() time.Time
+}
+
+func newIngestCounter() *ingestCounter {
+	return &ingestCounter{now: time.Now}
+}
+
+// add records n items ingested now
+func (c *ingestCounter) add(n int) {
+	if n <= 0 {
+		return
+	}
+	sec := c.now().Unix()
+	slot := sec % ingestWindowSeconds
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seconds[slot] != sec {
+		c.seconds[slot] = sec
+		c.counts[slot] = 0
+	}
+	c.counts[slot] += int64(n)
+}
+
+// rate returns the average items per second over the last window
+func (c *ingestCounter) rate() float64 {
+	sec := c.now().Unix()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total int64
+	for i := range c.counts {
+		if sec-c.seconds[i] < ingestWindowSeconds {
+			total += c.counts[i]
+		}
+	}
+	return float64(total) / ingestWindowSeconds
+}
@@ -0,0 +1,31 @@
+package receiver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIngestCounterRate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := newIngestCounter()
+	c.now = func() time.Time { return now }
+
+	c.add(30)
+	now = now.Add(10 * time.Second)
+	c.add(90)
+
+	if got := c.rate(); got != 2 {
+		t.Errorf("Expected rate 2/s, got %v", got)
+	}
+
+	// The first batch falls out of the window after a minute
+	now = now.Add(55 * time.Second)
+	if got := c.rate(); got != 1.5 {
+		t.Errorf("Expected rate 1.5/s, got %v", got)
+	}
+
+	now = now.Add(time.Hour)
+	if got := c.rate(); got != 0 {
+		t.Errorf("Expected rate 0 after idle period, got %v", got)
+	}
+}
@@ -0,0 +1,21 @@
+package receiver
+
+import "sync/atomic"
+
+// insertFailures counts the spans, log records and data points that could not
+// be stored, whether written per request or by the batcher. Batched failures
+// are otherwise only visible in the logs since the client was already answered.
+type insertFailures struct {
+	spans      atomic.Int64
+	logs       atomic.Int64
+	dataPoints atomic.Int64
+}
+
+// snapshot returns the totals per signal type
+func (f *insertFailures) snapshot() map[string]int64 {
+	return map[string]int64{
+		"spans":   f.spans.Load(),
+		"logs":    f.logs.Load(),
+		"metrics": f.dataPoints.Load(),
+	}
+}
@@ -3,12 +3,18 @@ package receiver
 import (
 	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/mesaglio/otel-front/internal/auth"
+	"github.com/mesaglio/otel-front/internal/config"
 	"github.com/mesaglio/otel-front/internal/exporter"
+	"github.com/mesaglio/otel-front/internal/pubsub"
 	"github.com/mesaglio/otel-front/internal/store"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
@@ -18,30 +24,139 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
+// logTailReplay is how many recent log records are kept for tails resuming
+// with Last-Event-ID; logTailBuffer is how far a tail may fall behind before
+// it misses records
+const (
+	logTailReplay = 1000
+	logTailBuffer = 256
+)
+
+// newLogHub creates the hub stored log records are published to
+func newLogHub() *pubsub.Hub[*store.LogRecord] {
+	return pubsub.NewHub[*store.LogRecord](logTailReplay)
+}
+
+// defaultGRPCMaxRecvBytes matches gRPC's own default receive limit
+const defaultGRPCMaxRecvBytes = 4 << 20
+
 // OTLPReceiver receives OTLP data via HTTP and gRPC
 type OTLPReceiver struct {
+	httpAddr   string // host:port for the HTTP receiver; an empty host listens on all interfaces
+	grpcAddr   string
 	httpPort   int
 	grpcPort   int
+	httpPrefix string // Extra base path for the OTLP HTTP endpoints, e.g. "/otlp"
+	authToken  string
+	tlsCert    string
+	tlsKey     string
+	tlsConfig  *tls.Config // Loaded on Start; nil serves plaintext
+	reflection bool        // Expose gRPC server reflection (debug only)
+	maxRecvMB  int         // Largest gRPC message accepted, in MiB; zero keeps the gRPC default of 4
+	timeouts   httpTimeouts
 	store      *store.Store
 	logger     *zap.Logger
 	httpServer *http.Server
 	grpcServer *grpc.Server
+	health     *health.Server
+	ingested   *ingestCounter
+	failed     *insertFailures
+	batcher    *batcher                      // nil when records are stored synchronously
+	logHub     *pubsub.Hub[*store.LogRecord] // Stored logs, for live tails
+	transform  exporter.Options              // Options passed to the OTLP transformers
 }
 
-// NewOTLPReceiver creates a new OTLP receiver
-func NewOTLPReceiver(httpPort, grpcPort int, store *store.Store, logger *zap.Logger) *OTLPReceiver {
-	return &OTLPReceiver{
-		httpPort: httpPort,
-		grpcPort: grpcPort,
-		store:    store,
-		logger:   logger,
+// NewOTLPReceiver creates a new OTLP receiver. With a positive batch size, records
+// are queued and written in batches in the background instead of per request.
+func NewOTLPReceiver(cfg *config.Config, store *store.Store, logger *zap.Logger) *OTLPReceiver {
+	r := &OTLPReceiver{
+		httpAddr:   cfg.Server.ListenAddr(cfg.Server.OTLPHTTPPort),
+		grpcAddr:   cfg.Server.ListenAddr(cfg.Server.OTLPGRPCPort),
+		httpPort:   cfg.Server.OTLPHTTPPort,
+		grpcPort:   cfg.Server.OTLPGRPCPort,
+		httpPrefix: normalizeHTTPPrefix(cfg.Server.OTLPHTTPPrefix),
+		authToken:  cfg.Server.AuthToken,
+		tlsCert:    cfg.Server.TLSCertFile,
+		tlsKey:     cfg.Server.TLSKeyFile,
+		reflection: cfg.Debug,
+		maxRecvMB:  cfg.Server.GRPCMaxRecvMB,
+		store:      store,
+		logger:     logger,
+		health:     health.NewServer(),
+		ingested:   newIngestCounter(),
+		failed:     &insertFailures{},
+		logHub:     newLogHub(),
+	}
+
+	// The policy is validated at startup; fall back to the default if it is not
+	if policy, err := exporter.ParseTimestampPolicy(cfg.Server.MissingTimestamp); err == nil {
+		r.transform.MissingTimestamp = policy
+	} else {
+		logger.Warn("Invalid missing timestamp policy, using default", zap.Error(err))
+		r.transform.MissingTimestamp = exporter.TimestampNow
+	}
+
+	r.transform.MaxSpansPerTrace = cfg.Server.MaxSpansPerTrace
+	r.transform.NormalizeSeverity = cfg.Server.NormalizeSeverity
+	r.transform.TemplateOperationNames = cfg.Server.TemplateOperationNames
+	r.timeouts = httpTimeouts{
+		readHeader: cfg.Server.ReadHeaderTimeout,
+		read:       cfg.Server.ReadTimeout,
+		write:      cfg.Server.WriteTimeout,
+		idle:       cfg.Server.IdleTimeout,
+	}
+
+	if cfg.Server.BatchSize > 0 {
+		interval := cfg.Server.BatchInterval
+		if interval <= 0 {
+			interval = defaultBatchInterval
+		}
+		r.batcher = newBatcher(store, logger, r.ingested, r.failed, r.logHub, interval, cfg.Server.BatchSize)
 	}
+
+	return r
+}
+
+// IngestRate returns the average number of spans, log records and data points
+// stored per second over the last minute
+func (r *OTLPReceiver) IngestRate() float64 {
+	return r.ingested.rate()
+}
+
+// FailedInserts returns the number of spans, log records and data points
+// (keyed spans, logs and metrics) that failed to store since startup
+func (r *OTLPReceiver) FailedInserts() map[string]int64 {
+	return r.failed.snapshot()
+}
+
+// SubscribeLogs streams log records as they are stored, starting with the
+// retained records after afterID (see pubsub.Hub.Subscribe). The channel is
+// closed when cancel is called or the receiver stops.
+func (r *OTLPReceiver) SubscribeLogs(afterID uint64) (<-chan pubsub.Event[*store.LogRecord], func()) {
+	return r.logHub.Subscribe(afterID, logTailBuffer)
 }
 
 // Start starts the OTLP receiver
 func (r *OTLPReceiver) Start(ctx context.Context) error {
+	tlsConfig, err := config.LoadTLSConfig(r.tlsCert, r.tlsKey)
+	if err != nil {
+		return err
+	}
+	r.tlsConfig = tlsConfig
+
+	if r.batcher != nil {
+		r.batcher.start()
+	}
+
 	// Start HTTP server
 	go func() {
 		if err := r.startHTTPServer(ctx); err != nil {
@@ -65,47 +180,173 @@ func (r *OTLPReceiver) Stop(ctx context.Context) error {
 		r.httpServer.Shutdown(ctx)
 	}
 	if r.grpcServer != nil {
+		r.health.Shutdown()
 		r.grpcServer.GracefulStop()
 	}
+	// Servers are stopped, so nothing else is enqueued; flush what is pending
+	if r.batcher != nil {
+		r.batcher.close()
+	}
+	// Everything is stored; end live tails
+	r.CloseLogTails()
 	return nil
 }
 
+// CloseLogTails ends all live log tails. Stop does this once pending logs are
+// stored; call it directly when the receiver was never started.
+func (r *OTLPReceiver) CloseLogTails() {
+	r.logHub.Close()
+}
+
+// httpTimeouts bounds the OTLP HTTP server's connections; zero values disable a
+// timeout. readHeader guards against slowloris clients that trickle in headers
+// to hold connections open.
+type httpTimeouts struct {
+	readHeader time.Duration
+	read       time.Duration
+	write      time.Duration
+	idle       time.Duration
+}
+
+// newHTTPServer creates the HTTP OTLP receiver's server
+func (r *OTLPReceiver) newHTTPServer() *http.Server {
+	return &http.Server{
+		Addr:              r.httpAddr,
+		Handler:           r.httpHandler(),
+		ReadHeaderTimeout: r.timeouts.readHeader,
+		ReadTimeout:       r.timeouts.read,
+		WriteTimeout:      r.timeouts.write,
+		IdleTimeout:       r.timeouts.idle,
+	}
+}
+
 // startHTTPServer starts the HTTP OTLP receiver
 func (r *OTLPReceiver) startHTTPServer(ctx context.Context) error {
+	r.httpServer = r.newHTTPServer()
+
+	r.logger.Info("Starting OTLP HTTP receiver", zap.Int("port", r.httpPort), zap.String("prefix", r.httpPrefix),
+		zap.Bool("tls", r.tlsConfig != nil))
+	if r.tlsConfig != nil {
+		r.httpServer.TLSConfig = r.tlsConfig.Clone()
+		return r.httpServer.ListenAndServeTLS("", "")
+	}
+	return r.httpServer.ListenAndServe()
+}
+
+// httpHandler builds the OTLP HTTP routes. The endpoints are always served at
+// /v1/..., and additionally under the configured prefix (e.g. /otlp/v1/traces).
+func (r *OTLPReceiver) httpHandler() http.Handler {
 	mux := http.NewServeMux()
 
-	// Register OTLP HTTP endpoints
-	mux.Handle("/v1/traces", gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPTraces)))
-	mux.Handle("/v1/logs", gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPLogs)))
-	mux.Handle("/v1/metrics", gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPMetrics)))
+	prefixes := []string{""}
+	if r.httpPrefix != "" {
+		prefixes = append(prefixes, r.httpPrefix)
+	}
 
-	r.httpServer = &http.Server{
-		Addr:    fmt.Sprintf(":%d", r.httpPort),
-		Handler: mux,
+	// Register OTLP HTTP endpoints
+	for _, prefix := range prefixes {
+		mux.Handle(prefix+"/v1/traces", r.authMiddleware(gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPTraces))))
+		mux.Handle(prefix+"/v1/logs", r.authMiddleware(gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPLogs))))
+		mux.Handle(prefix+"/v1/metrics", r.authMiddleware(gzipRequestMiddleware(http.HandlerFunc(r.handleHTTPMetrics))))
 	}
 
-	r.logger.Info("Starting OTLP HTTP receiver", zap.Int("port", r.httpPort))
-	return r.httpServer.ListenAndServe()
+	// Prometheus remote-write (snappy-compressed, so no gzip middleware)
+	mux.Handle("/api/v1/write", r.authMiddleware(http.HandlerFunc(r.handlePrometheusWrite)))
+
+	return mux
+}
+
+// normalizeHTTPPrefix turns "otlp", "/otlp/" etc. into "/otlp"; empty and "/" mean no prefix
+func normalizeHTTPPrefix(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
 }
 
 // startGRPCServer starts the gRPC OTLP receiver
 func (r *OTLPReceiver) startGRPCServer(ctx context.Context) error {
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", r.grpcPort))
+	lis, err := net.Listen("tcp", r.grpcAddr)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	r.grpcServer = grpc.NewServer()
+	r.grpcServer = r.newGRPCServer()
 
-	// Register gRPC services
-	ptraceotlp.RegisterGRPCServer(r.grpcServer, &traceService{receiver: r})
-	plogotlp.RegisterGRPCServer(r.grpcServer, &logService{receiver: r})
-	pmetricotlp.RegisterGRPCServer(r.grpcServer, &metricService{receiver: r})
+	// Report SERVING only once the store answers
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if err := r.store.Ping(ctx); err != nil {
+		r.logger.Warn("Store not ready, reporting NOT_SERVING", zap.Error(err))
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	r.health.SetServingStatus("", servingStatus)
 
-	r.logger.Info("Starting OTLP gRPC receiver", zap.Int("port", r.grpcPort))
+	r.logger.Info("Starting OTLP gRPC receiver", zap.Int("port", r.grpcPort), zap.Bool("tls", r.tlsConfig != nil),
+		zap.Int("max_recv_bytes", r.maxRecvBytes()))
 	return r.grpcServer.Serve(lis)
 }
 
+// newGRPCServer creates the gRPC server with the OTLP, health and (in debug
+// mode) reflection services registered
+func (r *OTLPReceiver) newGRPCServer() *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(r.authUnaryInterceptor),
+		grpc.MaxRecvMsgSize(r.maxRecvBytes()),
+	}
+	if r.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(r.tlsConfig.Clone())))
+	}
+	server := grpc.NewServer(opts...)
+
+	// Register gRPC services
+	ptraceotlp.RegisterGRPCServer(server, &traceService{receiver: r})
+	plogotlp.RegisterGRPCServer(server, &logService{receiver: r})
+	pmetricotlp.RegisterGRPCServer(server, &metricService{receiver: r})
+	healthpb.RegisterHealthServer(server, r.health)
+	if r.reflection {
+		reflection.Register(server)
+	}
+	return server
+}
+
+// maxRecvBytes is the largest gRPC message the server accepts. Busy collectors
+// batch well past gRPC's 4 MiB default, which otherwise fails with ResourceExhausted.
+func (r *OTLPReceiver) maxRecvBytes() int {
+	if r.maxRecvMB <= 0 {
+		return defaultGRPCMaxRecvBytes
+	}
+	return r.maxRecvMB << 20
+}
+
+// authMiddleware rejects requests without a valid bearer token when auth is enabled
+func (r *OTLPReceiver) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.authToken != "" && !auth.ValidBearerToken(req.Header.Get("Authorization"), r.authToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="otel-front"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// authUnaryInterceptor is the gRPC counterpart of authMiddleware, reading the
+// token from the "authorization" metadata key. Health checks are always allowed.
+func (r *OTLPReceiver) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if r.authToken == "" || strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if auth.ValidBearerToken(header, r.authToken) {
+			return handler(ctx, req)
+		}
+	}
+	return nil, status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
 // gzipRequestMiddleware transparently decompresses request bodies when
 // Content-Encoding: gzip is present, so handlers can always read req.Body directly.
 func gzipRequestMiddleware(next http.Handler) http.Handler {
@@ -126,7 +367,7 @@ func gzipRequestMiddleware(next http.Handler) http.Handler {
 
 // handleHTTPTraces handles HTTP trace requests
 func (r *OTLPReceiver) handleHTTPTraces(w http.ResponseWriter, req *http.Request) {
-	body, err := io.ReadAll(req.Body)
+	body, err := readPooledBody(req.Body)
 	if err != nil {
 		http.Error(w, "failed to read body", http.StatusBadRequest)
 		return
@@ -134,22 +375,30 @@ func (r *OTLPReceiver) handleHTTPTraces(w http.ResponseWriter, req *http.Request
 	defer req.Body.Close()
 
 	// Unmarshal protobuf
-	request := ptraceotlp.NewExportRequest()
-	if err := request.UnmarshalProto(body); err != nil {
+	request := getTraceRequest()
+	defer putTraceRequest(request)
+	err = request.UnmarshalProto(body.Bytes())
+	putBodyBuffer(body)
+	if err != nil {
 		http.Error(w, "failed to unmarshal protobuf", http.StatusBadRequest)
 		r.logger.Error("Failed to unmarshal traces", zap.Error(err))
 		return
 	}
 
-	// Process traces
-	if err := r.processTraces(req.Context(), request.Traces()); err != nil {
-		http.Error(w, "failed to process traces", http.StatusInternalServerError)
-		r.logger.Error("Failed to process traces", zap.Error(err))
+	// Process traces; storage failures are reported as a partial success, while a
+	// full ingest queue asks the client to retry
+	rejected, err := r.processTraces(req.Context(), request.Traces())
+	if errors.Is(err, errQueueFull) {
+		writeQueueFull(w)
 		return
 	}
 
 	// Send response
 	response := ptraceotlp.NewExportResponse()
+	if err != nil {
+		response.PartialSuccess().SetRejectedSpans(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
 	responseBytes, _ := response.MarshalProto()
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.Write(responseBytes)
@@ -157,7 +406,7 @@ func (r *OTLPReceiver) handleHTTPTraces(w http.ResponseWriter, req *http.Request
 
 // handleHTTPLogs handles HTTP log requests
 func (r *OTLPReceiver) handleHTTPLogs(w http.ResponseWriter, req *http.Request) {
-	body, err := io.ReadAll(req.Body)
+	body, err := readPooledBody(req.Body)
 	if err != nil {
 		http.Error(w, "failed to read body", http.StatusBadRequest)
 		return
@@ -165,22 +414,30 @@ func (r *OTLPReceiver) handleHTTPLogs(w http.ResponseWriter, req *http.Request)
 	defer req.Body.Close()
 
 	// Unmarshal protobuf
-	request := plogotlp.NewExportRequest()
-	if err := request.UnmarshalProto(body); err != nil {
+	request := getLogRequest()
+	defer putLogRequest(request)
+	err = request.UnmarshalProto(body.Bytes())
+	putBodyBuffer(body)
+	if err != nil {
 		http.Error(w, "failed to unmarshal protobuf", http.StatusBadRequest)
 		r.logger.Error("Failed to unmarshal logs", zap.Error(err))
 		return
 	}
 
-	// Process logs
-	if err := r.processLogs(req.Context(), request.Logs()); err != nil {
-		http.Error(w, "failed to process logs", http.StatusInternalServerError)
-		r.logger.Error("Failed to process logs", zap.Error(err))
+	// Process logs; storage failures are reported as a partial success, while a
+	// full ingest queue asks the client to retry
+	rejected, err := r.processLogs(req.Context(), request.Logs())
+	if errors.Is(err, errQueueFull) {
+		writeQueueFull(w)
 		return
 	}
 
 	// Send response
 	response := plogotlp.NewExportResponse()
+	if err != nil {
+		response.PartialSuccess().SetRejectedLogRecords(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
 	responseBytes, _ := response.MarshalProto()
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.Write(responseBytes)
@@ -188,7 +445,7 @@ func (r *OTLPReceiver) handleHTTPLogs(w http.ResponseWriter, req *http.Request)
 
 // handleHTTPMetrics handles HTTP metric requests
 func (r *OTLPReceiver) handleHTTPMetrics(w http.ResponseWriter, req *http.Request) {
-	body, err := io.ReadAll(req.Body)
+	body, err := readPooledBody(req.Body)
 	if err != nil {
 		http.Error(w, "failed to read body", http.StatusBadRequest)
 		return
@@ -196,76 +453,196 @@ func (r *OTLPReceiver) handleHTTPMetrics(w http.ResponseWriter, req *http.Reques
 	defer req.Body.Close()
 
 	// Unmarshal protobuf
-	request := pmetricotlp.NewExportRequest()
-	if err := request.UnmarshalProto(body); err != nil {
+	request := getMetricRequest()
+	defer putMetricRequest(request)
+	err = request.UnmarshalProto(body.Bytes())
+	putBodyBuffer(body)
+	if err != nil {
 		http.Error(w, "failed to unmarshal protobuf", http.StatusBadRequest)
 		r.logger.Error("Failed to unmarshal metrics", zap.Error(err))
 		return
 	}
 
-	// Process metrics
-	if err := r.processMetrics(req.Context(), request.Metrics()); err != nil {
-		http.Error(w, "failed to process metrics", http.StatusInternalServerError)
-		r.logger.Error("Failed to process metrics", zap.Error(err))
+	// Process metrics; storage failures are reported as a partial success, while a
+	// full ingest queue asks the client to retry
+	rejected, err := r.processMetrics(req.Context(), request.Metrics())
+	if errors.Is(err, errQueueFull) {
+		writeQueueFull(w)
 		return
 	}
 
 	// Send response
 	response := pmetricotlp.NewExportResponse()
+	if err != nil {
+		response.PartialSuccess().SetRejectedDataPoints(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
 	responseBytes, _ := response.MarshalProto()
 	w.Header().Set("Content-Type", "application/x-protobuf")
 	w.Write(responseBytes)
 }
 
-// processTraces transforms and stores traces
-func (r *OTLPReceiver) processTraces(ctx context.Context, td ptrace.Traces) error {
-	traces, err := exporter.TransformTraces(td)
+// writeQueueFull answers an export that could not be queued with 503 and
+// Retry-After, which OTLP exporters retry instead of dropping the data
+func writeQueueFull(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "ingest queue full, retry later", http.StatusServiceUnavailable)
+}
+
+// processTraces transforms and stores traces. Traces that fail to store do not
+// abort the batch; the number of rejected spans is returned with the first error.
+func (r *OTLPReceiver) processTraces(ctx context.Context, td ptrace.Traces) (int, error) {
+	traces, stats, err := exporter.TransformTraces(td, r.transform)
 	if err != nil {
-		return err
+		return td.SpanCount(), err
+	}
+	if stats.NegativeDurations > 0 {
+		r.logger.Warn("Clamped negative span durations to 0", zap.Int("spans", stats.NegativeDurations))
+	}
+	if stats.TruncatedSpans > 0 {
+		truncated := []string{}
+		for _, trace := range traces {
+			if trace.Truncated {
+				truncated = append(truncated, trace.TraceID)
+			}
+		}
+		r.logger.Warn("Discarded spans beyond the per-trace limit",
+			zap.Int("spans", stats.TruncatedSpans),
+			zap.Int("max_spans_per_trace", r.transform.MaxSpansPerTrace),
+			zap.Strings("trace_ids", truncated))
+	}
+	spans := 0
+	for _, trace := range traces {
+		spans += len(trace.Spans)
+	}
+	// Truncated spans are reported as rejected but are not a timestamp problem
+	missing := td.SpanCount() - spans - stats.TruncatedSpans
+
+	if r.batcher != nil {
+		if err := r.batcher.enqueue(ctx, batchItem{traces: traces}); err != nil {
+			r.failed.spans.Add(int64(td.SpanCount()))
+			return td.SpanCount(), err
+		}
+		return missing + stats.TruncatedSpans, r.missingTimestampError("spans", missing)
 	}
 
+	rejected, failed := 0, 0
+	var firstErr error
 	for _, trace := range traces {
 		if err := r.store.Traces.InsertTrace(ctx, trace); err != nil {
-			return err
+			rejected += len(trace.Spans)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 
+	r.ingested.add(spans - rejected)
+	r.failed.spans.Add(int64(rejected))
+
+	if firstErr != nil {
+		r.logger.Warn("Rejected spans", zap.Int("rejected", rejected), zap.Error(firstErr))
+		return rejected + missing + stats.TruncatedSpans, fmt.Errorf("failed to store %d of %d traces: %w", failed, len(traces), firstErr)
+	}
+
 	r.logger.Debug("Stored traces", zap.Int("count", len(traces)))
-	return nil
+	return missing + stats.TruncatedSpans, r.missingTimestampError("spans", missing)
 }
 
-// processLogs transforms and stores logs
-func (r *OTLPReceiver) processLogs(ctx context.Context, ld plog.Logs) error {
-	logs, err := exporter.TransformLogs(ld)
+// processLogs transforms and stores logs, returning the number of rejected log records
+func (r *OTLPReceiver) processLogs(ctx context.Context, ld plog.Logs) (int, error) {
+	logs, err := exporter.TransformLogs(ld, r.transform)
 	if err != nil {
-		return err
+		return ld.LogRecordCount(), err
+	}
+	missing := ld.LogRecordCount() - len(logs)
+
+	if r.batcher != nil {
+		if err := r.batcher.enqueue(ctx, batchItem{logs: logs}); err != nil {
+			r.failed.logs.Add(int64(ld.LogRecordCount()))
+			return ld.LogRecordCount(), err
+		}
+		return missing, r.missingTimestampError("log records", missing)
 	}
 
+	rejected := 0
+	var firstErr error
+	stored := make([]*store.LogRecord, 0, len(logs))
 	for _, log := range logs {
 		if err := r.store.Logs.InsertLog(ctx, log); err != nil {
-			return err
+			rejected++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
 		}
+		stored = append(stored, log)
+	}
+
+	r.ingested.add(len(stored))
+	r.failed.logs.Add(int64(rejected))
+	r.logHub.Publish(stored...)
+
+	if firstErr != nil {
+		r.logger.Warn("Rejected log records", zap.Int("rejected", rejected), zap.Error(firstErr))
+		return rejected + missing, fmt.Errorf("failed to store %d of %d log records: %w", rejected, len(logs), firstErr)
 	}
 
 	r.logger.Debug("Stored logs", zap.Int("count", len(logs)))
-	return nil
+	return missing, r.missingTimestampError("log records", missing)
 }
 
-// processMetrics transforms and stores metrics
-func (r *OTLPReceiver) processMetrics(ctx context.Context, md pmetric.Metrics) error {
-	metrics, err := exporter.TransformMetrics(md)
+// processMetrics transforms and stores metrics, returning the number of rejected data points
+func (r *OTLPReceiver) processMetrics(ctx context.Context, md pmetric.Metrics) (int, error) {
+	metrics, dropped, err := exporter.TransformMetrics(md, r.transform)
 	if err != nil {
-		return err
+		return md.DataPointCount(), err
+	}
+	if dropped > 0 {
+		r.logger.Warn("Dropped metrics with unsupported type", zap.Int("dropped", dropped))
+	}
+	missing := md.DataPointCount() - len(metrics)
+
+	if r.batcher != nil {
+		if err := r.batcher.enqueue(ctx, batchItem{metrics: metrics}); err != nil {
+			r.failed.dataPoints.Add(int64(md.DataPointCount()))
+			return md.DataPointCount(), err
+		}
+		return missing, r.missingTimestampError("data points", missing)
 	}
 
+	rejected := 0
+	var firstErr error
 	for _, metric := range metrics {
 		if err := r.store.Metrics.InsertMetric(ctx, metric); err != nil {
-			return err
+			rejected++
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 
+	r.ingested.add(len(metrics) - rejected)
+	r.failed.dataPoints.Add(int64(rejected))
+
+	if firstErr != nil {
+		r.logger.Warn("Rejected metric data points", zap.Int("rejected", rejected), zap.Error(firstErr))
+		return rejected + missing, fmt.Errorf("failed to store %d of %d data points: %w", rejected, len(metrics), firstErr)
+	}
+
 	r.logger.Debug("Stored metrics", zap.Int("count", len(metrics)))
-	return nil
+	return missing, r.missingTimestampError("data points", missing)
+}
+
+// missingTimestampError logs and reports records dropped by the "reject"
+// missing timestamp policy; it returns nil when none were dropped
+func (r *OTLPReceiver) missingTimestampError(kind string, rejected int) error {
+	if rejected == 0 {
+		return nil
+	}
+	r.logger.Warn("Rejected records without timestamps", zap.String("kind", kind), zap.Int("rejected", rejected))
+	return fmt.Errorf("rejected %d %s without timestamps", rejected, kind)
 }
 
 // gRPC service implementations
@@ -276,8 +653,16 @@ type traceService struct {
 }
 
 func (s *traceService) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
-	err := s.receiver.processTraces(ctx, req.Traces())
-	return ptraceotlp.NewExportResponse(), err
+	response := ptraceotlp.NewExportResponse()
+	rejected, err := s.receiver.processTraces(ctx, req.Traces())
+	if errors.Is(err, errQueueFull) {
+		return response, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		response.PartialSuccess().SetRejectedSpans(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
+	return response, nil
 }
 
 type logService struct {
@@ -286,8 +671,16 @@ type logService struct {
 }
 
 func (s *logService) Export(ctx context.Context, req plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
-	err := s.receiver.processLogs(ctx, req.Logs())
-	return plogotlp.NewExportResponse(), err
+	response := plogotlp.NewExportResponse()
+	rejected, err := s.receiver.processLogs(ctx, req.Logs())
+	if errors.Is(err, errQueueFull) {
+		return response, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		response.PartialSuccess().SetRejectedLogRecords(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
+	return response, nil
 }
 
 type metricService struct {
@@ -296,6 +689,14 @@ type metricService struct {
 }
 
 func (s *metricService) Export(ctx context.Context, req pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
-	err := s.receiver.processMetrics(ctx, req.Metrics())
-	return pmetricotlp.NewExportResponse(), err
+	response := pmetricotlp.NewExportResponse()
+	rejected, err := s.receiver.processMetrics(ctx, req.Metrics())
+	if errors.Is(err, errQueueFull) {
+		return response, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		response.PartialSuccess().SetRejectedDataPoints(int64(rejected))
+		response.PartialSuccess().SetErrorMessage(err.Error())
+	}
+	return response, nil
 }
@@ -0,0 +1,400 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestTraceExportPartialSuccess(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	// A closed store makes every insert fail
+	dataStore.Close()
+
+	r := NewOTLPReceiver(&config.Config{}, dataStore, logger)
+	service := &traceService{receiver: r}
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for i := byte(1); i <= 2; i++ {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID([16]byte{1}))
+		span.SetSpanID(pcommon.SpanID([8]byte{i}))
+		span.SetName("op")
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	}
+
+	req := ptraceotlp.NewExportRequestFromTraces(traces)
+	resp, err := service.Export(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected partial success instead of an error, got %v", err)
+	}
+
+	if got := resp.PartialSuccess().RejectedSpans(); got != 2 {
+		t.Errorf("Expected 2 rejected spans, got %d", got)
+	}
+	if resp.PartialSuccess().ErrorMessage() == "" {
+		t.Error("Expected an error message on partial success")
+	}
+}
+
+func TestHTTPPrefixedTracesPath(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{OTLPHTTPPrefix: "otlp/"}}
+	handler := NewOTLPReceiver(cfg, dataStore, logger).httpHandler()
+
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID([16]byte{7}))
+	span.SetSpanID(pcommon.SpanID([8]byte{7}))
+	span.SetName("prefixed")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	body, err := ptraceotlp.NewExportRequestFromTraces(traces).MarshalProto()
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	for _, path := range []string{"/otlp/v1/traces", "/v1/traces"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	if count, err := dataStore.Traces.CountTraces(ctx, store.TraceFilters{}); err != nil || count != 1 {
+		t.Errorf("Expected 1 stored trace, got %d (err: %v)", count, err)
+	}
+}
+
+func TestRejectMissingTimestamps(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{MissingTimestamp: "reject"}}
+	service := &traceService{receiver: NewOTLPReceiver(cfg, dataStore, logger)}
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for i := byte(1); i <= 3; i++ {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID([16]byte{i}))
+		span.SetSpanID(pcommon.SpanID([8]byte{i}))
+		span.SetName("op")
+		if i == 1 {
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+			span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		}
+	}
+
+	resp, err := service.Export(ctx, ptraceotlp.NewExportRequestFromTraces(traces))
+	if err != nil {
+		t.Fatalf("Expected partial success instead of an error, got %v", err)
+	}
+
+	if got := resp.PartialSuccess().RejectedSpans(); got != 2 {
+		t.Errorf("Expected 2 rejected spans, got %d", got)
+	}
+	if count, err := dataStore.Traces.CountTraces(ctx, store.TraceFilters{}); err != nil || count != 1 {
+		t.Errorf("Expected 1 stored trace, got %d (err: %v)", count, err)
+	}
+}
+
+func TestSubscribeLogs(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	r := NewOTLPReceiver(&config.Config{}, dataStore, logger)
+	events, cancel := r.SubscribeLogs(0)
+	defer cancel()
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range []string{"first", "second"} {
+		record := records.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		record.Body().SetStr(body)
+	}
+	if _, err := r.processLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to process logs: %v", err)
+	}
+
+	for i, body := range []string{"first", "second"} {
+		select {
+		case event := <-events:
+			if event.ID != uint64(i+1) || event.Data.Body != body {
+				t.Errorf("Expected event %d with body %q, got %d %q", i+1, body, event.ID, event.Data.Body)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a published log for %q", body)
+		}
+	}
+
+	// A reconnecting subscriber resumes after the last event it saw
+	resumed, cancelResumed := r.SubscribeLogs(1)
+	defer cancelResumed()
+	if event := <-resumed; event.Data.Body != "second" {
+		t.Errorf("Expected to resume with the second log, got %q", event.Data.Body)
+	}
+
+	// Stopping the receiver ends live tails
+	if err := r.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop receiver: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("Expected the subscription to be closed on stop")
+	}
+}
+
+func TestHTTPServerTimeouts(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.ReadHeaderTimeout = 2 * time.Second
+	cfg.Server.ReadTimeout = 5 * time.Second
+	cfg.Server.WriteTimeout = 5 * time.Minute
+	cfg.Server.IdleTimeout = 90 * time.Second
+
+	server := NewOTLPReceiver(cfg, nil, zap.NewNop()).newHTTPServer()
+	if server.ReadHeaderTimeout != 2*time.Second {
+		t.Errorf("Expected a 2s read header timeout, got %v", server.ReadHeaderTimeout)
+	}
+	if server.ReadTimeout != 5*time.Second || server.WriteTimeout != 5*time.Minute || server.IdleTimeout != 90*time.Second {
+		t.Errorf("Expected the configured timeouts, got read %v, write %v, idle %v",
+			server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+}
+
+func TestHTTPServerDefaultTimeouts(t *testing.T) {
+	// A stalled client must not hold a connection open forever
+	server := NewOTLPReceiver(config.Default(), nil, zap.NewNop()).newHTTPServer()
+	if server.ReadHeaderTimeout <= 0 {
+		t.Errorf("Expected a read header timeout by default, got %v", server.ReadHeaderTimeout)
+	}
+	if server.ReadTimeout <= 0 {
+		t.Errorf("Expected a read timeout by default, got %v", server.ReadTimeout)
+	}
+}
+
+func TestGRPCMaxRecvMsgSize(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// A ~6 MiB request, above gRPC's 4 MiB default
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID([16]byte{9}))
+	span.SetSpanID(pcommon.SpanID([8]byte{9}))
+	span.SetName("large")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	span.Attributes().PutStr("payload", strings.Repeat("x", 6<<20))
+	req := ptraceotlp.NewExportRequestFromTraces(traces)
+
+	export := func(maxRecvMB int) error {
+		cfg := &config.Config{Server: config.ServerConfig{GRPCMaxRecvMB: maxRecvMB}}
+		server := NewOTLPReceiver(cfg, dataStore, logger).newGRPCServer()
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		go server.Serve(lis)
+		defer server.Stop()
+
+		conn, err := grpc.NewClient(lis.Addr().String(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(64<<20)))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		defer conn.Close()
+
+		_, err = ptraceotlp.NewGRPCClient(conn).Export(ctx, req)
+		return err
+	}
+
+	if err := export(0); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted with the default limit, got %v", err)
+	}
+	if err := export(16); err != nil {
+		t.Errorf("Expected a 16 MiB limit to accept the request, got %v", err)
+	}
+}
+
+func TestFailedInsertsCounted(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	// A closed store makes every insert fail
+	dataStore.Close()
+
+	metrics := pmetric.NewMetrics()
+	gauge := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	gauge.SetName("queue.depth")
+	points := gauge.SetEmptyGauge().DataPoints()
+	for i := 0; i < 3; i++ {
+		point := points.AppendEmpty()
+		point.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		point.SetDoubleValue(float64(i))
+	}
+
+	r := NewOTLPReceiver(&config.Config{}, dataStore, logger)
+	if _, err := r.processMetrics(ctx, metrics); err == nil {
+		t.Fatal("Expected an error storing into a closed store")
+	}
+	if got := r.FailedInserts()["metrics"]; got != 3 {
+		t.Errorf("Expected 3 failed data points, got %d", got)
+	}
+
+	// Batched writes fail after the client was answered; only the counter shows it
+	cfg := &config.Config{Server: config.ServerConfig{BatchSize: 1000, BatchInterval: time.Hour}}
+	batched := NewOTLPReceiver(cfg, dataStore, logger)
+	batched.batcher.flush(batchItem{logs: []*store.LogRecord{
+		{Timestamp: time.Now(), Body: "lost"},
+		{Timestamp: time.Now(), Body: "also lost"},
+	}})
+	failed := batched.FailedInserts()
+	if failed["logs"] != 2 || failed["spans"] != 0 || failed["metrics"] != 0 {
+		t.Errorf("Expected 2 failed logs only, got %v", failed)
+	}
+}
+
+func TestHistogramExemplarsStored(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	traceID := pcommon.TraceID([16]byte{0xab, 1})
+	spanID := pcommon.SpanID([8]byte{0xcd, 2})
+
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "api")
+	rm.Resource().Attributes().PutStr("host.name", "api-0")
+	hist := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	hist.SetName("http.server.duration")
+	point := hist.SetEmptyHistogram().DataPoints().AppendEmpty()
+	point.SetTimestamp(pcommon.NewTimestampFromTime(now))
+	point.Attributes().PutStr("http.route", "/checkout")
+	point.ExplicitBounds().FromRaw([]float64{100, 500, 1000})
+	point.BucketCounts().FromRaw([]uint64{8, 1, 1, 0})
+	point.SetCount(10)
+	point.SetSum(2100)
+
+	// The slow request landed in the (500, 1000] bucket
+	exemplar := point.Exemplars().AppendEmpty()
+	exemplar.SetDoubleValue(870)
+	exemplar.SetTimestamp(pcommon.NewTimestampFromTime(now))
+	exemplar.SetTraceID(traceID)
+	exemplar.SetSpanID(spanID)
+
+	r := NewOTLPReceiver(&config.Config{}, dataStore, logger)
+	if _, err := r.processMetrics(ctx, metrics); err != nil {
+		t.Fatalf("Failed to process metrics: %v", err)
+	}
+
+	exemplars, err := dataStore.Metrics.GetHistogramExemplars(ctx, "http.server.duration", "api", "", now.Add(-time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get histogram exemplars: %v", err)
+	}
+	if len(exemplars) != 1 {
+		t.Fatalf("Expected 1 exemplar, got %d", len(exemplars))
+	}
+	got := exemplars[0]
+	if got.TraceID != traceID.String() || got.SpanID != spanID.String() || got.Value != 870 {
+		t.Errorf("Expected the exemplar of trace %s, got %+v", traceID, got.ExemplarPoint)
+	}
+	if got.Le == nil || *got.Le != 1000 {
+		t.Errorf("Expected the exemplar in the le=1000 bucket, got %v", got.Le)
+	}
+
+	// The exemplar also links the trace back to the data point
+	linked, err := dataStore.Metrics.GetMetricsByExemplarTrace(ctx, traceID.String())
+	if err != nil || len(linked) != 1 || linked[0].Histogram == nil {
+		t.Errorf("Expected the histogram data point for the exemplar trace, got %v (err: %v)", linked, err)
+	}
+}
@@ -0,0 +1,226 @@
+package receiver
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// promTimeSeries is a decoded Prometheus remote-write time series
+type promTimeSeries struct {
+	labels  map[string]string
+	samples []promSample
+}
+
+// promSample is a single Prometheus sample; the timestamp is in milliseconds
+type promSample struct {
+	value     float64
+	timestamp int64
+}
+
+// handlePrometheusWrite handles Prometheus remote-write requests
+// (snappy-compressed prometheus.WriteRequest protobuf)
+func (r *OTLPReceiver) handlePrometheusWrite(w http.ResponseWriter, req *http.Request) {
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer req.Body.Close()
+
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, "failed to decompress snappy body", http.StatusBadRequest)
+		r.logger.Error("Failed to decompress remote-write request", zap.Error(err))
+		return
+	}
+
+	series, err := decodeWriteRequest(body)
+	if err != nil {
+		http.Error(w, "failed to unmarshal protobuf", http.StatusBadRequest)
+		r.logger.Error("Failed to unmarshal remote-write request", zap.Error(err))
+		return
+	}
+
+	metrics := promSeriesToMetrics(series)
+	if r.batcher != nil {
+		item := batchItem{metrics: make([]*store.MetricRecord, len(metrics))}
+		for i := range metrics {
+			item.metrics[i] = &metrics[i]
+		}
+		if err := r.batcher.enqueue(req.Context(), item); err != nil {
+			r.failed.dataPoints.Add(int64(len(metrics)))
+			writeQueueFull(w)
+			r.logger.Error("Failed to queue remote-write metrics", zap.Error(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	stored, err := r.store.Metrics.InsertMetrics(req.Context(), metrics)
+	r.ingested.add(stored)
+	if err != nil {
+		http.Error(w, "failed to store metrics", http.StatusInternalServerError)
+		r.logger.Error("Failed to store remote-write metrics", zap.Error(err))
+		return
+	}
+
+	r.logger.Debug("Stored remote-write samples", zap.Int("count", len(metrics)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// promSeriesToMetrics maps each sample to a gauge data point. The metric name
+// comes from __name__, the service from the "job" label, and all other labels
+// become attributes. NaN samples (staleness markers) are skipped.
+func promSeriesToMetrics(series []promTimeSeries) []store.MetricRecord {
+	metrics := make([]store.MetricRecord, 0, len(series))
+
+	for _, ts := range series {
+		name := ts.labels["__name__"]
+		if name == "" {
+			continue
+		}
+
+		serviceName := ts.labels["job"]
+		if serviceName == "" {
+			serviceName = "unknown"
+		}
+
+		attributes := make(map[string]interface{}, len(ts.labels))
+		for k, v := range ts.labels {
+			if k != "__name__" {
+				attributes[k] = v
+			}
+		}
+
+		for _, sample := range ts.samples {
+			if math.IsNaN(sample.value) {
+				continue
+			}
+			value := sample.value
+			metrics = append(metrics, store.MetricRecord{
+				Timestamp:   time.UnixMilli(sample.timestamp),
+				MetricName:  name,
+				MetricType:  "gauge",
+				ServiceName: serviceName,
+				Value:       &value,
+				Attributes:  attributes,
+			})
+		}
+	}
+
+	return metrics
+}
+
+// decodeWriteRequest decodes a prometheus.WriteRequest:
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+//
+// Other fields (metadata, native histograms, exemplars) are skipped.
+func decodeWriteRequest(data []byte) ([]promTimeSeries, error) {
+	series := []promTimeSeries{}
+	err := forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		ts, err := decodeTimeSeries(value)
+		if err != nil {
+			return err
+		}
+		series = append(series, ts)
+		return nil
+	})
+	return series, err
+}
+
+func decodeTimeSeries(data []byte) (promTimeSeries, error) {
+	ts := promTimeSeries{labels: map[string]string{}}
+	err := forEachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			var name, labelValue string
+			err := forEachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ != protowire.BytesType {
+					return nil
+				}
+				switch num {
+				case 1:
+					name = string(value)
+				case 2:
+					labelValue = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.labels[name] = labelValue
+		case 2:
+			var sample promSample
+			err := forEachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					bits, _ := protowire.ConsumeFixed64(value)
+					sample.value = math.Float64frombits(bits)
+				case num == 2 && typ == protowire.VarintType:
+					millis, _ := protowire.ConsumeVarint(value)
+					sample.timestamp = int64(millis)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			ts.samples = append(ts.samples, sample)
+		}
+		return nil
+	})
+	return ts, err
+}
+
+// forEachField walks the top-level fields of a protobuf message. For length-delimited
+// fields value is the payload; for scalar fields it is the raw encoded value.
+func forEachField(data []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("invalid field tag: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		var value []byte
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(m))
+			}
+			value, n = v, m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+			}
+			value = data[:n]
+		}
+
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
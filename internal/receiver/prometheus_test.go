@@ -0,0 +1,106 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeTimeSeries builds a prometheus.TimeSeries message
+func encodeTimeSeries(labels [][2]string, values []float64, timestamp time.Time) []byte {
+	var ts []byte
+	for _, l := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, l[0])
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, l[1])
+		ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, label)
+	}
+	for _, v := range values {
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(v))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(timestamp.UnixMilli()))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+	}
+	return ts
+}
+
+func TestPrometheusRemoteWrite(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	r := NewOTLPReceiver(&config.Config{}, dataStore, logger)
+
+	now := time.Now().Truncate(time.Millisecond)
+	var writeRequest []byte
+	writeRequest = protowire.AppendTag(writeRequest, 1, protowire.BytesType)
+	writeRequest = protowire.AppendBytes(writeRequest, encodeTimeSeries(
+		[][2]string{{"__name__", "http_requests_total"}, {"job", "api"}, {"method", "GET"}},
+		[]float64{12, math.NaN()}, now))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(snappy.Encode(nil, writeRequest)))
+	rec := httptest.NewRecorder()
+	r.handlePrometheusWrite(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	metrics, err := dataStore.Metrics.GetMetrics(ctx, store.MetricFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	// The NaN staleness marker is skipped
+	if len(metrics) != 1 {
+		t.Fatalf("Expected 1 metric, got %d", len(metrics))
+	}
+
+	m := metrics[0]
+	if m.MetricName != "http_requests_total" || m.ServiceName != "api" || m.MetricType != "gauge" {
+		t.Errorf("Unexpected metric: %+v", m)
+	}
+	if m.Value == nil || *m.Value != 12 {
+		t.Errorf("Expected value 12, got %v", m.Value)
+	}
+	if m.Attributes["method"] != "GET" {
+		t.Errorf("Expected method label as attribute, got %v", m.Attributes)
+	}
+	if _, ok := m.Attributes["__name__"]; ok {
+		t.Error("Expected __name__ to be excluded from attributes")
+	}
+	if !m.Timestamp.Equal(now) {
+		t.Errorf("Expected timestamp %v, got %v", now, m.Timestamp)
+	}
+
+	// Bodies that are not snappy-compressed are rejected
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader([]byte("not snappy")))
+	rec = httptest.NewRecorder()
+	r.handlePrometheusWrite(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid body, got %d", rec.Code)
+	}
+}
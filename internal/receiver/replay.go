@@ -0,0 +1,167 @@
+package receiver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mesaglio/otel-front/internal/exporter"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// A replay file holds captured OTLP export requests. Each record is a one-byte
+// signal type (ReplayTraces, ReplayLogs or ReplayMetrics), the payload length
+// as a 4-byte big-endian integer and the protobuf-encoded ExportRequest.
+// Records are concatenated with nothing in between.
+const (
+	ReplayTraces  byte = 'T'
+	ReplayLogs    byte = 'L'
+	ReplayMetrics byte = 'M'
+)
+
+// maxReplayRecordSize rejects lengths that are more likely corruption than a
+// real export request, before allocating for them
+const maxReplayRecordSize = 256 << 20
+
+// ReplayStats counts what Replay stored
+type ReplayStats struct {
+	Requests   int
+	Spans      int
+	LogRecords int
+	DataPoints int
+}
+
+// WriteReplayRecord appends one export request of the given signal type to a
+// replay file
+func WriteReplayRecord(w io.Writer, signal byte, payload []byte) error {
+	if len(payload) > maxReplayRecordSize {
+		return fmt.Errorf("replay record of %d bytes exceeds the %d byte limit", len(payload), maxReplayRecordSize)
+	}
+	var header [5]byte
+	header[0] = signal
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// ReplayFile ingests the replay file at path; see Replay
+func (r *OTLPReceiver) ReplayFile(ctx context.Context, path string) (ReplayStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ReplayStats{}, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+	return r.Replay(ctx, bufio.NewReader(f))
+}
+
+// Replay reads replay records from rd, transforming and storing each request
+// like the OTLP receivers do. Records are written straight to the store, not
+// through the batcher, so Replay may run before Start and everything is stored
+// once it returns. It stops at the first malformed record or failed insert;
+// requests stored before that are kept.
+func (r *OTLPReceiver) Replay(ctx context.Context, rd io.Reader) (ReplayStats, error) {
+	var stats ReplayStats
+	var header [5]byte
+	payload := []byte{}
+
+	for {
+		if _, err := io.ReadFull(rd, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return stats, nil
+			}
+			return stats, fmt.Errorf("failed to read replay record %d: %w", stats.Requests+1, err)
+		}
+
+		size := binary.BigEndian.Uint32(header[1:])
+		if size > maxReplayRecordSize {
+			return stats, fmt.Errorf("replay record %d claims %d bytes, more than the %d byte limit", stats.Requests+1, size, maxReplayRecordSize)
+		}
+		if cap(payload) < int(size) {
+			payload = make([]byte, size)
+		}
+		payload = payload[:size]
+		if _, err := io.ReadFull(rd, payload); err != nil {
+			return stats, fmt.Errorf("failed to read replay record %d: %w", stats.Requests+1, err)
+		}
+
+		if err := r.replayRecord(ctx, header[0], payload, &stats); err != nil {
+			return stats, fmt.Errorf("replay record %d: %w", stats.Requests+1, err)
+		}
+		stats.Requests++
+	}
+}
+
+// replayRecord decodes and stores one replay record, adding what was stored to stats
+func (r *OTLPReceiver) replayRecord(ctx context.Context, signal byte, payload []byte, stats *ReplayStats) error {
+	switch signal {
+	case ReplayTraces:
+		req := getTraceRequest()
+		defer putTraceRequest(req)
+		if err := req.UnmarshalProto(payload); err != nil {
+			return fmt.Errorf("failed to unmarshal traces: %w", err)
+		}
+		traces, _, err := exporter.TransformTraces(req.Traces(), r.transform)
+		if err != nil {
+			return err
+		}
+		if err := r.store.Traces.InsertTraces(ctx, traces); err != nil {
+			return err
+		}
+		for _, trace := range traces {
+			stats.Spans += len(trace.Spans)
+		}
+
+	case ReplayLogs:
+		req := getLogRequest()
+		defer putLogRequest(req)
+		if err := req.UnmarshalProto(payload); err != nil {
+			return fmt.Errorf("failed to unmarshal logs: %w", err)
+		}
+		transformed, err := exporter.TransformLogs(req.Logs(), r.transform)
+		if err != nil {
+			return err
+		}
+		logs := make([]store.LogRecord, len(transformed))
+		for i, log := range transformed {
+			logs[i] = *log
+		}
+		if _, err := r.store.Logs.InsertLogs(ctx, logs); err != nil {
+			return err
+		}
+		stats.LogRecords += len(logs)
+
+	case ReplayMetrics:
+		req := getMetricRequest()
+		defer putMetricRequest(req)
+		if err := req.UnmarshalProto(payload); err != nil {
+			return fmt.Errorf("failed to unmarshal metrics: %w", err)
+		}
+		transformed, dropped, err := exporter.TransformMetrics(req.Metrics(), r.transform)
+		if err != nil {
+			return err
+		}
+		if dropped > 0 {
+			r.logger.Warn("Dropped replayed metrics with unsupported type", zap.Int("dropped", dropped))
+		}
+		metrics := make([]store.MetricRecord, len(transformed))
+		for i, metric := range transformed {
+			metrics[i] = *metric
+		}
+		if _, err := r.store.Metrics.InsertMetrics(ctx, metrics); err != nil {
+			return err
+		}
+		stats.DataPoints += len(metrics)
+
+	default:
+		return fmt.Errorf("unknown signal type %q", signal)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"go.uber.org/zap"
+)
+
+// replayFile writes one traces, logs and metrics request to a replay file
+func replayFile(t *testing.T) string {
+	t.Helper()
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	traces := ptrace.NewTraces()
+	span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID([16]byte{1}))
+	span.SetSpanID(pcommon.SpanID([8]byte{1}))
+	span.SetName("GET /replayed")
+	span.SetStartTimestamp(now)
+	span.SetEndTimestamp(now)
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range []string{"first", "second"} {
+		record := records.AppendEmpty()
+		record.SetTimestamp(now)
+		record.Body().SetStr(body)
+	}
+
+	metrics := pmetric.NewMetrics()
+	gauge := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	gauge.SetName("queue.depth")
+	points := gauge.SetEmptyGauge().DataPoints()
+	for i := 0; i < 3; i++ {
+		point := points.AppendEmpty()
+		point.SetTimestamp(now)
+		point.SetDoubleValue(float64(i))
+	}
+
+	var buf bytes.Buffer
+	for _, record := range []struct {
+		signal  byte
+		marshal func() ([]byte, error)
+	}{
+		{ReplayTraces, ptraceotlp.NewExportRequestFromTraces(traces).MarshalProto},
+		{ReplayLogs, plogotlp.NewExportRequestFromLogs(logs).MarshalProto},
+		{ReplayMetrics, pmetricotlp.NewExportRequestFromMetrics(metrics).MarshalProto},
+	} {
+		payload, err := record.marshal()
+		if err != nil {
+			t.Fatalf("Failed to marshal request: %v", err)
+		}
+		if err := WriteReplayRecord(&buf, record.signal, payload); err != nil {
+			t.Fatalf("Failed to write replay record: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.otlp")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write replay file: %v", err)
+	}
+	return path
+}
+
+func TestReplayFile(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+
+	dataStore, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer dataStore.Close()
+	if err := dataStore.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// Batching is configured but the receiver is never started: replayed data
+	// must still be stored by the time Replay returns
+	cfg := &config.Config{}
+	cfg.Server.BatchSize = 1000
+	r := NewOTLPReceiver(cfg, dataStore, logger)
+
+	stats, err := r.ReplayFile(ctx, replayFile(t))
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if stats != (ReplayStats{Requests: 3, Spans: 1, LogRecords: 2, DataPoints: 3}) {
+		t.Errorf("Unexpected replay stats %+v", stats)
+	}
+
+	traces, err := dataStore.Traces.GetTraces(ctx, store.TraceFilters{Limit: 10})
+	if err != nil || len(traces) != 1 || traces[0].OperationName != "GET /replayed" {
+		t.Errorf("Expected the replayed trace, got %v (err: %v)", traces, err)
+	}
+	logs, err := dataStore.Logs.GetLogs(ctx, store.LogFilters{Limit: 10})
+	if err != nil || len(logs) != 2 {
+		t.Errorf("Expected 2 replayed logs, got %d (err: %v)", len(logs), err)
+	}
+	if count, err := dataStore.Metrics.GetMetricsCount(ctx); err != nil || count != 3 {
+		t.Errorf("Expected 3 replayed data points, got %d (err: %v)", count, err)
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	r := NewOTLPReceiver(&config.Config{}, nil, zap.NewNop())
+
+	var truncated bytes.Buffer
+	WriteReplayRecord(&truncated, ReplayLogs, []byte("0123456789"))
+	_, err := r.Replay(context.Background(), bytes.NewReader(truncated.Bytes()[:8]))
+	if err == nil || !strings.Contains(err.Error(), "record 1") {
+		t.Errorf("Expected an error for a truncated record, got %v", err)
+	}
+
+	var unknown bytes.Buffer
+	WriteReplayRecord(&unknown, 'X', nil)
+	if _, err := r.Replay(context.Background(), &unknown); err == nil || !strings.Contains(err.Error(), "unknown signal type") {
+		t.Errorf("Expected an error for an unknown signal type, got %v", err)
+	}
+
+	if stats, err := r.Replay(context.Background(), &bytes.Buffer{}); err != nil || stats.Requests != 0 {
+		t.Errorf("Expected an empty file to replay nothing, got %+v (err: %v)", stats, err)
+	}
+}
@@ -0,0 +1,98 @@
+package receiver
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+)
+
+// Protobuf can't be decoded incrementally, so OTLP HTTP bodies are read in
+// full. Pooling the read buffers and export requests keeps large batches from
+// allocating both afresh on every request. Unmarshalling copies strings and
+// bytes out of the buffer, so it can be reused as soon as decoding returns.
+
+// maxPooledBodySize is the largest buffer returned to the pool; a rare huge
+// request shouldn't pin its buffer for the life of the process
+const maxPooledBodySize = 16 << 20
+
+var bodyBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readPooledBody reads r into a pooled buffer; release it with putBodyBuffer
+func readPooledBody(r io.Reader) (*bytes.Buffer, error) {
+	buf := bodyBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if _, err := buf.ReadFrom(r); err != nil {
+		putBodyBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBodySize {
+		return
+	}
+	bodyBufferPool.Put(buf)
+}
+
+// UnmarshalProto appends to a request's contents, so requests are emptied
+// before they go back to the pool
+
+var traceRequestPool = sync.Pool{
+	New: func() interface{} {
+		req := ptraceotlp.NewExportRequest()
+		return &req
+	},
+}
+
+func getTraceRequest() *ptraceotlp.ExportRequest {
+	return traceRequestPool.Get().(*ptraceotlp.ExportRequest)
+}
+
+func putTraceRequest(req *ptraceotlp.ExportRequest) {
+	req.Traces().ResourceSpans().RemoveIf(func(ptrace.ResourceSpans) bool { return true })
+	traceRequestPool.Put(req)
+}
+
+var logRequestPool = sync.Pool{
+	New: func() interface{} {
+		req := plogotlp.NewExportRequest()
+		return &req
+	},
+}
+
+func getLogRequest() *plogotlp.ExportRequest {
+	return logRequestPool.Get().(*plogotlp.ExportRequest)
+}
+
+func putLogRequest(req *plogotlp.ExportRequest) {
+	req.Logs().ResourceLogs().RemoveIf(func(plog.ResourceLogs) bool { return true })
+	logRequestPool.Put(req)
+}
+
+var metricRequestPool = sync.Pool{
+	New: func() interface{} {
+		req := pmetricotlp.NewExportRequest()
+		return &req
+	},
+}
+
+func getMetricRequest() *pmetricotlp.ExportRequest {
+	return metricRequestPool.Get().(*pmetricotlp.ExportRequest)
+}
+
+func putMetricRequest(req *pmetricotlp.ExportRequest) {
+	req.Metrics().ResourceMetrics().RemoveIf(func(pmetric.ResourceMetrics) bool { return true })
+	metricRequestPool.Put(req)
+}
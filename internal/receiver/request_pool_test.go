@@ -0,0 +1,99 @@
+package receiver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+)
+
+// largeTracePayload returns an OTLP protobuf trace request of roughly size bytes
+func largeTracePayload(tb testing.TB, size int) []byte {
+	tb.Helper()
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	body, _ := ptraceotlp.NewExportRequestFromTraces(traces).MarshalProto()
+	for i := 0; len(body) < size; i++ {
+		for j := 0; j < 100; j++ {
+			span := spans.AppendEmpty()
+			span.SetTraceID(pcommon.TraceID([16]byte{byte(i), byte(j)}))
+			span.SetSpanID(pcommon.SpanID([8]byte{byte(i), byte(j)}))
+			span.SetName(fmt.Sprintf("operation-%d", j))
+			span.SetStartTimestamp(now)
+			span.SetEndTimestamp(now)
+			span.Attributes().PutStr("http.url", fmt.Sprintf("https://example.com/items/%d/%d", i, j))
+		}
+		var err error
+		if body, err = ptraceotlp.NewExportRequestFromTraces(traces).MarshalProto(); err != nil {
+			tb.Fatalf("Failed to marshal request: %v", err)
+		}
+	}
+	return body
+}
+
+func TestPooledTraceRequestIsEmptied(t *testing.T) {
+	body := largeTracePayload(t, 64<<10)
+
+	for i := 0; i < 3; i++ {
+		buf, err := readPooledBody(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		request := getTraceRequest()
+		err = request.UnmarshalProto(buf.Bytes())
+		putBodyBuffer(buf)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+
+		// A reused request must not keep the previous request's spans
+		if got := request.Traces().ResourceSpans().Len(); got != 1 {
+			t.Fatalf("Expected 1 resource on iteration %d, got %d", i, got)
+		}
+		putTraceRequest(request)
+	}
+}
+
+func BenchmarkDecodeTraces(b *testing.B) {
+	body := largeTracePayload(b, 1<<20)
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for i := 0; i < b.N; i++ {
+			data, err := io.ReadAll(bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			request := ptraceotlp.NewExportRequest()
+			if err := request.UnmarshalProto(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for i := 0; i < b.N; i++ {
+			buf, err := readPooledBody(bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			request := getTraceRequest()
+			err = request.UnmarshalProto(buf.Bytes())
+			putBodyBuffer(buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			putTraceRequest(request)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// AdminServer serves pprof profiles and runtime statistics for diagnosing
+// memory and performance issues. It listens on localhost only and is meant to
+// be started in debug mode, never exposed alongside the public API.
+type AdminServer struct {
+	store  *store.Store
+	logger *zap.Logger
+	server *http.Server
+}
+
+// NewAdminServer creates an admin server listening on 127.0.0.1:port
+func NewAdminServer(port int, store *store.Store, logger *zap.Logger) *AdminServer {
+	admin := &AdminServer{
+		store:  store,
+		logger: logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/stats", admin.handleStats)
+
+	// No write timeout: CPU profiles and traces stream for as long as requested
+	admin.server = &http.Server{
+		Addr:              net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return admin
+}
+
+// handleStats reports goroutines, heap usage and the database connection pool
+func (a *AdminServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	db := a.store.DBStats()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"goroutines":       runtime.NumGoroutine(),
+		"heap_alloc_bytes": mem.HeapAlloc,
+		"heap_sys_bytes":   mem.HeapSys,
+		"heap_objects":     mem.HeapObjects,
+		"num_gc":           mem.NumGC,
+		"db": map[string]interface{}{
+			"max_open_connections": db.MaxOpenConnections,
+			"open_connections":     db.OpenConnections,
+			"in_use":               db.InUse,
+			"idle":                 db.Idle,
+			"wait_count":           db.WaitCount,
+			"wait_duration_ms":     db.WaitDuration.Milliseconds(),
+		},
+	})
+}
+
+// Start serves until Shutdown is called
+func (a *AdminServer) Start() error {
+	a.logger.Info("Starting admin server", zap.String("addr", a.server.Addr))
+	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("admin server failed: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops the admin server
+func (a *AdminServer) Shutdown(ctx context.Context) error {
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return a.server.Shutdown(shutdownCtx)
+}
@@ -167,7 +167,7 @@ func (s *Server) handleGetMetrics(c *gin.Context) {
 func (s *Server) handleGetMetricNames(c *gin.Context) {
 	serviceName := c.Query("service")
 
-	names, err := s.store.Metrics.GetMetricNames(c.Request.Context(), serviceName)
+	names, err := s.store.Metrics.GetMetricNames(c.Request.Context(), serviceName, c.Query("environment"))
 	if err != nil {
 		s.logger.Error("Failed to get metric names", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve metric names"})
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// AttributesHandler serves attribute discovery for the filter builder
+type AttributesHandler struct {
+	store  *store.Store
+	logger *zap.Logger
+}
+
+// NewAttributesHandler creates a new attributes handler
+func NewAttributesHandler(store *store.Store, logger *zap.Logger) *AttributesHandler {
+	return &AttributesHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// GetAttributeKeys returns the distinct attribute keys for ?type=traces|logs|metrics
+func (h *AttributesHandler) GetAttributeKeys(c *gin.Context) {
+	signalType := c.Query("type")
+
+	keys, err := h.store.GetAttributeKeys(c.Request.Context(), signalType)
+	if errors.Is(err, store.ErrUnknownSignalType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type, expected traces, logs or metrics"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get attribute keys", zap.String("type", signalType), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attribute keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// GetAttributeValues returns suggested values for ?type=...&key=..., most frequent first
+func (h *AttributesHandler) GetAttributeValues(c *gin.Context) {
+	signalType := c.Query("type")
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	values, err := h.store.GetAttributeValues(c.Request.Context(), signalType, key, getIntQuery(c, "limit", 50))
+	if errors.Is(err, store.ErrUnknownSignalType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type, expected traces, logs or metrics"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get attribute values", zap.String("type", signalType), zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attribute values"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"values": values,
+		"count":  len(values),
+	})
+}
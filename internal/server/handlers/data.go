@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// DataHandler handles bulk operations on the stored telemetry
+type DataHandler struct {
+	store  *store.Store
+	logger *zap.Logger
+}
+
+// NewDataHandler creates a new data handler
+func NewDataHandler(store *store.Store, logger *zap.Logger) *DataHandler {
+	return &DataHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// DeleteData wipes all traces, logs and metrics and returns the deleted row
+// counts per table
+func (h *DataHandler) DeleteData(c *gin.Context) {
+	deleted, err := h.store.Truncate(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to delete data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
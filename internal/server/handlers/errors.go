@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// minErrorSeverity is the lowest OTLP severity number (ERROR) in the error feed
+const minErrorSeverity = 17
+
+// ErrorEvent is one entry of the error feed: a trace with error spans or an
+// ERROR/FATAL log. Exactly one of Trace and Log is set, as named by Type.
+type ErrorEvent struct {
+	Type      string           `json:"type"` // "trace" or "log"
+	Timestamp time.Time        `json:"timestamp"`
+	Trace     *store.Trace     `json:"trace,omitempty"`
+	Log       *store.LogRecord `json:"log,omitempty"`
+}
+
+// ErrorsHandler serves a combined feed of recent errors across signals
+type ErrorsHandler struct {
+	store  *store.Store
+	logger *zap.Logger
+}
+
+// NewErrorsHandler creates a new errors handler
+func NewErrorsHandler(store *store.Store, logger *zap.Logger) *ErrorsHandler {
+	return &ErrorsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// GetErrors returns the most recent error traces and ERROR/FATAL logs, newest
+// first, optionally for one service
+func (h *ErrorsHandler) GetErrors(c *gin.Context) {
+	limit := getIntQuery(c, "limit", 50)
+	service := c.Query("service")
+	ctx := c.Request.Context()
+
+	traces, err := h.store.Traces.GetTraces(ctx, store.TraceFilters{
+		ServiceName: service,
+		HasErrors:   true,
+		Limit:       limit,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get error traces", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve errors"})
+		return
+	}
+
+	logs, err := h.store.Logs.GetLogs(ctx, store.LogFilters{
+		ServiceName: service,
+		MinSeverity: minErrorSeverity,
+		Limit:       limit,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get error logs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve errors"})
+		return
+	}
+
+	events := mergeErrorEvents(traces, logs, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"errors": events,
+		"count":  len(events),
+	})
+}
+
+// mergeErrorEvents combines traces and logs into one feed ordered newest
+// first, keeping at most limit events. Traces are placed by their start time.
+func mergeErrorEvents(traces []store.Trace, logs []store.LogRecord, limit int) []ErrorEvent {
+	events := make([]ErrorEvent, 0, len(traces)+len(logs))
+	for i := range traces {
+		events = append(events, ErrorEvent{Type: "trace", Timestamp: traces[i].StartTime, Trace: &traces[i]})
+	}
+	for i := range logs {
+		events = append(events, ErrorEvent{Type: "log", Timestamp: logs[i].Timestamp, Log: &logs[i]})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
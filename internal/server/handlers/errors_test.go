@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+func TestGetErrors(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	base := time.Now().Add(-time.Minute)
+	insertTrace := func(id string, offset time.Duration, errors int) {
+		if err := s.Traces.InsertTrace(ctx, &store.Trace{
+			TraceID:       id,
+			ServiceName:   "api",
+			OperationName: "GET /",
+			StartTime:     base.Add(offset),
+			EndTime:       base.Add(offset),
+			ErrorCount:    errors,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+	insertLog := func(body string, offset time.Duration, severity int) {
+		if err := s.Logs.InsertLog(ctx, &store.LogRecord{
+			Timestamp:      base.Add(offset),
+			SeverityNumber: severity,
+			SeverityText:   "ERROR",
+			ServiceName:    "api",
+			Body:           body,
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+	}
+
+	insertTrace("failed-1", 1*time.Second, 1)
+	insertLog("db timeout", 2*time.Second, 17)
+	insertTrace("ok", 3*time.Second, 0)
+	insertTrace("failed-2", 4*time.Second, 2)
+	insertLog("warning", 5*time.Second, 13)
+	insertLog("crash", 6*time.Second, 21)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/errors", NewErrorsHandler(s, zap.NewNop()).GetErrors)
+
+	feed := func(path string) []ErrorEvent {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Errors []ErrorEvent `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp.Errors
+	}
+
+	describe := func(events []ErrorEvent) string {
+		var names []string
+		for _, e := range events {
+			switch {
+			case e.Trace != nil:
+				names = append(names, e.Type+":"+e.Trace.TraceID)
+			case e.Log != nil:
+				names = append(names, e.Type+":"+e.Log.Body)
+			}
+		}
+		return fmt.Sprint(names)
+	}
+
+	if got := describe(feed("/api/errors")); got != "[log:crash trace:failed-2 log:db timeout trace:failed-1]" {
+		t.Errorf("Expected error traces and logs newest first, got %s", got)
+	}
+	if got := describe(feed("/api/errors?limit=2")); got != "[log:crash trace:failed-2]" {
+		t.Errorf("Expected the 2 most recent errors, got %s", got)
+	}
+}
@@ -1,24 +1,69 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// HealthHandler handles health check requests
-type HealthHandler struct{}
+// readyPingTimeout bounds the database check done by /readyz
+const readyPingTimeout = 2 * time.Second
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+// Pinger verifies that the backing database is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
 
-// HandleHealth returns health status
+// HealthHandler handles liveness and readiness checks
+type HealthHandler struct {
+	db    Pinger
+	ready atomic.Bool
+}
+
+// NewHealthHandler creates a new health handler. It reports not ready until
+// SetReady is called, typically once migrations have finished.
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// SetReady marks whether the store is migrated and may serve requests
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// HandleHealth reports that the process is up (liveness)
 func (h *HealthHandler) HandleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "ok",
 		"timestamp": time.Now().Unix(),
 	})
 }
+
+// HandleReady returns 503 until the store is migrated and answers a ping (readiness)
+func (h *HealthHandler) HandleReady(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unavailable",
+			"reason": "database migrations have not finished",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyPingTimeout)
+	defer cancel()
+	if err := h.db.Ping(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unavailable",
+			"reason": "database ping failed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ok",
+		"timestamp": time.Now().Unix(),
+	})
+}
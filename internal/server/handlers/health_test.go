@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+func newHealthRouter(h *HealthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/livez", h.HandleHealth)
+	router.GET("/readyz", h.HandleReady)
+	return router
+}
+
+func probe(router *gin.Engine, path string) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Code
+}
+
+func TestHealthBeforeReady(t *testing.T) {
+	router := newHealthRouter(NewHealthHandler(&fakePinger{}))
+
+	if code := probe(router, "/livez"); code != http.StatusOK {
+		t.Errorf("Expected liveness 200 before migrations, got %d", code)
+	}
+	if code := probe(router, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness 503 before migrations, got %d", code)
+	}
+}
+
+func TestHealthReady(t *testing.T) {
+	pinger := &fakePinger{}
+	h := NewHealthHandler(pinger)
+	h.SetReady(true)
+	router := newHealthRouter(h)
+
+	if code := probe(router, "/livez"); code != http.StatusOK {
+		t.Errorf("Expected liveness 200, got %d", code)
+	}
+	if code := probe(router, "/readyz"); code != http.StatusOK {
+		t.Errorf("Expected readiness 200 after migrations, got %d", code)
+	}
+
+	pinger.err = errors.New("database is closed")
+	if code := probe(router, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness 503 when ping fails, got %d", code)
+	}
+}
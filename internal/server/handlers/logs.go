@@ -1,37 +1,291 @@
 package handlers
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/pubsub"
 	"github.com/mesaglio/otel-front/internal/store"
 	"go.uber.org/zap"
 )
 
+// LogSubscriber streams log records as they are ingested
+type LogSubscriber interface {
+	SubscribeLogs(afterID uint64) (<-chan pubsub.Event[*store.LogRecord], func())
+}
+
 // LogsHandler handles log-related HTTP requests
 type LogsHandler struct {
-	store  *store.Store
-	logger *zap.Logger
+	store           *store.Store
+	tail            LogSubscriber
+	logger          *zap.Logger
+	defaultLookback time.Duration
 }
 
-// NewLogsHandler creates a new logs handler
-func NewLogsHandler(store *store.Store, logger *zap.Logger) *LogsHandler {
+// NewLogsHandler creates a new logs handler. tail may be nil, in which case
+// live tailing is unavailable.
+func NewLogsHandler(store *store.Store, tail LogSubscriber, logger *zap.Logger) *LogsHandler {
 	return &LogsHandler{
 		store:  store,
+		tail:   tail,
 		logger: logger,
 	}
 }
 
-// GetLogs returns a list of logs
+// SetDefaultLookback limits GetLogs to the last lookback when the client sends
+// neither start_time nor end_time. Zero, the default, queries all logs.
+func (h *LogsHandler) SetDefaultLookback(lookback time.Duration) {
+	h.defaultLookback = lookback
+}
+
+// maxLogExportRows caps CSV exports so a missing filter can't dump the whole table
+const maxLogExportRows = 100000
+
+// logTailHeartbeat is how often an idle tail sends a comment so proxies and
+// clients keep the connection open
+const logTailHeartbeat = 15 * time.Second
+
+// GetLogs returns a list of logs. Pages are selected by offset, or by the
+// before/after cursors which stay stable while new logs arrive.
 func (h *LogsHandler) GetLogs(c *gin.Context) {
+	filters := parseLogFilters(c, 100)
+	filters.Dedup = c.Query("dedup") == "true"
+	applyDefaultLookback(c, &filters.StartTime, h.defaultLookback)
+
+	var ok bool
+	if filters.Before, ok = parseLogCursor(c, "before"); !ok {
+		return
+	}
+	if filters.After, ok = parseLogCursor(c, "after"); !ok {
+		return
+	}
+	if filters.Dedup && (filters.Before != nil || filters.After != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor pagination is not supported with dedup"})
+		return
+	}
+
+	logs, err := h.store.Logs.GetLogs(c.Request.Context(), filters)
+	if err != nil {
+		h.logger.Error("Failed to get logs", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
+		return
+	}
+
+	// Get total count for pagination
+	total, _ := h.store.Logs.CountLogs(c.Request.Context(), filters)
+
+	h.markExistingTraces(c.Request.Context(), logs)
+
+	response := gin.H{
+		"logs":  logs,
+		"count": len(logs),
+		"total": total,
+	}
+	// A full page may have more behind it; pass next_cursor as before= to continue
+	if !filters.Dedup && len(logs) > 0 && len(logs) == filters.Limit {
+		response["next_cursor"] = store.NewLogCursor(logs[len(logs)-1]).String()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// parseLogCursor reads an optional cursor query parameter, responding with 400
+// and returning false when it is malformed
+func parseLogCursor(c *gin.Context, param string) (*store.LogCursor, bool) {
+	token := c.Query(param)
+	if token == "" {
+		return nil, true
+	}
+	cursor, err := store.ParseLogCursor(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " cursor"})
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// markExistingTraces sets TraceExists on logs whose trace is stored, so the UI
+// only links to traces that won't 404. Lookup failures leave every flag false.
+func (h *LogsHandler) markExistingTraces(ctx context.Context, logs []store.LogRecord) {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, log := range logs {
+		if log.TraceID != nil && *log.TraceID != "" && !seen[*log.TraceID] {
+			seen[*log.TraceID] = true
+			ids = append(ids, *log.TraceID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	existing, err := h.store.Traces.FilterExistingTraceIDs(ctx, ids)
+	if err != nil {
+		h.logger.Warn("Failed to look up traces for logs", zap.Error(err))
+		return
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	for i := range logs {
+		logs[i].TraceExists = logs[i].TraceID != nil && found[*logs[i].TraceID]
+	}
+}
+
+// ExportLogs streams the logs matching the GetLogs filters as a CSV download.
+// Only format=csv (the default) is supported; at most maxLogExportRows rows are written.
+func (h *LogsHandler) ExportLogs(c *gin.Context) {
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format, expected csv"})
+		return
+	}
+
+	filters := parseLogFilters(c, maxLogExportRows)
+	if filters.Limit <= 0 || filters.Limit > maxLogExportRows {
+		filters.Limit = maxLogExportRows
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="logs-%s.csv"`, time.Now().UTC().Format("20060102-150405")))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"timestamp", "severity_text", "service_name", "trace_id", "body"})
+
+	err := h.store.Logs.StreamLogs(c.Request.Context(), filters, func(log *store.LogRecord) error {
+		traceID := ""
+		if log.TraceID != nil {
+			traceID = *log.TraceID
+		}
+		return w.Write([]string{
+			log.Timestamp.UTC().Format(time.RFC3339Nano),
+			log.SeverityText,
+			log.ServiceName,
+			traceID,
+			log.Body,
+		})
+	})
+	w.Flush()
+
+	// Headers are already sent, so a failure can only cut the download short
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		h.logger.Error("Failed to export logs", zap.Error(err))
+	}
+}
+
+// GetLogVolume returns log counts per time bucket with one series per severity,
+// for stacked volume charts. Accepts the GetLogs filters plus bucket
+// ("1 minute", "5 minutes", ...).
+func (h *LogsHandler) GetLogVolume(c *gin.Context) {
+	filters := parseLogFilters(c, 0)
+	bucket := c.DefaultQuery("bucket", "1 minute")
+
+	series, err := h.store.Logs.GetLogVolume(c.Request.Context(), filters, bucket)
+	if errors.Is(err, store.ErrInvalidBucketSize) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket, expected a size like 5 minutes or 10m"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get log volume", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve log volume"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bucket": bucket,
+		"series": series,
+		"count":  len(series),
+	})
+}
+
+// TailLogs streams newly ingested logs matching the GetLogs filters as
+// Server-Sent Events, one "data:" frame of JSON per log with its event ID.
+// Clients reconnecting with Last-Event-ID first receive the recent logs they missed.
+func (h *LogsHandler) TailLogs(c *gin.Context) {
+	if h.tail == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Live tail is not available"})
+		return
+	}
+
+	filters := parseLogFilters(c, 0)
+
+	var afterID uint64
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		afterID = id
+	}
+
+	events, cancel := h.tail.SubscribeLogs(afterID)
+	defer cancel()
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(c.Writer)
+	rc.SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(logTailHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !filters.Matches(event.Data) {
+				continue
+			}
+			data, marshalErr := json.Marshal(event.Data)
+			if marshalErr != nil {
+				h.logger.Error("Failed to encode tailed log", zap.Error(marshalErr))
+				continue
+			}
+			_, err = fmt.Fprintf(c.Writer, "id: %d\ndata: %s\n\n", event.ID, data)
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// parseLogFilters reads the log filter query parameters shared by GetLogs, ExportLogs, GetLogVolume and TailLogs
+func parseLogFilters(c *gin.Context, defaultLimit int) store.LogFilters {
 	filters := store.LogFilters{
-		ServiceName: c.Query("service"),
-		TraceID:     c.Query("trace_id"),
-		SearchText:  c.Query("search"),
-		Limit:       getIntQuery(c, "limit", 100),
-		Offset:      getIntQuery(c, "offset", 0),
+		ServiceName:      c.Query("service"),
+		TraceID:          c.Query("trace_id"),
+		EventName:        c.Query("event_name"),
+		Environment:      c.Query("environment"),
+		SearchText:       c.Query("search"),
+		SearchAttributes: c.Query("search_attributes") == "true",
+		Limit:            getIntQuery(c, "limit", defaultLimit),
+		Offset:           getIntQuery(c, "offset", 0),
 	}
 
 	if severity := c.Query("severity"); severity != "" {
@@ -40,6 +294,12 @@ func (h *LogsHandler) GetLogs(c *gin.Context) {
 		}
 	}
 
+	if maxSeverity := c.Query("max_severity"); maxSeverity != "" {
+		if val, err := strconv.Atoi(maxSeverity); err == nil {
+			filters.MaxSeverity = val
+		}
+	}
+
 	if startTime := c.Query("start_time"); startTime != "" {
 		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
 			filters.StartTime = t
@@ -52,34 +312,48 @@ func (h *LogsHandler) GetLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.store.Logs.GetLogs(c.Request.Context(), filters)
-	if err != nil {
-		h.logger.Error("Failed to get logs", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
+	return filters
+}
+
+// applyDefaultLookback sets start to lookback before now when lookback is
+// positive and the request has no start_time or end_time, so an unbounded
+// query doesn't scan the whole table
+func applyDefaultLookback(c *gin.Context, start *time.Time, lookback time.Duration) {
+	if lookback <= 0 || c.Query("start_time") != "" || c.Query("end_time") != "" {
 		return
 	}
-
-	// Get total count for pagination
-	total, _ := h.store.Logs.CountLogs(c.Request.Context(), filters)
-
-	c.JSON(http.StatusOK, gin.H{
-		"logs":  logs,
-		"count": len(logs),
-		"total": total,
-	})
+	*start = time.Now().Add(-lookback)
 }
 
-// GetLogsByTraceID returns logs associated with a trace
+// GetLogsByTraceID returns logs associated with a trace. With correlate=true
+// it also returns same-service logs without a trace ID that fall within the
+// trace's time range, widened on each side by the optional window duration.
 func (h *LogsHandler) GetLogsByTraceID(c *gin.Context) {
 	traceID := c.Param("traceId")
 
-	logs, err := h.store.Logs.GetLogsByTraceID(c.Request.Context(), traceID)
+	var logs []store.LogRecord
+	var err error
+	if c.Query("correlate") == "true" {
+		var window time.Duration
+		if raw := c.Query("window"); raw != "" {
+			window, err = time.ParseDuration(raw)
+			if err != nil || window < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window duration"})
+				return
+			}
+		}
+		logs, err = h.store.Logs.GetCorrelatedLogs(c.Request.Context(), traceID, window)
+	} else {
+		logs, err = h.store.Logs.GetLogsByTraceID(c.Request.Context(), traceID)
+	}
 	if err != nil {
 		h.logger.Error("Failed to get logs by trace ID", zap.Error(err), zap.String("trace_id", traceID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve logs"})
 		return
 	}
 
+	h.markExistingTraces(c.Request.Context(), logs)
+
 	c.JSON(http.StatusOK, gin.H{
 		"logs":  logs,
 		"count": len(logs),
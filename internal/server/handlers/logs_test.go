@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// newTestStore returns a migrated, empty in-memory store closed when the test ends
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	ctx := context.Background()
+
+	s, err := store.NewStore(ctx, zap.NewNop())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(s.Close)
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return s
+}
+
+// setupLookbackStore returns a migrated in-memory store holding one log and one
+// metric from now and one of each from two hours ago
+func setupLookbackStore(t *testing.T) *store.Store {
+	t.Helper()
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	for _, ts := range []time.Time{time.Now(), time.Now().Add(-2 * time.Hour)} {
+		if err := s.Logs.InsertLog(ctx, &store.LogRecord{
+			Timestamp: ts, SeverityText: "INFO", SeverityNumber: 9, Body: "hello", ServiceName: "api",
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+		value := 1.0
+		if err := s.Metrics.InsertMetric(ctx, &store.MetricRecord{
+			Timestamp: ts, MetricName: "requests", MetricType: "sum", ServiceName: "api", Value: &value,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+	return s
+}
+
+// getCount serves path and returns the "count" field of the response
+func getCount(t *testing.T, router *gin.Engine, path string) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for %s, got %d: %s", path, w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.Count
+}
+
+func TestGetLogsDefaultLookback(t *testing.T) {
+	s := setupLookbackStore(t)
+	h := NewLogsHandler(s, nil, zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/logs", h.GetLogs)
+
+	if count := getCount(t, router, "/api/logs"); count != 2 {
+		t.Errorf("Expected all 2 logs without a lookback, got %d", count)
+	}
+
+	h.SetDefaultLookback(time.Hour)
+	if count := getCount(t, router, "/api/logs"); count != 1 {
+		t.Errorf("Expected 1 log within the default hour, got %d", count)
+	}
+
+	// An explicit range replaces the default window
+	start := time.Now().Add(-3 * time.Hour).UTC().Format(time.RFC3339)
+	if count := getCount(t, router, "/api/logs?start_time="+start); count != 2 {
+		t.Errorf("Expected 2 logs with an explicit start_time, got %d", count)
+	}
+}
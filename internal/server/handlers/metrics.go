@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,8 +13,9 @@ import (
 
 // MetricsHandler handles metrics-related HTTP requests
 type MetricsHandler struct {
-	store  *store.Store
-	logger *zap.Logger
+	store           *store.Store
+	logger          *zap.Logger
+	defaultLookback time.Duration
 }
 
 // NewMetricsHandler creates a new metrics handler
@@ -23,12 +26,19 @@ func NewMetricsHandler(store *store.Store, logger *zap.Logger) *MetricsHandler {
 	}
 }
 
+// SetDefaultLookback limits GetMetrics to the last lookback when the client
+// sends neither start_time nor end_time. Zero, the default, queries all metrics.
+func (h *MetricsHandler) SetDefaultLookback(lookback time.Duration) {
+	h.defaultLookback = lookback
+}
+
 // GetMetrics returns a list of metrics
 func (h *MetricsHandler) GetMetrics(c *gin.Context) {
 	filters := store.MetricFilters{
 		MetricName:  c.Query("name"),
 		MetricType:  c.Query("type"),
 		ServiceName: c.Query("service"),
+		Environment: c.Query("environment"),
 		Limit:       getIntQuery(c, "limit", 1000),
 		Offset:      getIntQuery(c, "offset", 0),
 	}
@@ -44,6 +54,19 @@ func (h *MetricsHandler) GetMetrics(c *gin.Context) {
 			filters.EndTime = t
 		}
 	}
+	applyDefaultLookback(c, &filters.StartTime, h.defaultLookback)
+
+	if minValue := c.Query("min_value"); minValue != "" {
+		if val, err := strconv.ParseFloat(minValue, 64); err == nil {
+			filters.MinValue = &val
+		}
+	}
+
+	if maxValue := c.Query("max_value"); maxValue != "" {
+		if val, err := strconv.ParseFloat(maxValue, 64); err == nil {
+			filters.MaxValue = &val
+		}
+	}
 
 	metrics, err := h.store.Metrics.GetMetrics(c.Request.Context(), filters)
 	if err != nil {
@@ -70,7 +93,7 @@ func (h *MetricsHandler) GetMetrics(c *gin.Context) {
 func (h *MetricsHandler) GetMetricNames(c *gin.Context) {
 	serviceName := c.Query("service")
 
-	names, err := h.store.Metrics.GetMetricNames(c.Request.Context(), serviceName)
+	names, err := h.store.Metrics.GetMetricNames(c.Request.Context(), serviceName, c.Query("environment"))
 	if err != nil {
 		h.logger.Error("Failed to get metric names", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve metric names"})
@@ -83,6 +106,78 @@ func (h *MetricsHandler) GetMetricNames(c *gin.Context) {
 	})
 }
 
+// GetMetricsByTrace returns metric data points whose exemplars reference a trace
+func (h *MetricsHandler) GetMetricsByTrace(c *gin.Context) {
+	traceID := c.Param("traceId")
+
+	metrics, err := h.store.Metrics.GetMetricsByExemplarTrace(c.Request.Context(), traceID)
+	if err != nil {
+		h.logger.Error("Failed to get metrics by trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve metrics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metrics": metrics,
+		"count":   len(metrics),
+	})
+}
+
+// GetExemplars returns the exemplars of ?metric= (optionally ?service= and
+// ?environment=) within ?range= (default 1h) as points for overlaying on charts
+func (h *MetricsHandler) GetExemplars(c *gin.Context) {
+	metricName := c.Query("metric")
+	if metricName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric is required"})
+		return
+	}
+
+	timeRange, ok := parseRange(c, time.Hour, maxQueryRange)
+	if !ok {
+		return
+	}
+
+	exemplars, err := h.store.Metrics.GetExemplars(c.Request.Context(), metricName, c.Query("service"), c.Query("environment"), timeRange)
+	if err != nil {
+		h.logger.Error("Failed to get exemplars", zap.Error(err), zap.String("metric", metricName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exemplars"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exemplars": exemplars,
+		"count":     len(exemplars),
+	})
+}
+
+// GetHistogramExemplars returns the exemplars of histogram ?metric= (optionally
+// ?service= and ?environment=) within ?range= (default 1h), each with the upper
+// bound of the bucket it fell in
+func (h *MetricsHandler) GetHistogramExemplars(c *gin.Context) {
+	metricName := c.Query("metric")
+	if metricName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric is required"})
+		return
+	}
+
+	timeRange, ok := parseRange(c, time.Hour, maxQueryRange)
+	if !ok {
+		return
+	}
+
+	exemplars, err := h.store.Metrics.GetHistogramExemplars(c.Request.Context(), metricName, c.Query("service"), c.Query("environment"), time.Now().Add(-timeRange), time.Time{})
+	if err != nil {
+		h.logger.Error("Failed to get histogram exemplars", zap.Error(err), zap.String("metric", metricName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve exemplars"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exemplars": exemplars,
+		"count":     len(exemplars),
+	})
+}
+
 // AggregateMetrics computes metric aggregations
 func (h *MetricsHandler) AggregateMetrics(c *gin.Context) {
 	var req store.AggregationRequest
@@ -92,6 +187,10 @@ func (h *MetricsHandler) AggregateMetrics(c *gin.Context) {
 	}
 
 	results, err := h.store.Metrics.AggregateMetrics(c.Request.Context(), req)
+	if errors.Is(err, store.ErrInvalidBucketSize) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time_bucket, expected a size like 5 minutes or 10m"})
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to aggregate metrics", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate metrics"})
@@ -104,6 +203,38 @@ func (h *MetricsHandler) AggregateMetrics(c *gin.Context) {
 	})
 }
 
+// GetHistogramHeatmap returns time-bucketed histogram bucket counts.
+// The response is a flattened grid of {time_bucket, le, count} cells, where
+// le is the bucket upper bound (null for +Inf).
+func (h *MetricsHandler) GetHistogramHeatmap(c *gin.Context) {
+	var req store.HistogramHeatmapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.MetricName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric_name is required"})
+		return
+	}
+
+	cells, err := h.store.Metrics.GetHistogramHeatmap(c.Request.Context(), req)
+	if errors.Is(err, store.ErrInvalidBucketSize) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time_bucket, expected a size like 5 minutes or 10m"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get histogram heatmap", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve histogram heatmap"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cells": cells,
+		"count": len(cells),
+	})
+}
+
 // GetServices returns a list of unique services
 func (h *MetricsHandler) GetServices(c *gin.Context) {
 	services, err := h.store.Traces.GetServices(c.Request.Context())
@@ -118,3 +249,19 @@ func (h *MetricsHandler) GetServices(c *gin.Context) {
 		"count":    len(services),
 	})
 }
+
+// GetServiceEnvironments returns each service once per deployment environment
+// it reported, so services sharing a name across environments can be told apart
+func (h *MetricsHandler) GetServiceEnvironments(c *gin.Context) {
+	services, err := h.store.GetServicesWithEnvironment(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get service environments", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve service environments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"services": services,
+		"count":    len(services),
+	})
+}
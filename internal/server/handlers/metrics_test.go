@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestGetMetricsDefaultLookback(t *testing.T) {
+	s := setupLookbackStore(t)
+	h := NewMetricsHandler(s, zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/metrics", h.GetMetrics)
+
+	if count := getCount(t, router, "/api/metrics"); count != 2 {
+		t.Errorf("Expected all 2 metrics without a lookback, got %d", count)
+	}
+
+	h.SetDefaultLookback(time.Hour)
+	if count := getCount(t, router, "/api/metrics"); count != 1 {
+		t.Errorf("Expected 1 metric within the default hour, got %d", count)
+	}
+
+	end := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	if count := getCount(t, router, "/api/metrics?end_time="+end); count != 2 {
+		t.Errorf("Expected 2 metrics with an explicit end_time, got %d", count)
+	}
+}
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+)
+
+// apiParam is a path or query parameter of an API route
+type apiParam struct {
+	name        string
+	in          string // "path" or "query"
+	typ         string // OpenAPI scalar type
+	description string
+}
+
+// apiRoute describes one /api route. Paths use OpenAPI {param} syntax; keep
+// the list in sync with SetupRouter.
+type apiRoute struct {
+	method      string
+	path        string
+	summary     string
+	params      []apiParam
+	body        interface{} // Request body type, nil for none
+	response    func(s openAPISchemas) map[string]interface{}
+	contentType string // Response content type, application/json when empty
+}
+
+func pathParam(name, description string) apiParam {
+	return apiParam{name: name, in: "path", typ: "string", description: description}
+}
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{name: name, in: "query", typ: typ, description: description}
+}
+
+var (
+	timeRangeParams = []apiParam{
+		queryParam("start_time", "string", "RFC3339 start of the time range"),
+		queryParam("end_time", "string", "RFC3339 end of the time range"),
+	}
+	pageParams = []apiParam{
+		queryParam("limit", "integer", "Maximum number of results"),
+		queryParam("offset", "integer", "Number of results to skip"),
+	}
+	traceFilterParams = concatParams([]apiParam{
+		queryParam("service", "string", "Root service name; repeat to match any of several"),
+		queryParam("environment", "string", "deployment.environment resource attribute"),
+		queryParam("errors", "boolean", "Only traces with error spans"),
+		queryParam("search", "string", "Substring of the trace ID or operation name"),
+		queryParam("kind", "string", "Only traces with a span of this kind"),
+		queryParam("sort", "string", "Sort column, e.g. start_time or duration_ms"),
+		queryParam("order", "string", "asc or desc"),
+		queryParam("include_attributes", "boolean", "Include trace attributes"),
+		queryParam("min_duration", "integer", "Minimum duration in milliseconds"),
+		queryParam("max_duration", "integer", "Maximum duration in milliseconds"),
+		queryParam("attr", "string", "key:value attribute match; repeat to require several"),
+		queryParam("resource", "string", "key:value resource attribute match, e.g. deployment.environment:production; repeat to require several"),
+	}, pageParams)
+	logFilterParams = concatParams([]apiParam{
+		queryParam("service", "string", "Service name"),
+		queryParam("environment", "string", "deployment.environment resource attribute"),
+		queryParam("trace_id", "string", "Trace ID"),
+		queryParam("event_name", "string", "OTel event name"),
+		queryParam("search", "string", "Substring of the body"),
+		queryParam("search_attributes", "boolean", "Also search attribute values"),
+		queryParam("severity", "integer", "Minimum severity number"),
+		queryParam("max_severity", "integer", "Maximum severity number"),
+	}, timeRangeParams, pageParams)
+	rangeParam = queryParam("range", "string", "Go duration to look back, default 1h, at most 168h")
+)
+
+func concatParams(lists ...[]apiParam) []apiParam {
+	var params []apiParam
+	for _, list := range lists {
+		params = append(params, list...)
+	}
+	return params
+}
+
+// listOf is the {"<key>": [...], "count": n} shape shared by list endpoints,
+// with any extra fields
+func listOf(key string, item interface{}, extra map[string]interface{}) func(s openAPISchemas) map[string]interface{} {
+	return func(s openAPISchemas) map[string]interface{} {
+		props := map[string]interface{}{
+			key:     map[string]interface{}{"type": "array", "items": s.schemaOf(reflect.TypeOf(item))},
+			"count": map[string]interface{}{"type": "integer"},
+		}
+		for name, schema := range extra {
+			props[name] = schema
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+}
+
+// schemaFor is the schema of v's type
+func schemaFor(v interface{}) func(s openAPISchemas) map[string]interface{} {
+	return func(s openAPISchemas) map[string]interface{} {
+		return s.schemaOf(reflect.TypeOf(v))
+	}
+}
+
+// objectOf is an object with the given property schemas; nil props leaves it
+// free-form
+func objectOf(props map[string]interface{}) func(s openAPISchemas) map[string]interface{} {
+	return func(s openAPISchemas) map[string]interface{} {
+		if props == nil {
+			return freeSchema
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+}
+
+var (
+	integerSchema = map[string]interface{}{"type": "integer"}
+	numberSchema  = map[string]interface{}{"type": "number"}
+	stringSchema  = map[string]interface{}{"type": "string"}
+	freeSchema    = map[string]interface{}{"type": "object", "additionalProperties": true}
+	countsSchema  = map[string]interface{}{"type": "object", "additionalProperties": integerSchema}
+)
+
+var apiRoutes = []apiRoute{
+	// Traces
+	{method: "get", path: "/api/traces", summary: "List traces", params: traceFilterParams,
+		response: listOf("traces", store.Trace{}, map[string]interface{}{"total": integerSchema})},
+	{method: "get", path: "/api/traces/histogram", summary: "Trace duration histogram",
+		params:   append([]apiParam{queryParam("buckets", "string", "Comma-separated ascending lower bounds in milliseconds")}, traceFilterParams...),
+		response: listOf("buckets", store.DurationBucket{}, nil)},
+	{method: "get", path: "/api/traces/slowest", summary: "Traces above a duration percentile",
+		params: []apiParam{
+			queryParam("p", "number", "Percentile between 0 and 1, default 0.99"),
+			queryParam("service", "string", "Service name"),
+			queryParam("limit", "integer", "Maximum number of traces"),
+		},
+		response: listOf("traces", store.Trace{}, map[string]interface{}{"percentile": numberSchema, "threshold_ms": numberSchema})},
+	{method: "get", path: "/api/traces/{id}", summary: "Get a trace with its spans",
+		params:   []apiParam{pathParam("id", "Trace ID"), queryParam("flatten", "boolean", "Flatten nested attributes into dotted keys")},
+		response: schemaFor(store.Trace{})},
+	{method: "delete", path: "/api/traces/{id}", summary: "Delete a trace (debug mode or with an auth token only)",
+		params: []apiParam{pathParam("id", "Trace ID"), queryParam("logs", "boolean", "Also delete the trace's logs")},
+		response: objectOf(map[string]interface{}{
+			"trace_id":      stringSchema,
+			"deleted_spans": integerSchema,
+			"deleted_logs":  integerSchema,
+		})},
+	{method: "get", path: "/api/traces/{id}/tree", summary: "Get a trace as a span hierarchy",
+		params: []apiParam{pathParam("id", "Trace ID")},
+		response: func(s openAPISchemas) map[string]interface{} {
+			return map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+				"trace_id":   stringSchema,
+				"span_count": integerSchema,
+				"roots":      map[string]interface{}{"type": "array", "items": s.schemaOf(reflect.TypeOf(store.SpanNode{}))},
+			}}
+		}},
+	{method: "get", path: "/api/traces/{id}/export", summary: "Export a trace as OTLP JSON",
+		params:   []apiParam{pathParam("id", "Trace ID"), queryParam("format", "string", "Export format, only otlp")},
+		response: objectOf(nil)},
+	{method: "post", path: "/api/traces/compare", summary: "Compare traces side by side",
+		body: CompareTracesRequest{},
+		response: func(s openAPISchemas) map[string]interface{} {
+			return map[string]interface{}{"type": "object", "properties": map[string]interface{}{
+				"traces":     map[string]interface{}{"type": "array", "items": s.schemaOf(reflect.TypeOf(store.Trace{}))},
+				"comparison": freeSchema,
+				"not_found":  map[string]interface{}{"type": "array", "items": stringSchema},
+			}}
+		}},
+	{method: "get", path: "/api/operations/stats", summary: "Per-operation latency percentiles",
+		params:   concatParams([]apiParam{queryParam("service", "string", "Service name")}, timeRangeParams),
+		response: listOf("operations", store.OperationStats{}, nil)},
+	{method: "get", path: "/api/spans/{id}", summary: "Get a span",
+		params:   []apiParam{pathParam("id", "Span ID")},
+		response: schemaFor(store.Span{})},
+	{method: "get", path: "/api/span-events", summary: "Spans that recorded an event",
+		params:   []apiParam{queryParam("name", "string", "Event name (required)"), rangeParam},
+		response: listOf("spans", store.Span{}, nil)},
+
+	// Logs
+	{method: "get", path: "/api/logs", summary: "List logs",
+		params: concatParams(logFilterParams, []apiParam{
+			queryParam("dedup", "boolean", "Collapse identical logs into one row with a count"),
+			queryParam("before", "string", "Cursor from next_cursor to page backwards"),
+			queryParam("after", "string", "Cursor to page forwards"),
+		}),
+		response: listOf("logs", store.LogRecord{}, map[string]interface{}{"total": integerSchema, "next_cursor": stringSchema})},
+	{method: "get", path: "/api/logs/export", summary: "Export logs as CSV",
+		params:      concatParams(logFilterParams, []apiParam{queryParam("format", "string", "Export format, only csv")}),
+		response:    func(openAPISchemas) map[string]interface{} { return stringSchema },
+		contentType: "text/csv"},
+	{method: "get", path: "/api/logs/histogram", summary: "Log volume per severity and time bucket",
+		params:   concatParams(logFilterParams, []apiParam{queryParam("bucket", "string", "Bucket size, e.g. 1 minute")}),
+		response: listOf("series", store.LogVolumeSeries{}, map[string]interface{}{"bucket": stringSchema})},
+	{method: "get", path: "/api/logs/tail", summary: "Stream new logs as Server-Sent Events",
+		params:      logFilterParams,
+		response:    func(openAPISchemas) map[string]interface{} { return stringSchema },
+		contentType: "text/event-stream"},
+	{method: "get", path: "/api/logs/trace/{traceId}", summary: "Logs of a trace",
+		params: []apiParam{
+			pathParam("traceId", "Trace ID"),
+			queryParam("correlate", "boolean", "Also match logs without a trace ID by time window"),
+			queryParam("window", "string", "Correlation window as a Go duration"),
+		},
+		response: listOf("logs", store.LogRecord{}, nil)},
+
+	// Metrics
+	{method: "get", path: "/api/metrics", summary: "List metric data points",
+		params: concatParams([]apiParam{
+			queryParam("name", "string", "Metric name"),
+			queryParam("type", "string", "Metric type"),
+			queryParam("service", "string", "Service name"),
+			queryParam("environment", "string", "deployment.environment resource attribute"),
+			queryParam("min_value", "number", "Minimum value"),
+			queryParam("max_value", "number", "Maximum value"),
+		}, timeRangeParams, pageParams),
+		response: listOf("metrics", store.MetricRecord{}, map[string]interface{}{"total": integerSchema})},
+	{method: "get", path: "/api/metrics/names", summary: "List metric names",
+		params: []apiParam{
+			queryParam("service", "string", "Service name"),
+			queryParam("environment", "string", "deployment.environment resource attribute"),
+		},
+		response: listOf("names", "", nil)},
+	{method: "get", path: "/api/metrics/by-trace/{traceId}", summary: "Metric data points with exemplars of a trace",
+		params:   []apiParam{pathParam("traceId", "Trace ID")},
+		response: listOf("metrics", store.MetricRecord{}, nil)},
+	{method: "get", path: "/api/metrics/exemplars", summary: "Exemplars of a metric",
+		params: []apiParam{
+			queryParam("metric", "string", "Metric name (required)"),
+			queryParam("service", "string", "Service name"),
+			queryParam("environment", "string", "deployment.environment resource attribute"),
+			rangeParam,
+		},
+		response: listOf("exemplars", store.ExemplarPoint{}, nil)},
+	{method: "post", path: "/api/metrics/aggregate", summary: "Aggregate a metric per time bucket",
+		body:     store.AggregationRequest{},
+		response: listOf("results", store.AggregationResult{}, nil)},
+	{method: "post", path: "/api/metrics/histogram", summary: "Histogram heatmap",
+		body:     store.HistogramHeatmapRequest{},
+		response: listOf("cells", store.HeatmapCell{}, nil)},
+	{method: "get", path: "/api/metrics/histogram/exemplars", summary: "Exemplars of a histogram with their buckets",
+		params: []apiParam{
+			queryParam("metric", "string", "Metric name (required)"),
+			queryParam("service", "string", "Service name"),
+			queryParam("environment", "string", "deployment.environment resource attribute"),
+			rangeParam,
+		},
+		response: listOf("exemplars", store.HistogramExemplar{}, nil)},
+
+	// Services
+	{method: "get", path: "/api/services", summary: "List services",
+		response: listOf("services", "", nil)},
+	{method: "get", path: "/api/services/environments", summary: "Services per deployment environment",
+		response: listOf("services", store.ServiceEnvironment{}, nil)},
+	{method: "get", path: "/api/services/{name}/errors", summary: "Error rate of a service per time bucket",
+		params: []apiParam{
+			pathParam("name", "Service name"),
+			queryParam("bucket", "string", "Bucket size, e.g. 1 minute"),
+			rangeParam,
+		},
+		response: listOf("points", store.ErrorRatePoint{}, map[string]interface{}{"service": stringSchema, "bucket": stringSchema})},
+	{method: "get", path: "/api/services/{name}/operations", summary: "Operations of a service",
+		params:   []apiParam{pathParam("name", "Service name")},
+		response: listOf("operations", store.OperationCount{}, map[string]interface{}{"service": stringSchema})},
+
+	// Attribute discovery
+	{method: "get", path: "/api/attributes/keys", summary: "Distinct attribute keys",
+		params:   []apiParam{queryParam("type", "string", "traces, logs or metrics")},
+		response: listOf("keys", "", nil)},
+	{method: "get", path: "/api/attributes/values", summary: "Suggested values of an attribute",
+		params: []apiParam{
+			queryParam("type", "string", "traces, logs or metrics"),
+			queryParam("key", "string", "Attribute key"),
+			queryParam("limit", "integer", "Maximum number of values"),
+		},
+		response: listOf("values", "", nil)},
+
+	// Dashboard summary
+	{method: "get", path: "/api/stats", summary: "Totals and the recent ingest rate",
+		response: objectOf(map[string]interface{}{
+			"traces":              integerSchema,
+			"spans":               integerSchema,
+			"error_traces":        integerSchema,
+			"logs":                integerSchema,
+			"metrics":             integerSchema,
+			"services":            integerSchema,
+			"ingest_rate_per_sec": numberSchema,
+			"failed_inserts":      countsSchema,
+		})},
+
+	// Recent errors
+	{method: "get", path: "/api/errors", summary: "Recent error traces and logs, newest first",
+		params: []apiParam{
+			queryParam("service", "string", "Service name"),
+			queryParam("limit", "integer", "Maximum number of events"),
+		},
+		response: listOf("errors", ErrorEvent{}, nil)},
+
+	{method: "delete", path: "/api/data", summary: "Delete all data (debug mode or with an auth token only)",
+		response: objectOf(map[string]interface{}{"deleted": countsSchema})},
+
+	{method: "get", path: "/api/version", summary: "Version of the running build",
+		response: schemaFor(VersionInfo{})},
+
+	{method: "get", path: "/api/openapi.json", summary: "This document",
+		response: objectOf(nil)},
+}
+
+// openAPISchemas collects the component schemas of the struct types referenced
+// by the document, keyed by type name
+type openAPISchemas map[string]interface{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the schema of t; structs are added to the components and
+// referenced by name
+func (s openAPISchemas) schemaOf(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if _, ok := s[t.Name()]; !ok {
+			// Reserve the name first so self-referencing types terminate
+			s[t.Name()] = nil
+			s[t.Name()] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema builds an object schema from a struct's json tags. Fields
+// without omitempty are required; embedded structs are inlined.
+func (s openAPISchemas) structSchema(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+	s.addFields(t, props, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s openAPISchemas) addFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			s.addFields(field.Type, props, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props[name] = s.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec assembles the OpenAPI 3 document for apiRoutes
+func buildOpenAPISpec() map[string]interface{} {
+	schemas := openAPISchemas{}
+	paths := map[string]interface{}{}
+
+	for _, route := range apiRoutes {
+		var params []interface{}
+		for _, p := range route.params {
+			params = append(params, map[string]interface{}{
+				"name":        p.name,
+				"in":          p.in,
+				"required":    p.in == "path",
+				"description": p.description,
+				"schema":      map[string]interface{}{"type": p.typ},
+			})
+		}
+
+		contentType := route.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		operation := map[string]interface{}{
+			"summary": route.summary,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "OK",
+					"content": map[string]interface{}{
+						contentType: map[string]interface{}{"schema": route.response(schemas)},
+					},
+				},
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+						},
+					},
+				},
+			},
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if route.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaOf(reflect.TypeOf(route.body))},
+				},
+			}
+		}
+
+		item, ok := paths[route.path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[route.path] = item
+		}
+		item[route.method] = operation
+	}
+
+	schemas["Error"] = map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"error": stringSchema},
+		"required":   []string{"error"},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "otel-front API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+		// The token is only enforced when --auth-token is set
+		"security": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"bearerAuth": []string{}},
+		},
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI description of the API
+type OpenAPIHandler struct {
+	spec map[string]interface{}
+}
+
+// NewOpenAPIHandler creates a new OpenAPI handler, building the document once
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{spec: buildOpenAPISpec()}
+}
+
+// GetSpec returns the OpenAPI 3 document
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	c.JSON(http.StatusOK, h.spec)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// IngestRater reports how many items per second are being ingested and how
+// many failed to store
+type IngestRater interface {
+	IngestRate() float64
+	FailedInserts() map[string]int64
+}
+
+// StatsHandler serves the dashboard summary
+type StatsHandler struct {
+	store  *store.Store
+	ingest IngestRater
+	logger *zap.Logger
+}
+
+// NewStatsHandler creates a new stats handler. ingest may be nil, in which
+// case the ingest rate is reported as zero and no failures are listed.
+func NewStatsHandler(store *store.Store, ingest IngestRater, logger *zap.Logger) *StatsHandler {
+	return &StatsHandler{
+		store:  store,
+		ingest: ingest,
+		logger: logger,
+	}
+}
+
+// GetStats returns totals and the recent ingest rate
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	counts := []struct {
+		name  string
+		count func() (int64, error)
+	}{
+		{"traces", func() (int64, error) { return h.store.Traces.CountTraces(ctx, store.TraceFilters{}) }},
+		{"spans", func() (int64, error) { return h.store.Traces.CountSpans(ctx) }},
+		{"error_traces", func() (int64, error) { return h.store.Traces.CountErrorTraces(ctx) }},
+		{"logs", func() (int64, error) { return h.store.Logs.CountLogs(ctx, store.LogFilters{}) }},
+		{"metrics", func() (int64, error) { return h.store.Metrics.GetMetricsCount(ctx) }},
+	}
+
+	stats := gin.H{}
+	for _, entry := range counts {
+		count, err := entry.count()
+		if err != nil {
+			h.logger.Error("Failed to get stats", zap.String("stat", entry.name), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+			return
+		}
+		stats[entry.name] = count
+	}
+
+	services, err := h.store.Traces.GetServices(ctx)
+	if err != nil {
+		h.logger.Error("Failed to get services", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stats"})
+		return
+	}
+	stats["services"] = len(services)
+
+	ingestRate := 0.0
+	failedInserts := map[string]int64{}
+	if h.ingest != nil {
+		ingestRate = h.ingest.IngestRate()
+		failedInserts = h.ingest.FailedInserts()
+	}
+	stats["ingest_rate_per_sec"] = ingestRate
+	stats["failed_inserts"] = failedInserts
+
+	c.JSON(http.StatusOK, stats)
+}
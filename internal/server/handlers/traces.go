@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/exporter"
 	"github.com/mesaglio/otel-front/internal/store"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 // TracesHandler handles trace-related HTTP requests
@@ -25,12 +33,107 @@ func NewTracesHandler(store *store.Store, logger *zap.Logger) *TracesHandler {
 
 // GetTraces returns a list of traces
 func (h *TracesHandler) GetTraces(c *gin.Context) {
+	filters := parseTraceFilters(c)
+
+	traces, err := h.store.Traces.GetTraces(c.Request.Context(), filters)
+	if err != nil {
+		h.logger.Error("Failed to get traces", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve traces"})
+		return
+	}
+
+	// Get total count for pagination
+	total, _ := h.store.Traces.CountTraces(c.Request.Context(), filters)
+
+	c.JSON(http.StatusOK, gin.H{
+		"traces": traces,
+		"count":  len(traces),
+		"total":  total,
+	})
+}
+
+// GetTraceDurationHistogram counts the traces matching the GetTraces filters per
+// duration bucket. buckets is a comma-separated list of ascending lower bounds in
+// milliseconds (default 0,10,50,100,500,1000,5000); the last bucket is open-ended.
+func (h *TracesHandler) GetTraceDurationHistogram(c *gin.Context) {
+	filters := parseTraceFilters(c)
+
+	bounds := store.DefaultDurationBuckets
+	if param := c.Query("buckets"); param != "" {
+		bounds = nil
+		for _, part := range strings.Split(param, ",") {
+			bound, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if err != nil || bound < 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid buckets, expected ascending millisecond bounds like 0,10,100"})
+				return
+			}
+			bounds = append(bounds, bound)
+		}
+	}
+
+	buckets, err := h.store.Traces.GetTraceDurationHistogram(c.Request.Context(), filters, bounds)
+	if err != nil {
+		h.logger.Error("Failed to get trace duration histogram", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trace duration histogram"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"buckets": buckets,
+		"count":   len(buckets),
+	})
+}
+
+// GetSlowestTraces returns the traces above a duration percentile (p, default
+// 0.99), optionally for one service, slowest first
+func (h *TracesHandler) GetSlowestTraces(c *gin.Context) {
+	percentile := 0.99
+	if param := c.Query("p"); param != "" {
+		val, err := strconv.ParseFloat(param, 64)
+		if err != nil || val <= 0 || val >= 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid p, expected a percentile between 0 and 1 like 0.99"})
+			return
+		}
+		percentile = val
+	}
+
+	traces, threshold, err := h.store.Traces.GetSlowestTraces(c.Request.Context(), c.Query("service"), percentile, getIntQuery(c, "limit", 100))
+	if err != nil {
+		h.logger.Error("Failed to get slowest traces", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve slowest traces"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"traces":       traces,
+		"count":        len(traces),
+		"percentile":   percentile,
+		"threshold_ms": threshold,
+	})
+}
+
+// parseTraceFilters reads the trace filter query parameters shared by GetTraces
+// and GetTraceDurationHistogram
+func parseTraceFilters(c *gin.Context) store.TraceFilters {
 	filters := store.TraceFilters{
 		ServiceName: c.Query("service"),
+		Environment: c.Query("environment"),
 		HasErrors:   c.Query("errors") == "true",
 		Search:      c.Query("search"),
+		SpanKind:    strings.ToLower(c.Query("kind")),
 		Limit:       getIntQuery(c, "limit", 100),
 		Offset:      getIntQuery(c, "offset", 0),
+		SortBy:      c.Query("sort"),
+		SortOrder:   c.Query("order"),
+		// Attributes are opt-in: typical SDK resource attributes make up most of
+		// a list page
+		IncludeAttributes: c.Query("include_attributes") == "true",
+	}
+
+	// Repeated service params select traces from any of them
+	if services := c.QueryArray("service"); len(services) > 1 {
+		filters.ServiceName = ""
+		filters.ServiceNames = services
 	}
 
 	if minDuration := c.Query("min_duration"); minDuration != "" {
@@ -45,62 +148,319 @@ func (h *TracesHandler) GetTraces(c *gin.Context) {
 		}
 	}
 
-	traces, err := h.store.Traces.GetTraces(c.Request.Context(), filters)
+	// Repeated attr=key:value and resource=key:value params must all match
+	filters.Attributes = parseAttributeParams(c, "attr")
+	filters.ResourceAttributes = parseAttributeParams(c, "resource")
+
+	return filters
+}
+
+// parseAttributeParams reads repeated key:value query params, returning nil
+// when there are none
+func parseAttributeParams(c *gin.Context, param string) map[string]string {
+	var attrs map[string]string
+	for _, attr := range c.QueryArray(param) {
+		if key, value, ok := strings.Cut(attr, ":"); ok && key != "" {
+			if attrs == nil {
+				attrs = map[string]string{}
+			}
+			attrs[key] = value
+		}
+	}
+	return attrs
+}
+
+// GetTraceByID returns a single trace with all spans. With flatten=true nested
+// attributes are returned under dot-joined keys.
+func (h *TracesHandler) GetTraceByID(c *gin.Context) {
+	traceID := c.Param("id")
+
+	trace, err := h.store.Traces.GetTraceByID(c.Request.Context(), traceID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trace not found"})
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to get traces", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve traces"})
+		h.logger.Error("Failed to get trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trace"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"traces": traces,
-		"count":  len(traces),
-	})
+	if c.Query("flatten") == "true" {
+		trace.FlattenAttributes()
+	}
+
+	c.JSON(http.StatusOK, trace)
 }
 
-// GetTraceByID returns a single trace with all spans
-func (h *TracesHandler) GetTraceByID(c *gin.Context) {
+// DeleteTrace removes a trace and its spans; with logs=true the logs correlated
+// with it are deleted as well
+func (h *TracesHandler) DeleteTrace(c *gin.Context) {
 	traceID := c.Param("id")
 
+	withLogs := c.Query("logs") == "true"
+	spans, logs, err := h.store.DeleteTrace(c.Request.Context(), traceID, withLogs)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trace not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete trace"})
+		return
+	}
+
+	response := gin.H{
+		"trace_id":      traceID,
+		"deleted_spans": spans,
+	}
+	if withLogs {
+		response["deleted_logs"] = logs
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportTrace returns a stored trace re-encoded for another backend.
+// Only format=otlp (OTLP JSON, the default) is supported.
+func (h *TracesHandler) ExportTrace(c *gin.Context) {
+	traceID := c.Param("id")
+
+	if format := c.DefaultQuery("format", "otlp"); format != "otlp" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format, expected otlp"})
+		return
+	}
+
 	trace, err := h.store.Traces.GetTraceByID(c.Request.Context(), traceID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trace not found"})
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to get trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trace"})
+		return
+	}
+
+	body, err := (&ptrace.JSONMarshaler{}).MarshalTraces(exporter.TraceToOTLP(trace))
+	if err != nil {
+		h.logger.Error("Failed to export trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export trace"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// GetSpanByID returns a single span, e.g. to jump from a correlated log to its span
+func (h *TracesHandler) GetSpanByID(c *gin.Context) {
+	spanID := c.Param("id")
+
+	span, err := h.store.Traces.GetSpanByID(c.Request.Context(), spanID)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Span not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get span", zap.Error(err), zap.String("span_id", spanID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve span"})
+		return
+	}
+
+	c.JSON(http.StatusOK, span)
+}
+
+// GetTraceTree returns a trace's spans arranged as a parent→children hierarchy
+func (h *TracesHandler) GetTraceTree(c *gin.Context) {
+	traceID := c.Param("id")
+
+	trace, err := h.store.Traces.GetTraceByID(c.Request.Context(), traceID)
+	if errors.Is(err, store.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Trace not found"})
 		return
 	}
+	if err != nil {
+		h.logger.Error("Failed to get trace", zap.Error(err), zap.String("trace_id", traceID))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trace"})
+		return
+	}
 
-	c.JSON(http.StatusOK, trace)
+	c.JSON(http.StatusOK, gin.H{
+		"trace_id":   trace.TraceID,
+		"span_count": len(trace.Spans),
+		"roots":      store.BuildSpanTree(trace.Spans),
+	})
+}
+
+// GetOperationStats returns latency percentiles and throughput per operation
+func (h *TracesHandler) GetOperationStats(c *gin.Context) {
+	var startTime, endTime time.Time
+
+	if start := c.Query("start_time"); start != "" {
+		if t, err := time.Parse(time.RFC3339, start); err == nil {
+			startTime = t
+		}
+	}
+
+	if end := c.Query("end_time"); end != "" {
+		if t, err := time.Parse(time.RFC3339, end); err == nil {
+			endTime = t
+		}
+	}
+
+	stats, err := h.store.Traces.GetOperationStats(c.Request.Context(), c.Query("service"), startTime, endTime)
+	if err != nil {
+		h.logger.Error("Failed to get operation stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve operation stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"operations": stats,
+		"count":      len(stats),
+	})
+}
+
+// GetServiceErrorRate returns a service's error rate per time bucket. Query params:
+// bucket ("1 minute", "5 minutes", ...) and range (Go duration, default 1h).
+// Buckets without traces are omitted; a gap in the series means no data.
+func (h *TracesHandler) GetServiceErrorRate(c *gin.Context) {
+	serviceName := c.Param("name")
+	bucket := c.DefaultQuery("bucket", "1 minute")
+
+	timeRange, ok := parseRange(c, time.Hour, maxQueryRange)
+	if !ok {
+		return
+	}
+
+	points, err := h.store.Traces.GetErrorRateTimeSeries(c.Request.Context(), serviceName, bucket, timeRange)
+	if errors.Is(err, store.ErrInvalidBucketSize) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket, expected a size like 5 minutes or 10m"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get error rate", zap.Error(err), zap.String("service", serviceName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve error rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service": serviceName,
+		"bucket":  bucket,
+		"points":  points,
+		"count":   len(points),
+	})
+}
+
+// GetServiceOperations returns the operations a service has produced with span counts
+func (h *TracesHandler) GetServiceOperations(c *gin.Context) {
+	serviceName := c.Param("name")
+
+	operations, err := h.store.Traces.GetOperationsByService(c.Request.Context(), serviceName)
+	if err != nil {
+		h.logger.Error("Failed to get operations", zap.Error(err), zap.String("service", serviceName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve operations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service":    serviceName,
+		"operations": operations,
+		"count":      len(operations),
+	})
 }
 
+// SearchSpanEvents returns spans that recorded an event with the given name.
+// Query params: name (required) and range (Go duration, default 1h).
+func (h *TracesHandler) SearchSpanEvents(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	timeRange, ok := parseRange(c, time.Hour, maxQueryRange)
+	if !ok {
+		return
+	}
+
+	spans, err := h.store.Traces.SearchSpanEvents(c.Request.Context(), name, timeRange)
+	if err != nil {
+		h.logger.Error("Failed to search span events", zap.Error(err), zap.String("event", name))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search span events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"spans": spans,
+		"count": len(spans),
+	})
+}
+
+// maxCompareTraces is the most traces CompareTraces accepts; keep in sync with
+// the binding on CompareTracesRequest
+const maxCompareTraces = 20
+
+// compareFetchWorkers bounds how many traces CompareTraces loads at once
+const compareFetchWorkers = 4
+
 // CompareTracesRequest represents a request to compare traces
 type CompareTracesRequest struct {
-	TraceIDs []string `json:"trace_ids" binding:"required,min=2,max=4"`
+	TraceIDs []string `json:"trace_ids" binding:"required,min=2,max=20"`
 }
 
-// CompareTraces compares two or more traces side by side
+// CompareTraces compares two or more traces side by side. Unknown trace IDs are
+// listed under not_found and the remaining traces are still compared, as long
+// as at least two were found.
 func (h *TracesHandler) CompareTraces(c *gin.Context) {
 	var req CompareTracesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body. Must provide 2-4 trace_ids"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body. Must provide 2-%d trace_ids", maxCompareTraces)})
 		return
 	}
 
-	if len(req.TraceIDs) < 2 || len(req.TraceIDs) > 4 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Must provide between 2 and 4 trace_ids"})
+	if len(req.TraceIDs) < 2 || len(req.TraceIDs) > maxCompareTraces {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Must provide between 2 and %d trace_ids", maxCompareTraces)})
 		return
 	}
 
-	// Fetch all traces
-	traces := make([]*store.Trace, 0, len(req.TraceIDs))
-	for _, traceID := range req.TraceIDs {
-		trace, err := h.store.Traces.GetTraceByID(c.Request.Context(), traceID)
-		if err != nil {
-			h.logger.Warn("Failed to get trace for comparison", zap.Error(err), zap.String("trace_id", traceID))
-			c.JSON(http.StatusNotFound, gin.H{"error": "One or more traces not found", "trace_id": traceID})
-			return
+	// Fetch all traces, a few at a time; a missing trace leaves a nil slot
+	fetched := make([]*store.Trace, len(req.TraceIDs))
+	g, ctx := errgroup.WithContext(c.Request.Context())
+	g.SetLimit(compareFetchWorkers)
+	for i, traceID := range req.TraceIDs {
+		g.Go(func() error {
+			trace, err := h.store.Traces.GetTraceByID(ctx, traceID)
+			if errors.Is(err, store.ErrTraceNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("trace %s: %w", traceID, err)
+			}
+			fetched[i] = trace
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		h.logger.Error("Failed to get traces for comparison", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve traces"})
+		return
+	}
+
+	// Keep the requested order
+	traces := make([]*store.Trace, 0, len(fetched))
+	notFound := []string{}
+	for i, trace := range fetched {
+		if trace == nil {
+			notFound = append(notFound, req.TraceIDs[i])
+			continue
 		}
 		traces = append(traces, trace)
 	}
+	if len(traces) < 2 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fewer than two of the traces were found", "not_found": notFound})
+		return
+	}
 
 	// Calculate comparison statistics
 	comparison := h.compareTracesStats(traces)
@@ -108,6 +468,7 @@ func (h *TracesHandler) CompareTraces(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"traces":     traces,
 		"comparison": comparison,
+		"not_found":  notFound,
 	})
 }
 
@@ -165,10 +526,102 @@ func (h *TracesHandler) compareTracesStats(traces []*store.Trace) map[string]int
 			"max": maxSpans,
 			"avg": avgSpans,
 		},
-		"total_errors": totalErrors,
+		"total_errors":   totalErrors,
+		"operation_diff": diffOperations(traces),
 	}
 }
 
+// operationDiff compares one operation across the traces being compared. Slices
+// are indexed like the traces; the first trace is the baseline for deltas.
+type operationDiff struct {
+	ServiceName   string   `json:"service_name"`
+	OperationName string   `json:"operation_name"`
+	SpanCounts    []int    `json:"span_counts"`          // Spans with this operation per trace (0 when absent)
+	DurationMs    []*int64 `json:"duration_ms"`          // Summed span duration per trace, null when absent
+	DeltaMs       []*int64 `json:"delta_ms"`             // Duration minus the first trace's, null when either is absent
+	MissingIn     []string `json:"missing_in,omitempty"` // IDs of traces without this operation
+}
+
+// diffOperations builds a per-operation structural diff: which operations are
+// missing from some traces and how their durations differ from the first trace.
+// Operations are ordered by largest absolute delta, then missing ones, then name.
+func diffOperations(traces []*store.Trace) []operationDiff {
+	type opKey struct{ service, operation string }
+
+	byOp := make(map[opKey]*operationDiff)
+	for i, trace := range traces {
+		for _, span := range trace.Spans {
+			key := opKey{span.ServiceName, span.OperationName}
+			diff, ok := byOp[key]
+			if !ok {
+				diff = &operationDiff{
+					ServiceName:   span.ServiceName,
+					OperationName: span.OperationName,
+					SpanCounts:    make([]int, len(traces)),
+					DurationMs:    make([]*int64, len(traces)),
+					DeltaMs:       make([]*int64, len(traces)),
+				}
+				byOp[key] = diff
+			}
+			diff.SpanCounts[i]++
+			if diff.DurationMs[i] == nil {
+				diff.DurationMs[i] = new(int64)
+			}
+			*diff.DurationMs[i] += span.DurationMs
+		}
+	}
+
+	result := make([]operationDiff, 0, len(byOp))
+	for _, diff := range byOp {
+		baseline := diff.DurationMs[0]
+		for i, trace := range traces {
+			if diff.DurationMs[i] == nil {
+				diff.MissingIn = append(diff.MissingIn, trace.TraceID)
+				continue
+			}
+			if baseline != nil {
+				delta := *diff.DurationMs[i] - *baseline
+				diff.DeltaMs[i] = &delta
+			}
+		}
+		result = append(result, *diff)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := &result[i], &result[j]
+		if da, db := a.maxAbsDelta(), b.maxAbsDelta(); da != db {
+			return da > db
+		}
+		if len(a.MissingIn) != len(b.MissingIn) {
+			return len(a.MissingIn) > len(b.MissingIn)
+		}
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		return a.OperationName < b.OperationName
+	})
+
+	return result
+}
+
+// maxAbsDelta returns the largest absolute duration change from the first trace
+func (d *operationDiff) maxAbsDelta() int64 {
+	max := int64(0)
+	for _, delta := range d.DeltaMs {
+		if delta == nil {
+			continue
+		}
+		abs := *delta
+		if abs < 0 {
+			abs = -abs
+		}
+		if abs > max {
+			max = abs
+		}
+	}
+	return max
+}
+
 // Helper function to get int query parameter with default
 func getIntQuery(c *gin.Context, key string, defaultVal int) int {
 	if val := c.Query(key); val != "" {
@@ -178,3 +631,23 @@ func getIntQuery(c *gin.Context, key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+// maxQueryRange bounds the ?range= lookback so one request can't scan the
+// whole history
+const maxQueryRange = 7 * 24 * time.Hour
+
+// parseRange reads the ?range= lookback as a Go duration, defaulting to def.
+// A range above maxRange is lowered to maxRange. Invalid ranges get 400 and
+// false.
+func parseRange(c *gin.Context, def, maxRange time.Duration) (time.Duration, bool) {
+	raw := c.Query("range")
+	if raw == "" {
+		return def, true
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range, expected a duration like 1h or 30m"})
+		return 0, false
+	}
+	return min(d, maxRange), true
+}
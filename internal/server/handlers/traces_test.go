@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+// setupTracesHandler returns a handler backed by a migrated in-memory store
+// holding n traces named trace-0 ... trace-<n-1>
+func setupTracesHandler(t *testing.T, n int) *TracesHandler {
+	t.Helper()
+	ctx := context.Background()
+	logger := zap.NewNop()
+	s := newTestStore(t)
+
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		traceID := fmt.Sprintf("trace-%d", i)
+		duration := time.Duration(10*(i+1)) * time.Millisecond
+		if err := s.Traces.InsertTrace(ctx, &store.Trace{
+			TraceID:       traceID,
+			ServiceName:   "api",
+			OperationName: "GET /users",
+			StartTime:     now,
+			EndTime:       now.Add(duration),
+			DurationMs:    duration.Milliseconds(),
+			SpanCount:     1,
+			Spans: []store.Span{{
+				SpanID:        fmt.Sprintf("span-%d", i),
+				TraceID:       traceID,
+				ServiceName:   "api",
+				OperationName: "GET /users",
+				StartTime:     now,
+				EndTime:       now.Add(duration),
+				DurationMs:    duration.Milliseconds(),
+			}},
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	return NewTracesHandler(s, logger)
+}
+
+// postCompare sends trace IDs to CompareTraces and decodes the response
+func postCompare(t *testing.T, h *TracesHandler, traceIDs []string) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/traces/compare", h.CompareTraces)
+
+	body, _ := json.Marshal(CompareTracesRequest{TraceIDs: traceIDs})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/traces/compare", bytes.NewReader(body)))
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestCompareManyTraces(t *testing.T) {
+	h := setupTracesHandler(t, 8)
+
+	ids := make([]string, 8)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("trace-%d", i)
+	}
+
+	code, resp := postCompare(t, h, ids)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp)
+	}
+
+	traces := resp["traces"].([]interface{})
+	if len(traces) != 8 {
+		t.Fatalf("Expected 8 traces, got %d", len(traces))
+	}
+	for i, trace := range traces {
+		if id := trace.(map[string]interface{})["trace_id"]; id != ids[i] {
+			t.Errorf("Expected trace %d to be %s, got %v", i, ids[i], id)
+		}
+	}
+
+	comparison := resp["comparison"].(map[string]interface{})
+	if count := comparison["count"].(float64); count != 8 {
+		t.Errorf("Expected comparison count 8, got %v", count)
+	}
+	duration := comparison["duration_ms"].(map[string]interface{})
+	if duration["min"].(float64) != 10 || duration["max"].(float64) != 80 {
+		t.Errorf("Expected durations between 10 and 80ms, got %v", duration)
+	}
+
+	tooMany := make([]string, maxCompareTraces+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("trace-%d", i)
+	}
+	if code, _ := postCompare(t, h, tooMany); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for %d traces, got %d", len(tooMany), code)
+	}
+}
+
+func TestDiffOperations(t *testing.T) {
+	fast := &store.Trace{TraceID: "fast", Spans: []store.Span{
+		{ServiceName: "api", OperationName: "GET /users", DurationMs: 40},
+		{ServiceName: "api", OperationName: "SELECT users", DurationMs: 10},
+		{ServiceName: "api", OperationName: "cache.get", DurationMs: 2},
+	}}
+	slow := &store.Trace{TraceID: "slow", Spans: []store.Span{
+		{ServiceName: "api", OperationName: "GET /users", DurationMs: 400},
+		{ServiceName: "api", OperationName: "SELECT users", DurationMs: 150},
+		{ServiceName: "api", OperationName: "SELECT users", DurationMs: 200},
+		{ServiceName: "auth", OperationName: "verify", DurationMs: 30},
+	}}
+
+	diff := diffOperations([]*store.Trace{fast, slow})
+	if len(diff) != 4 {
+		t.Fatalf("Expected 4 operations, got %d", len(diff))
+	}
+
+	byName := make(map[string]operationDiff, len(diff))
+	for _, d := range diff {
+		byName[d.OperationName] = d
+	}
+
+	root := byName["GET /users"]
+	if root.DeltaMs[1] == nil || *root.DeltaMs[1] != 360 {
+		t.Errorf("Expected GET /users delta 360ms, got %v", root.DeltaMs[1])
+	}
+	if len(root.MissingIn) != 0 {
+		t.Errorf("Expected GET /users in both traces, missing in %v", root.MissingIn)
+	}
+
+	query := byName["SELECT users"]
+	if query.SpanCounts[0] != 1 || query.SpanCounts[1] != 2 {
+		t.Errorf("Expected SELECT users span counts [1 2], got %v", query.SpanCounts)
+	}
+	if query.DurationMs[1] == nil || *query.DurationMs[1] != 350 {
+		t.Errorf("Expected SELECT users summed duration 350ms in slow trace, got %v", query.DurationMs[1])
+	}
+
+	cache := byName["cache.get"]
+	if len(cache.MissingIn) != 1 || cache.MissingIn[0] != "slow" {
+		t.Errorf("Expected cache.get missing in slow, got %v", cache.MissingIn)
+	}
+	if cache.DurationMs[1] != nil || cache.DeltaMs[1] != nil {
+		t.Errorf("Expected no duration or delta where cache.get is absent")
+	}
+
+	verify := byName["verify"]
+	if len(verify.MissingIn) != 1 || verify.MissingIn[0] != "fast" {
+		t.Errorf("Expected verify missing in fast, got %v", verify.MissingIn)
+	}
+	if verify.DeltaMs[1] != nil {
+		t.Errorf("Expected no delta without a baseline, got %v", *verify.DeltaMs[1])
+	}
+
+	// Largest change first
+	if diff[0].OperationName != "GET /users" {
+		t.Errorf("Expected GET /users first, got %s", diff[0].OperationName)
+	}
+}
+
+func TestCompareTracesWithMissingTrace(t *testing.T) {
+	h := setupTracesHandler(t, 3)
+
+	code, resp := postCompare(t, h, []string{"trace-0", "trace-missing", "trace-2"})
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", code, resp)
+	}
+
+	traces := resp["traces"].([]interface{})
+	if len(traces) != 2 {
+		t.Fatalf("Expected 2 compared traces, got %d", len(traces))
+	}
+	notFound := resp["not_found"].([]interface{})
+	if len(notFound) != 1 || notFound[0] != "trace-missing" {
+		t.Errorf("Expected not_found [trace-missing], got %v", notFound)
+	}
+	if count := resp["comparison"].(map[string]interface{})["count"].(float64); count != 2 {
+		t.Errorf("Expected comparison of 2 traces, got %v", count)
+	}
+
+	code, resp = postCompare(t, h, []string{"trace-0", "trace-missing"})
+	if code != http.StatusNotFound {
+		t.Errorf("Expected 404 when fewer than two traces exist, got %d: %v", code, resp)
+	}
+}
+
+func TestGetTraceByIDErrors(t *testing.T) {
+	h := setupTracesHandler(t, 1)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/traces/:id", h.GetTraceByID)
+	router.GET("/api/spans/:id", h.GetSpanByID)
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	if code := get("/api/traces/trace-0"); code != http.StatusOK {
+		t.Errorf("Expected 200 for a stored trace, got %d", code)
+	}
+	if code := get("/api/traces/missing"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing trace, got %d", code)
+	}
+	if code := get("/api/spans/missing"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing span, got %d", code)
+	}
+
+	// A broken store is a server error, not a missing trace
+	h.store.Close()
+	if code := get("/api/traces/trace-0"); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the store fails, got %d", code)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		query  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "default", query: "", want: time.Hour, wantOK: true},
+		{name: "valid", query: "30m", want: 30 * time.Minute, wantOK: true},
+		{name: "clamped to max", query: "10000h", want: maxQueryRange, wantOK: true},
+		{name: "not a duration", query: "soon", wantOK: false},
+		{name: "negative", query: "-1h", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?range="+tt.query, nil)
+
+			got, ok := parseRange(c, time.Hour, maxQueryRange)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.want, tt.wantOK, got, ok)
+			}
+			if !ok && w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for an invalid range, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionInfo identifies the running build
+type VersionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// VersionHandler serves the build version
+type VersionHandler struct {
+	info VersionInfo
+}
+
+// NewVersionHandler creates a new version handler for the given build
+func NewVersionHandler(version, commit, date string) *VersionHandler {
+	return &VersionHandler{info: VersionInfo{Version: version, Commit: commit, Date: date}}
+}
+
+// GetVersion returns the version, commit and build date of the server
+func (h *VersionHandler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, h.info)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/auth"
+)
+
+// Auth creates a Gin middleware that requires an "Authorization: Bearer <token>"
+// header matching the configured token. An empty token disables the check.
+func Auth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		if !auth.ValidBearerToken(c.GetHeader("Authorization"), token) {
+			c.Header("WWW-Authenticate", `Bearer realm="otel-front"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		c.Next()
+	}
+}
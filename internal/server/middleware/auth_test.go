@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAuthTestRouter(token string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Auth(token))
+	router.GET("/api/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return router
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{name: "disabled when token empty", token: "", header: "", want: http.StatusOK},
+		{name: "missing header", token: "secret", header: "", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "secret", header: "Basic secret", want: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", header: "Bearer secret", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAuthTestRouter(tt.token)
+			req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
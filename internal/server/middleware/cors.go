@@ -12,6 +12,7 @@ func CORS() func(http.Handler) http.Handler {
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{RequestIDHeader},
 		AllowCredentials: true,
 	})
 	return c.Handler
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newGzipTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gzip())
+	router.GET("/api/large", func(c *gin.Context) {
+		items := make([]string, 200)
+		for i := range items {
+			items[i] = "span-" + strings.Repeat("x", 20)
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "count": len(items)})
+	})
+	router.GET("/api/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/api/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/octet-stream", make([]byte, 4096))
+	})
+	return router
+}
+
+func TestGzip(t *testing.T) {
+	router := newGzipTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Expected Content-Encoding gzip, got %q", got)
+	}
+
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	var body struct {
+		Items []string `json:"items"`
+		Count int      `json:"count"`
+	}
+	if err := json.NewDecoder(reader).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode decompressed body: %v", err)
+	}
+	if body.Count != 200 || len(body.Items) != 200 {
+		t.Errorf("Expected 200 items, got count=%d len=%d", body.Count, len(body.Items))
+	}
+}
+
+func TestGzipSkipped(t *testing.T) {
+	router := newGzipTestRouter()
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{name: "client without gzip", path: "/api/large", acceptEncoding: "", wantEncoding: ""},
+		{name: "gzip refused", path: "/api/large", acceptEncoding: "gzip;q=0", wantEncoding: ""},
+		{name: "small response", path: "/api/small", acceptEncoding: "gzip", wantEncoding: ""},
+		{name: "already encoded", path: "/api/encoded", acceptEncoding: "gzip", wantEncoding: "br"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+			if tt.path == "/api/small" && !json.Valid(w.Body.Bytes()) {
+				t.Errorf("Expected an uncompressed JSON body, got %q", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryLimits creates a Gin middleware that validates the limit and offset
+// query parameters shared by the list endpoints. Values that are not
+// non-negative integers get 400; a limit above maxLimit is lowered to maxLimit
+// so one request can't scan an unbounded result set into memory. Routes listed
+// in exempt (full route paths such as /api/logs/export) enforce their own
+// maximum and are only validated, as is everything with a maxLimit of zero or
+// less.
+func QueryLimits(maxLimit int, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Read the URL directly: c.Query would cache the values before they are clamped
+		query := c.Request.URL.Query()
+
+		for _, param := range []string{"limit", "offset"} {
+			raw := query.Get(param)
+			if raw == "" {
+				continue
+			}
+			if val, err := strconv.Atoi(raw); err != nil || val < 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + ", expected a non-negative integer"})
+				return
+			}
+		}
+
+		if raw := query.Get("limit"); raw != "" && maxLimit > 0 && !slices.Contains(exempt, c.FullPath()) {
+			if limit, _ := strconv.Atoi(raw); limit > maxLimit {
+				query.Set("limit", strconv.Itoa(maxLimit))
+				c.Request.URL.RawQuery = query.Encode()
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestQueryLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(QueryLimits(100))
+	router.GET("/api/logs", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Query("limit"))
+	})
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{"limit=50", http.StatusOK, "50"},
+		{"limit=100", http.StatusOK, "100"},
+		{"limit=100000000", http.StatusOK, "100"},
+		{"", http.StatusOK, ""},
+		{"limit=-1", http.StatusBadRequest, ""},
+		{"limit=abc", http.StatusBadRequest, ""},
+		{"offset=-10", http.StatusBadRequest, ""},
+		{"limit=10&offset=20", http.StatusOK, "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs?"+tt.query, nil))
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode == http.StatusOK && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected effective limit %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestQueryLimitsNoMaximum(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(QueryLimits(0))
+	router.GET("/api/logs", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Query("limit"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs?limit=100000000", nil))
+	if w.Body.String() != "100000000" {
+		t.Errorf("Expected limit left as-is without a maximum, got %q", w.Body.String())
+	}
+}
+
+func TestQueryLimitsExemptRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(QueryLimits(100, "/api/logs/export"))
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, c.Query("limit"))
+	}
+	router.GET("/api/logs", handler)
+	router.GET("/api/logs/export", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/export?limit=50000", nil))
+	if w.Body.String() != "50000" {
+		t.Errorf("Expected the exempt route to keep its limit, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/export?limit=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected the exempt route to still be validated, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs?limit=50000", nil))
+	if w.Body.String() != "100" {
+		t.Errorf("Expected other routes to be clamped, got %q", w.Body.String())
+	}
+}
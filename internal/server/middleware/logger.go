@@ -20,6 +20,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("path", path),
 			zap.Int("status", c.Writer.Status()),
 			zap.Duration("duration", duration),
+			zap.String("request_id", GetRequestID(c)),
 		)
 	}
 }
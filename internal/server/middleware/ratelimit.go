@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitCleanupInterval is how often idle client buckets are discarded
+const rateLimitCleanupInterval = time.Minute
+
+// RateLimit creates a Gin middleware that allows each client IP requestsPerSecond
+// requests per second, with bursts of up to the same number. Requests over the
+// limit get 429 with a Retry-After header. Zero or less disables the limit.
+func RateLimit(requestsPerSecond int) gin.HandlerFunc {
+	if requestsPerSecond <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	limiter := newRateLimiter(float64(requestsPerSecond), time.Now)
+	return func(c *gin.Context) {
+		if wait, ok := limiter.allow(c.ClientIP()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tokenBucket holds the tokens left for one client as of updated
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter is a per-key token bucket refilled at rate tokens per second
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu          sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+func newRateLimiter(rate float64, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		rate:        rate,
+		burst:       math.Max(1, rate),
+		now:         now,
+		buckets:     make(map[string]*tokenBucket),
+		lastCleanup: now(),
+	}
+}
+
+// allow takes a token for key. When none is left it returns false and how long
+// until the next token is available.
+func (l *rateLimiter) allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second)), false
+	}
+	bucket.tokens--
+	return 0, true
+}
+
+// cleanup drops buckets that have refilled completely, since they behave
+// exactly like a new client; the caller must hold l.mu
+func (l *rateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < rateLimitCleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
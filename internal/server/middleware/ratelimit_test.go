@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(2))
+	router.GET("/api/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The burst allows as many requests as the per-second rate
+	for i := 0; i < 2; i++ {
+		if w := request("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got status %d", i+1, w.Code)
+		}
+	}
+
+	w := request("10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 past the limit, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own bucket
+	if w := request("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to pass, got status %d", w.Code)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(0))
+	router.GET("/api/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	for i := 0; i < 50; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 with the limit disabled, got %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(4, func() time.Time { return now })
+
+	for i := 0; i < 4; i++ {
+		if _, ok := limiter.allow("client"); !ok {
+			t.Fatalf("Expected request %d within the burst to pass", i+1)
+		}
+	}
+
+	wait, ok := limiter.allow("client")
+	if ok {
+		t.Fatal("Expected request past the burst to be limited")
+	}
+	if wait != 250*time.Millisecond {
+		t.Errorf("Expected to wait 250ms for the next token, got %v", wait)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if _, ok := limiter.allow("client"); !ok {
+		t.Error("Expected a refilled token to be available")
+	}
+
+	// Idle buckets are dropped once they have refilled
+	now = now.Add(2 * rateLimitCleanupInterval)
+	limiter.allow("other")
+	if _, ok := limiter.buckets["client"]; ok {
+		t.Error("Expected the idle bucket to be cleaned up")
+	}
+}
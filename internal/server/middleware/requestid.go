@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey stores the request ID on the gin context
+const requestIDKey = "request_id"
+
+// maxRequestIDLength bounds client-supplied IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+// RequestID creates a Gin middleware that tags every request with an ID. A
+// well-formed X-Request-ID from the client is reused, otherwise a random one is
+// generated. The ID is echoed in the response header and added to JSON error
+// bodies so failures can be matched to server logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Writer = &requestIDResponseWriter{ResponseWriter: c.Writer, id: id}
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID, or "" if it did not run
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID accepts short IDs made of URL-safe characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex encoded
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestIDResponseWriter adds a "request_id" field to JSON error bodies
+type requestIDResponseWriter struct {
+	gin.ResponseWriter
+	id      string
+	started bool
+}
+
+func (w *requestIDResponseWriter) Write(data []byte) (int, error) {
+	if w.started {
+		return w.ResponseWriter.Write(data)
+	}
+	w.started = true
+
+	if !w.isJSONError() || len(data) < 2 || data[0] != '{' {
+		return w.ResponseWriter.Write(data)
+	}
+
+	// Splice the field in right after the opening brace
+	field, _ := json.Marshal(w.id)
+	body := make([]byte, 0, len(data)+len(field)+16)
+	body = append(body, `{"request_id":`...)
+	body = append(body, field...)
+	if rest := strings.TrimSpace(string(data[1:])); !strings.HasPrefix(rest, "}") {
+		body = append(body, ',')
+	}
+	body = append(body, data[1:]...)
+
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *requestIDResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *requestIDResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// isJSONError reports whether the response is an uncompressed JSON error
+func (w *requestIDResponseWriter) isJSONError() bool {
+	header := w.Header()
+	return w.Status() >= 400 &&
+		strings.HasPrefix(header.Get("Content-Type"), "application/json") &&
+		header.Get("Content-Encoding") == ""
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/api/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"request_id_seen": GetRequestID(c)})
+	})
+	router.GET("/api/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve traces"})
+	})
+	return router
+}
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	router := newRequestIDRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ok", nil)
+	req.Header.Set(RequestIDHeader, "client-id-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "client-id-123" {
+		t.Errorf("Expected X-Request-ID client-id-123, got %q", got)
+	}
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["request_id_seen"] != "client-id-123" {
+		t.Errorf("Expected handler to see client-id-123, got %q", resp["request_id_seen"])
+	}
+	if _, ok := resp["request_id"]; ok {
+		t.Errorf("Expected no request_id in successful body, got %v", resp)
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	router := newRequestIDRouter()
+
+	for _, incoming := range []string{"", "bad id with spaces"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/ok", nil)
+		if incoming != "" {
+			req.Header.Set(RequestIDHeader, incoming)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		got := w.Header().Get(RequestIDHeader)
+		if len(got) != 32 {
+			t.Errorf("Expected a generated 32-char ID for %q, got %q", incoming, got)
+		}
+	}
+}
+
+func TestRequestIDInErrorBody(t *testing.T) {
+	router := newRequestIDRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/fail", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Expected valid JSON error body, got %q: %v", w.Body.String(), err)
+	}
+	if resp["request_id"] != "abc" {
+		t.Errorf("Expected request_id abc in error body, got %v", resp)
+	}
+	if resp["error"] != "Failed to retrieve traces" {
+		t.Errorf("Expected original error preserved, got %v", resp)
+	}
+}
+
+func TestRequestIDInAbortedErrorBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.Use(Auth("secret"))
+	router.Use(Gzip())
+	router.GET("/api/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ok", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401, got %d", w.Code)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Expected valid JSON error body, got %q: %v", w.Body.String(), err)
+	}
+	if resp["request_id"] != "abc" {
+		t.Errorf("Expected request_id abc in auth error body, got %v", resp)
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeoutBody replaces the error body of a request that ran out of time
+var timeoutBody = []byte(`{"error":"Request timed out"}`)
+
+// Timeout creates a Gin middleware that cancels the request context after
+// timeout, so store queries run with it are abandoned instead of tying up the
+// server. A handler that fails with a server error once the deadline has
+// passed answers 503 instead. Routes listed in streams (full route paths such
+// as /api/logs/tail) are long-lived tails or streamed exports and keep an
+// unbounded context. A timeout of zero or less disables it.
+func Timeout(timeout time.Duration, streams ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || slices.Contains(streams, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &timeoutResponseWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Next()
+	}
+}
+
+// timeoutResponseWriter turns server errors caused by the deadline into 503
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+	replaced bool
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+		code = http.StatusServiceUnavailable
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
+	if !w.timedOut {
+		return w.ResponseWriter.Write(data)
+	}
+
+	// The handler's own error body describes the cancelled query, not the timeout
+	if !w.replaced {
+		w.replaced = true
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if _, err := w.ResponseWriter.Write(timeoutBody); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *timeoutResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTimeoutTestRouter(timeout time.Duration) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Timeout(timeout, "/api/logs/tail"))
+
+	// Stands in for a slow store query: fails once the context is cancelled
+	slow := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query logs"})
+		case <-time.After(50 * time.Millisecond):
+			c.String(http.StatusOK, "done")
+		}
+	}
+	router.GET("/api/logs", slow)
+	router.GET("/api/logs/tail", slow)
+	return router
+}
+
+func TestTimeout(t *testing.T) {
+	router := newTimeoutTestRouter(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Request timed out") {
+		t.Errorf("Expected a timeout error body, got %s", w.Body.String())
+	}
+}
+
+func TestTimeoutSkipsStreams(t *testing.T) {
+	router := newTimeoutTestRouter(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/tail", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the stream to outlive the timeout, got %d", w.Code)
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	router := newTimeoutTestRouter(0)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected no timeout when disabled, got %d", w.Code)
+	}
+}
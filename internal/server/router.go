@@ -2,46 +2,105 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/config"
 	"github.com/mesaglio/otel-front/internal/server/handlers"
 	"github.com/mesaglio/otel-front/internal/server/middleware"
 	"github.com/mesaglio/otel-front/internal/store"
 	"go.uber.org/zap"
 )
 
-// SetupRouter configures all HTTP routes
-func SetupRouter(store *store.Store, logger *zap.Logger) *gin.Engine {
+// SetupRouter configures all HTTP routes. health serves the liveness and
+// readiness probes; its readiness is controlled by the caller.
+func SetupRouter(cfg *config.Config, store *store.Store, health *handlers.HealthHandler, ingest handlers.IngestRater, tail handlers.LogSubscriber, logger *zap.Logger) *gin.Engine {
 	router := gin.New()
+	// No proxy is trusted, so ClientIP (which keys the rate limiter) is the
+	// connection's address rather than a client-supplied X-Forwarded-For
+	router.SetTrustedProxies(nil)
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
 
 	// Initialize handlers
-	healthHandler := handlers.NewHealthHandler()
 	tracesHandler := handlers.NewTracesHandler(store, logger)
-	logsHandler := handlers.NewLogsHandler(store, logger)
+	logsHandler := handlers.NewLogsHandler(store, tail, logger)
+	logsHandler.SetDefaultLookback(cfg.Server.DefaultLookback)
 	metricsHandler := handlers.NewMetricsHandler(store, logger)
+	metricsHandler.SetDefaultLookback(cfg.Server.DefaultLookback)
+	statsHandler := handlers.NewStatsHandler(store, ingest, logger)
+	attributesHandler := handlers.NewAttributesHandler(store, logger)
+	dataHandler := handlers.NewDataHandler(store, logger)
+	errorsHandler := handlers.NewErrorsHandler(store, logger)
+	openAPIHandler := handlers.NewOpenAPIHandler()
+	versionHandler := handlers.NewVersionHandler(cfg.Build.Version, cfg.Build.Commit, cfg.Build.Date)
 
-	// Health check
-	router.GET("/health", healthHandler.HandleHealth)
+	// Health checks: /health is kept as an alias of the liveness probe
+	router.GET("/health", health.HandleHealth)
+	router.GET("/livez", health.HandleHealth)
+	router.GET("/readyz", health.HandleReady)
 
 	// API routes
 	api := router.Group("/api")
+	api.Use(middleware.RateLimit(cfg.Server.RateLimit))
+	api.Use(middleware.Auth(cfg.Server.AuthToken))
+	api.Use(middleware.QueryLimits(cfg.Server.MaxQueryLimit, "/api/logs/export"))
+	api.Use(middleware.Gzip())
+	api.Use(middleware.Timeout(cfg.Server.RequestTimeout, "/api/logs/tail", "/api/logs/export", "/api/traces/:id/export"))
 	{
 		// Traces
 		api.GET("/traces", tracesHandler.GetTraces)
+		api.GET("/traces/histogram", tracesHandler.GetTraceDurationHistogram)
+		api.GET("/traces/slowest", tracesHandler.GetSlowestTraces)
 		api.GET("/traces/:id", tracesHandler.GetTraceByID)
+		api.GET("/traces/:id/tree", tracesHandler.GetTraceTree)
+		api.GET("/traces/:id/export", tracesHandler.ExportTrace)
 		api.POST("/traces/compare", tracesHandler.CompareTraces)
+		api.GET("/operations/stats", tracesHandler.GetOperationStats)
+		api.GET("/spans/:id", tracesHandler.GetSpanByID)
+		api.GET("/span-events", tracesHandler.SearchSpanEvents)
 
 		// Logs
 		api.GET("/logs", logsHandler.GetLogs)
+		api.GET("/logs/export", logsHandler.ExportLogs)
+		api.GET("/logs/histogram", logsHandler.GetLogVolume)
+		api.GET("/logs/tail", logsHandler.TailLogs)
 		api.GET("/logs/trace/:traceId", logsHandler.GetLogsByTraceID)
 
 		// Metrics
 		api.GET("/metrics", metricsHandler.GetMetrics)
 		api.GET("/metrics/names", metricsHandler.GetMetricNames)
+		api.GET("/metrics/by-trace/:traceId", metricsHandler.GetMetricsByTrace)
+		api.GET("/metrics/exemplars", metricsHandler.GetExemplars)
 		api.POST("/metrics/aggregate", metricsHandler.AggregateMetrics)
+		api.POST("/metrics/histogram", metricsHandler.GetHistogramHeatmap)
+		api.GET("/metrics/histogram/exemplars", metricsHandler.GetHistogramExemplars)
 
 		// Services
 		api.GET("/services", metricsHandler.GetServices)
+		api.GET("/services/environments", metricsHandler.GetServiceEnvironments)
+		api.GET("/services/:name/errors", tracesHandler.GetServiceErrorRate)
+		api.GET("/services/:name/operations", tracesHandler.GetServiceOperations)
+
+		// Attribute discovery
+		api.GET("/attributes/keys", attributesHandler.GetAttributeKeys)
+		api.GET("/attributes/values", attributesHandler.GetAttributeValues)
+
+		// Dashboard summary
+		api.GET("/stats", statsHandler.GetStats)
+
+		// Recent errors across traces and logs
+		api.GET("/errors", errorsHandler.GetErrors)
+
+		// Running build, so the UI can detect a version mismatch
+		api.GET("/version", versionHandler.GetVersion)
+
+		// Machine-readable description of these routes
+		api.GET("/openapi.json", openAPIHandler.GetSpec)
+
+		// Deleting data is only exposed in debug mode or behind the auth token
+		if cfg.Debug || cfg.Server.AuthToken != "" {
+			api.DELETE("/traces/:id", tracesHandler.DeleteTrace)
+			api.DELETE("/data", dataHandler.DeleteData)
+		}
 	}
 
 	return router
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/server/handlers"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+func TestOpenAPIListsEveryRoute(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	// Debug mode registers the DELETE routes as well
+	cfg := config.Default()
+	cfg.Debug = true
+	router := SetupRouter(cfg, s, handlers.NewHealthHandler(s), nil, nil, logger)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Document is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+
+	routes := 0
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		routes++
+
+		// Gin's :param segments are {param} in OpenAPI
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		path := strings.Join(segments, "/")
+
+		if _, ok := doc.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is not in the OpenAPI document", route.Method, path)
+		}
+	}
+	if routes == 0 {
+		t.Fatal("Expected /api routes to be registered")
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	cfg := config.Default()
+	cfg.Build = config.BuildInfo{Version: "1.4.2", Commit: "abc1234", Date: "2026-01-02T03:04:05Z"}
+	router := SetupRouter(cfg, s, handlers.NewHealthHandler(s), nil, nil, logger)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var info handlers.VersionInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Response is not valid JSON: %v", err)
+	}
+	if info != (handlers.VersionInfo{Version: "1.4.2", Commit: "abc1234", Date: "2026-01-02T03:04:05Z"}) {
+		t.Errorf("Expected the configured build, got %+v", info)
+	}
+}
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	cfg := config.Default()
+	cfg.Server.RateLimit = 1
+	router := SetupRouter(cfg, s, handlers.NewHealthHandler(s), nil, nil, logger)
+
+	// Rotating X-Forwarded-For from one connection must not get a fresh bucket
+	codes := []int{}
+	for _, forwarded := range []string{"203.0.113.1", "203.0.113.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/version", nil)
+		req.RemoteAddr = "192.0.2.10:40000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected 200 then 429 despite a new X-Forwarded-For, got %v", codes)
+	}
+}
+
+func TestDeleteRoutesRequireDebugOrAuth(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	deleteRoutes := func(cfg *config.Config) []string {
+		var paths []string
+		for _, route := range SetupRouter(cfg, s, handlers.NewHealthHandler(s), nil, nil, logger).Routes() {
+			if route.Method == http.MethodDelete {
+				paths = append(paths, route.Path)
+			}
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	if paths := deleteRoutes(config.Default()); len(paths) != 0 {
+		t.Errorf("Expected no DELETE routes without debug or auth, got %v", paths)
+	}
+
+	cfg := config.Default()
+	cfg.Server.AuthToken = "secret"
+	if paths := deleteRoutes(cfg); strings.Join(paths, " ") != "/api/data /api/traces/:id" {
+		t.Errorf("Expected DELETE routes behind the auth token, got %v", paths)
+	}
+}
+
+func TestRequestTimeoutCancelsStoreQuery(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	gin.SetMode(gin.TestMode)
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// The deadline passes before the traces query starts, so the store sees a
+	// cancelled context and the handler's failure becomes a 503
+	cfg := config.Default()
+	cfg.Server.RequestTimeout = time.Nanosecond
+	router := SetupRouter(cfg, s, handlers.NewHealthHandler(s), nil, nil, logger)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/traces", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Streamed exports can run longer than the request timeout
+	if err := s.Logs.InsertLog(ctx, &store.LogRecord{
+		Timestamp:    time.Now(),
+		SeverityText: "INFO",
+		Body:         "exported line",
+		ServiceName:  "api",
+	}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/logs/export", nil))
+	if !strings.Contains(w.Body.String(), "exported line") {
+		t.Errorf("Expected the log export to be exempt, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/server/handlers"
 	"github.com/mesaglio/otel-front/internal/server/middleware"
 	"github.com/mesaglio/otel-front/internal/store"
 	"go.uber.org/zap"
@@ -25,17 +26,20 @@ type Server struct {
 	logger *zap.Logger
 	router *gin.Engine
 	server *http.Server
+	health *handlers.HealthHandler
 }
 
-// NewServer creates a new HTTP server
-func NewServer(cfg *config.Config, store *store.Store, logger *zap.Logger) (*Server, error) {
+// NewServer creates a new HTTP server. ingest supplies the ingest rate for /api/stats
+// and tail the live logs for /api/logs/tail.
+func NewServer(cfg *config.Config, store *store.Store, ingest handlers.IngestRater, tail handlers.LogSubscriber, logger *zap.Logger) (*Server, error) {
 	// Set Gin mode
 	if !cfg.Debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	// Setup router with all routes
-	router := SetupRouter(store, logger)
+	health := handlers.NewHealthHandler(store)
+	router := SetupRouter(cfg, store, health, ingest, tail, logger)
 
 	// Setup static file serving
 	setupStaticFiles(router, logger)
@@ -45,15 +49,23 @@ func NewServer(cfg *config.Config, store *store.Store, logger *zap.Logger) (*Ser
 		store:  store,
 		logger: logger,
 		router: router,
+		health: health,
+	}
+
+	tlsConfig, err := config.LoadTLSConfig(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create HTTP server with CORS middleware
 	srv.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Server.HTTPPort),
-		Handler:      middleware.CORS()(router),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              cfg.Server.ListenAddr(cfg.Server.HTTPPort),
+		Handler:           middleware.CORS()(router),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		TLSConfig:         tlsConfig,
 	}
 
 	return srv, nil
@@ -113,7 +125,7 @@ func setupStaticFiles(router *gin.Engine, logger *zap.Logger) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "API endpoint not found"})
 			return
 		}
-		if c.Request.URL.Path == "/health" {
+		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/livez" || c.Request.URL.Path == "/readyz" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Health endpoint not found"})
 			return
 		}
@@ -123,14 +135,26 @@ func setupStaticFiles(router *gin.Engine, logger *zap.Logger) {
 	})
 }
 
+// SetReady marks the server ready to receive traffic; /readyz returns 503 until then
+func (s *Server) SetReady() {
+	s.health.SetReady(true)
+}
+
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context) error {
-	s.logger.Info("Starting HTTP server", zap.Int("port", s.config.Server.HTTPPort))
+	s.logger.Info("Starting HTTP server", zap.Int("port", s.config.Server.HTTPPort), zap.Bool("tls", s.server.TLSConfig != nil))
 
 	// Start server in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.server.TLSConfig != nil {
+			// Certificates come from TLSConfig
+			err = s.server.ListenAndServeTLS("", "")
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errChan <- err
 		}
 	}()
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mesaglio/otel-front/internal/config"
+	"github.com/mesaglio/otel-front/internal/store"
+	"go.uber.org/zap"
+)
+
+func TestNewServerTimeouts(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	s, err := store.NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	cfg := config.Default()
+	cfg.Server.ReadHeaderTimeout = 2 * time.Second
+	cfg.Server.ReadTimeout = 5 * time.Second
+	cfg.Server.WriteTimeout = 5 * time.Minute
+	cfg.Server.IdleTimeout = 0
+
+	srv, err := NewServer(cfg, s, nil, nil, logger)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if srv.server.ReadHeaderTimeout != 2*time.Second {
+		t.Errorf("Expected a 2s read header timeout, got %v", srv.server.ReadHeaderTimeout)
+	}
+	if srv.server.ReadTimeout != 5*time.Second {
+		t.Errorf("Expected a 5s read timeout, got %v", srv.server.ReadTimeout)
+	}
+	if srv.server.WriteTimeout != 5*time.Minute {
+		t.Errorf("Expected a 5m write timeout, got %v", srv.server.WriteTimeout)
+	}
+	if srv.server.IdleTimeout != 0 {
+		t.Errorf("Expected no idle timeout, got %v", srv.server.IdleTimeout)
+	}
+}
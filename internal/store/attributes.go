@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// attributeKeysTTL is how long discovered attribute keys are reused; scanning
+// every attributes column is expensive and new keys rarely appear
+const attributeKeysTTL = 30 * time.Second
+
+// maxAttributeValues caps how many suggestions GetAttributeValues returns
+const maxAttributeValues = 100
+
+// ErrUnknownSignalType is returned for a signal type other than traces, logs or metrics
+var ErrUnknownSignalType = errors.New("unknown signal type")
+
+// attributeTables maps each signal type to the table whose attributes column
+// is searched. Traces use the trace-level attributes that TraceFilters match on.
+var attributeTables = map[string]string{
+	"traces":  "traces",
+	"logs":    "logs",
+	"metrics": "metrics",
+}
+
+// attributeKeyCache holds the distinct attribute keys per signal type
+type attributeKeyCache struct {
+	mu      sync.Mutex
+	entries map[string]attributeKeyEntry
+}
+
+type attributeKeyEntry struct {
+	keys    []string
+	expires time.Time
+}
+
+func newAttributeKeyCache() *attributeKeyCache {
+	return &attributeKeyCache{entries: make(map[string]attributeKeyEntry)}
+}
+
+// reset drops every cached key list, e.g. after the data was deleted
+func (c *attributeKeyCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]attributeKeyEntry)
+}
+
+// GetAttributeKeys returns the distinct attribute keys seen on the given signal
+// type (traces, logs or metrics), sorted by name. Results are cached briefly.
+func (s *Store) GetAttributeKeys(ctx context.Context, signalType string) ([]string, error) {
+	table, ok := attributeTables[signalType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSignalType, signalType)
+	}
+
+	s.attributeKeys.mu.Lock()
+	entry, cached := s.attributeKeys.entries[signalType]
+	s.attributeKeys.mu.Unlock()
+	if cached && time.Now().Before(entry.expires) {
+		return entry.keys, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT DISTINCT key FROM (
+			SELECT unnest(json_keys(attributes)) AS key
+			FROM %s
+			WHERE attributes IS NOT NULL
+		)
+		ORDER BY key
+	`, table)
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attribute keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read attribute keys: %w", err)
+	}
+
+	s.attributeKeys.mu.Lock()
+	s.attributeKeys.entries[signalType] = attributeKeyEntry{keys: keys, expires: time.Now().Add(attributeKeysTTL)}
+	s.attributeKeys.mu.Unlock()
+
+	return keys, nil
+}
+
+// GetAttributeValues returns up to limit distinct values of an attribute key on
+// the given signal type, most frequent first. Unknown keys yield an empty list.
+func (s *Store) GetAttributeValues(ctx context.Context, signalType, key string, limit int) ([]string, error) {
+	table, ok := attributeTables[signalType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSignalType, signalType)
+	}
+	if limit <= 0 || limit > maxAttributeValues {
+		limit = maxAttributeValues
+	}
+
+	query := fmt.Sprintf(`
+		SELECT value FROM (
+			SELECT json_extract_string(attributes, ?) AS value
+			FROM %s
+			WHERE attributes IS NOT NULL
+		)
+		WHERE value IS NOT NULL
+		GROUP BY value
+		ORDER BY COUNT(*) DESC, value
+		LIMIT ?
+	`, table)
+
+	rows, err := s.db.QueryContext(ctx, query, attributeJSONPath(key), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attribute values: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute value: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read attribute values: %w", err)
+	}
+
+	return values, nil
+}
+
+// flattenAttributes returns attrs with nested maps replaced by dot-joined keys,
+// so {"http": {"request": {"method": "GET"}}} becomes {"http.request.method":
+// "GET"}. Other values, including slices and empty maps, are kept as-is.
+func flattenAttributes(attrs map[string]interface{}) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	flat := make(map[string]interface{}, len(attrs))
+	flattenInto(flat, "", attrs)
+	return flat
+}
+
+// flattenInto copies attrs into flat with prefix prepended to each key
+func flattenInto(flat map[string]interface{}, prefix string, attrs map[string]interface{}) {
+	for key, value := range attrs {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenInto(flat, key, nested)
+			continue
+		}
+		flat[key] = value
+	}
+}
+
+// FlattenAttributes flattens the nested attributes of the trace and of its
+// spans, span events and links into dot-joined keys, for display in flat tables
+func (t *Trace) FlattenAttributes() {
+	t.Attributes = flattenAttributes(t.Attributes)
+	t.ResourceAttributes = flattenAttributes(t.ResourceAttributes)
+	for i := range t.Spans {
+		span := &t.Spans[i]
+		span.Attributes = flattenAttributes(span.Attributes)
+		for j := range span.Events {
+			span.Events[j].Attributes = flattenAttributes(span.Events[j].Attributes)
+		}
+		for j := range span.Links {
+			span.Links[j].Attributes = flattenAttributes(span.Links[j].Attributes)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetAttributeKeys(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, attrs := range []map[string]interface{}{
+		{"http.method": "GET", "user.id": "42"},
+		{"http.method": "POST", "k8s.pod.name": "api-0"},
+	} {
+		if err := store.Logs.InsertLog(ctx, &LogRecord{
+			Timestamp:    now,
+			SeverityText: "INFO",
+			Body:         "request",
+			ServiceName:  "api",
+			Attributes:   attrs,
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+	}
+
+	keys, err := store.GetAttributeKeys(ctx, "logs")
+	if err != nil {
+		t.Fatalf("Failed to get attribute keys: %v", err)
+	}
+	expected := []string{"http.method", "k8s.pod.name", "user.id"}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected keys %v, got %v", expected, keys)
+	}
+	for i, key := range expected {
+		if keys[i] != key {
+			t.Errorf("Expected key %d to be %s, got %s", i, key, keys[i])
+		}
+	}
+
+	keys, err = store.GetAttributeKeys(ctx, "metrics")
+	if err != nil {
+		t.Fatalf("Failed to get attribute keys: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected no metric attribute keys, got %v", keys)
+	}
+
+	if _, err := store.GetAttributeKeys(ctx, "profiles"); !errors.Is(err, ErrUnknownSignalType) {
+		t.Errorf("Expected ErrUnknownSignalType, got %v", err)
+	}
+}
+
+func TestGetAttributeKeysCached(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	insert := func(id string, attrs map[string]interface{}) {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       id,
+			ServiceName:   "api",
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+			Attributes:    attrs,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	insert("trace-1", map[string]interface{}{"service.name": "api"})
+	keys, err := store.GetAttributeKeys(ctx, "traces")
+	if err != nil {
+		t.Fatalf("Failed to get attribute keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "service.name" {
+		t.Fatalf("Expected [service.name], got %v", keys)
+	}
+
+	// A new key is not visible until the cached entry expires
+	insert("trace-2", map[string]interface{}{"deployment.environment": "dev"})
+	keys, _ = store.GetAttributeKeys(ctx, "traces")
+	if len(keys) != 1 {
+		t.Errorf("Expected cached keys, got %v", keys)
+	}
+
+	store.attributeKeys.entries["traces"] = attributeKeyEntry{expires: now.Add(-time.Second)}
+	keys, _ = store.GetAttributeKeys(ctx, "traces")
+	if len(keys) != 2 {
+		t.Errorf("Expected 2 keys after expiry, got %v", keys)
+	}
+}
+
+func TestGetAttributeValues(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, method := range []string{"GET", "POST", "GET", "DELETE", "GET", "POST"} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("values-trace-%d", i),
+			ServiceName:   "api",
+			OperationName: method + " /",
+			StartTime:     now,
+			EndTime:       now,
+			Attributes:    map[string]interface{}{"http.method": method},
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	values, err := store.GetAttributeValues(ctx, "traces", "http.method", 0)
+	if err != nil {
+		t.Fatalf("Failed to get attribute values: %v", err)
+	}
+	expected := []string{"GET", "POST", "DELETE"}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected values %v, got %v", expected, values)
+	}
+	for i, value := range expected {
+		if values[i] != value {
+			t.Errorf("Expected value %d to be %s, got %s", i, value, values[i])
+		}
+	}
+
+	values, err = store.GetAttributeValues(ctx, "traces", "http.method", 1)
+	if err != nil {
+		t.Fatalf("Failed to get attribute values: %v", err)
+	}
+	if len(values) != 1 || values[0] != "GET" {
+		t.Errorf("Expected [GET] with limit 1, got %v", values)
+	}
+
+	values, err = store.GetAttributeValues(ctx, "traces", "does.not.exist", 10)
+	if err != nil {
+		t.Fatalf("Expected no error for unknown key, got %v", err)
+	}
+	if values == nil || len(values) != 0 {
+		t.Errorf("Expected empty list for unknown key, got %v", values)
+	}
+}
+
+func TestFlattenAttributes(t *testing.T) {
+	trace := &Trace{
+		Attributes: map[string]interface{}{"service.name": "api"},
+		Spans: []Span{{
+			Attributes: map[string]interface{}{
+				"http": map[string]interface{}{
+					"method": "GET",
+					"request": map[string]interface{}{
+						"header": map[string]interface{}{"accept": "application/json"},
+					},
+				},
+				"tags":  []interface{}{"a", "b"},
+				"empty": map[string]interface{}{},
+			},
+			Events: []SpanEvent{{Attributes: map[string]interface{}{
+				"exception": map[string]interface{}{"type": "io.EOF"},
+			}}},
+		}},
+	}
+	trace.FlattenAttributes()
+
+	attrs := trace.Spans[0].Attributes
+	if attrs["http.method"] != "GET" {
+		t.Errorf("Expected http.method GET, got %v", attrs["http.method"])
+	}
+	if attrs["http.request.header.accept"] != "application/json" {
+		t.Errorf("Expected http.request.header.accept application/json, got %v", attrs["http.request.header.accept"])
+	}
+	if _, ok := attrs["http"]; ok {
+		t.Errorf("Expected the nested http map to be replaced, got %v", attrs["http"])
+	}
+	if tags, ok := attrs["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("Expected slices to be kept, got %v", attrs["tags"])
+	}
+	if _, ok := attrs["empty"].(map[string]interface{}); !ok {
+		t.Errorf("Expected an empty map to be kept, got %v", attrs["empty"])
+	}
+	if len(attrs) != 4 {
+		t.Errorf("Expected 4 flattened span attributes, got %v", attrs)
+	}
+	if got := trace.Spans[0].Events[0].Attributes["exception.type"]; got != "io.EOF" {
+		t.Errorf("Expected event attribute exception.type io.EOF, got %v", got)
+	}
+	if trace.Attributes["service.name"] != "api" {
+		t.Errorf("Expected flat attributes unchanged, got %v", trace.Attributes)
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// LogCursor marks a position in the logs ordered by (timestamp, id). Unlike an
+// offset it stays valid while new logs arrive, so pages never repeat or skip rows.
+type LogCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// NewLogCursor returns the cursor positioned at log
+func NewLogCursor(log LogRecord) LogCursor {
+	return LogCursor{Timestamp: log.Timestamp, ID: log.ID}
+}
+
+// String encodes the cursor as an opaque URL-safe token
+func (c LogCursor) String() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseLogCursor decodes a token produced by LogCursor.String
+func ParseLogCursor(token string) (LogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return LogCursor{}, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return LogCursor{}, ErrInvalidCursor
+	}
+
+	ts, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return LogCursor{}, fmt.Errorf("%w: bad timestamp", ErrInvalidCursor)
+	}
+	logID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return LogCursor{}, fmt.Errorf("%w: bad id", ErrInvalidCursor)
+	}
+	return LogCursor{Timestamp: time.Unix(0, ts), ID: logID}, nil
+}
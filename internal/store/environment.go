@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// environmentKeys are the resource attributes naming a deployment environment,
+// current semantic convention first
+var environmentKeys = []string{"deployment.environment.name", "deployment.environment"}
+
+// ServiceEnvironment is a service name as deployed to one environment.
+// Environment is empty for data sent without an environment attribute.
+type ServiceEnvironment struct {
+	ServiceName string `json:"service_name"`
+	Environment string `json:"environment"`
+}
+
+// environmentExpr is the SQL expression reading the environment from a JSON
+// attributes column, NULL when it has none
+func environmentExpr(column string) string {
+	parts := make([]string, len(environmentKeys))
+	for i, key := range environmentKeys {
+		parts[i] = fmt.Sprintf("json_extract_string(%s, %s)", column, sqlStringLiteral(attributeJSONPath(key)))
+	}
+	return "COALESCE(" + strings.Join(parts, ", ") + ")"
+}
+
+// environmentOf returns the environment named by attrs, or "" without one
+func environmentOf(attrs map[string]interface{}) string {
+	for _, key := range environmentKeys {
+		if value, ok := attrs[key]; ok {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// GetServicesWithEnvironment returns the distinct service and environment pairs
+// across traces, logs and metrics, so one service name deployed to several
+// environments is listed once per environment. Traces and logs read their
+// resource attributes; metrics store resource attributes merged into theirs.
+func (s *Store) GetServicesWithEnvironment(ctx context.Context) ([]ServiceEnvironment, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT service_name, COALESCE(environment, '') AS environment FROM (
+			SELECT service_name, %s AS environment FROM traces
+			UNION
+			SELECT service_name, %s AS environment FROM logs
+			UNION
+			SELECT service_name, %s AS environment FROM metrics
+		)
+		ORDER BY service_name, environment
+	`, environmentExpr("resource_attributes"), environmentExpr("resource_attributes"), environmentExpr("attributes"))
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service environments: %w", err)
+	}
+	defer rows.Close()
+
+	services := []ServiceEnvironment{}
+	for rows.Next() {
+		var service ServiceEnvironment
+		if err := rows.Scan(&service.ServiceName, &service.Environment); err != nil {
+			return nil, fmt.Errorf("failed to scan service environment: %w", err)
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read service environments: %w", err)
+	}
+	return services, nil
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestServicesWithEnvironment(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// One service name in two environments, the newer attribute name for staging
+	resources := []map[string]interface{}{
+		{"service.name": "checkout", "deployment.environment": "production"},
+		{"service.name": "checkout", "deployment.environment.name": "staging"},
+	}
+	for i, resource := range resources {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:            fmt.Sprintf("env-trace-%d", i),
+			ServiceName:        "checkout",
+			OperationName:      "POST /checkout",
+			StartTime:          now,
+			EndTime:            now,
+			ResourceAttributes: resource,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+		if err := store.Logs.InsertLog(ctx, &LogRecord{
+			Timestamp:          now,
+			SeverityText:       "INFO",
+			Body:               "order placed",
+			ServiceName:        "checkout",
+			ResourceAttributes: resource,
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+		value := float64(i)
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now,
+			MetricName:  "orders",
+			MetricType:  "sum",
+			ServiceName: "checkout",
+			Value:       &value,
+			Attributes:  resource,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+	// A metric-only service without an environment
+	if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+		Timestamp:   now,
+		MetricName:  "jobs",
+		MetricType:  "gauge",
+		ServiceName: "worker",
+	}); err != nil {
+		t.Fatalf("Failed to insert metric: %v", err)
+	}
+
+	services, err := store.GetServicesWithEnvironment(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get services: %v", err)
+	}
+	expected := []ServiceEnvironment{
+		{ServiceName: "checkout", Environment: "production"},
+		{ServiceName: "checkout", Environment: "staging"},
+		{ServiceName: "worker", Environment: ""},
+	}
+	if len(services) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, services)
+	}
+	for i := range expected {
+		if services[i] != expected[i] {
+			t.Errorf("Expected %v at %d, got %v", expected[i], i, services[i])
+		}
+	}
+
+	traces, err := store.Traces.GetTraces(ctx, TraceFilters{Environment: "staging", Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(traces) != 1 || traces[0].TraceID != "env-trace-1" {
+		t.Errorf("Expected only the staging trace, got %v", traces)
+	}
+
+	logFilters := LogFilters{Environment: "production", Limit: 10}
+	logs, err := store.Logs.GetLogs(ctx, logFilters)
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].ResourceAttributes["deployment.environment"] != "production" {
+		t.Errorf("Expected only the production log, got %v", logs)
+	}
+	if !logFilters.Matches(&logs[0]) {
+		t.Errorf("Expected Matches to agree with the production filter")
+	}
+	if logFilters.Matches(&LogRecord{ResourceAttributes: resources[1]}) {
+		t.Errorf("Expected Matches to reject a staging log")
+	}
+
+	metrics, err := store.Metrics.GetMetrics(ctx, MetricFilters{Environment: "staging", Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(metrics) != 1 || *metrics[0].Value != 1 {
+		t.Errorf("Expected only the staging metric, got %v", metrics)
+	}
+}
+
+func TestMetricQueriesFilterEnvironment(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, env := range []string{"production", "staging"} {
+		resource := map[string]interface{}{"service.name": "checkout", "deployment.environment": env}
+		value := float64(i + 1)
+		count := uint64(i + 1)
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(-3 * time.Hour),
+			MetricName:  "checkout.duration",
+			MetricType:  "histogram",
+			ServiceName: "checkout",
+			Value:       &value,
+			Attributes:  resource,
+			Histogram:   &HistogramData{Bounds: []float64{100}, BucketCounts: []uint64{count, 0}, Count: count},
+			Exemplars:   []Exemplar{{Value: 50, Timestamp: now.Add(-3 * time.Hour), TraceID: "trace-" + env, SpanID: "span-" + env}},
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(-time.Minute),
+			MetricName:  env + ".only",
+			MetricType:  "gauge",
+			ServiceName: "checkout",
+			Value:       &value,
+			Attributes:  resource,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+	// The later gauges move the rollup watermark past the histogram points, but
+	// rolled-up buckets keep no attributes and must not answer a filtered request
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics: %v", err)
+	}
+
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "checkout.duration",
+		Environment: "staging",
+		StartTime:   now.Add(-24 * time.Hour),
+		EndTime:     now.Add(time.Hour),
+		Aggregation: "sum",
+		BucketSize:  "1 hour",
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+	if len(results) != 1 || results[0].Value != 2 {
+		t.Errorf("Expected only the staging value 2, got %+v", results)
+	}
+
+	cells, err := store.Metrics.GetHistogramHeatmap(ctx, HistogramHeatmapRequest{
+		MetricName:  "checkout.duration",
+		Environment: "staging",
+		StartTime:   now.Add(-4 * time.Hour),
+		EndTime:     now.Add(time.Hour),
+		BucketSize:  "1 hour",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get heatmap: %v", err)
+	}
+	var observations uint64
+	for _, cell := range cells {
+		observations += cell.Count
+	}
+	if observations != 2 {
+		t.Errorf("Expected only the staging observations, got %d", observations)
+	}
+
+	exemplars, err := store.Metrics.GetExemplars(ctx, "checkout.duration", "", "staging", 4*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get exemplars: %v", err)
+	}
+	if len(exemplars) != 1 || exemplars[0].TraceID != "trace-staging" {
+		t.Errorf("Expected only the staging exemplar, got %v", exemplars)
+	}
+
+	histogramExemplars, err := store.Metrics.GetHistogramExemplars(ctx, "checkout.duration", "", "staging", now.Add(-4*time.Hour), time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get histogram exemplars: %v", err)
+	}
+	if len(histogramExemplars) != 1 || histogramExemplars[0].TraceID != "trace-staging" {
+		t.Errorf("Expected only the staging histogram exemplar, got %v", histogramExemplars)
+	}
+
+	names, err := store.Metrics.GetMetricNames(ctx, "", "staging")
+	if err != nil {
+		t.Fatalf("Failed to get metric names: %v", err)
+	}
+	if fmt.Sprint(names) != "[checkout.duration staging.only]" {
+		t.Errorf("Expected only the staging metric names, got %v", names)
+	}
+}
@@ -0,0 +1,21 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is wrapped by every error reporting a missing record, so callers
+// can tell "doesn't exist" from a failed query with errors.Is
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidBucketSize is returned by time-bucketed queries for a bucket size
+// that is not a positive number of seconds, minutes, hours or days
+var ErrInvalidBucketSize = errors.New("invalid bucket size")
+
+var (
+	// ErrTraceNotFound is returned by GetTraceByID and DeleteTrace for an unknown trace ID
+	ErrTraceNotFound = fmt.Errorf("trace %w", ErrNotFound)
+	// ErrSpanNotFound is returned by GetSpanByID for an unknown span ID
+	ErrSpanNotFound = fmt.Errorf("span %w", ErrNotFound)
+)
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,15 +16,17 @@ import (
 
 // LogsStore handles log storage and retrieval
 type LogsStore struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db        *sql.DB
+	logger    *zap.Logger
+	chunkSize int // Rows per InsertLogs transaction
 }
 
 // NewLogsStore creates a new logs store
 func NewLogsStore(db *sql.DB, logger *zap.Logger) *LogsStore {
 	return &LogsStore{
-		db:     db,
-		logger: logger,
+		db:        db,
+		logger:    logger,
+		chunkSize: DefaultInsertChunkSize,
 	}
 }
 
@@ -33,9 +39,19 @@ type LogRecord struct {
 	SeverityText       string                 `json:"severity_text"`
 	SeverityNumber     int                    `json:"severity_number"`
 	Body               string                 `json:"body"`
+	BodyJSON           map[string]interface{} `json:"body_json,omitempty"`           // Original structure of map bodies
+	EstimatedTimestamp bool                   `json:"estimated_timestamp,omitempty"` // Timestamp was missing; observed or receipt time was used
+	ObservedTimestamp  *time.Time             `json:"observed_timestamp,omitempty"`  // When the collector or SDK observed the record, if sent
+	EventName          string                 `json:"event_name,omitempty"`          // OTel event name, distinct from the body
 	ServiceName        string                 `json:"service_name"`
 	Attributes         map[string]interface{} `json:"attributes,omitempty"`
 	ResourceAttributes map[string]interface{} `json:"resource_attributes,omitempty"`
+	// Count is the number of identical logs this row stands for; only set by GetLogs with Dedup
+	Count int64 `json:"count,omitempty"`
+	// TraceExists reports whether TraceID has a stored trace; only set by the logs API
+	TraceExists bool `json:"trace_exists"`
+	// Correlation is CorrelationTimeBased for logs GetCorrelatedLogs matched by time window
+	Correlation string `json:"correlation,omitempty"`
 }
 
 // InsertLog inserts a new log record
@@ -45,11 +61,14 @@ func (ls *LogsStore) InsertLog(ctx context.Context, log *LogRecord) error {
 
 	err := ls.db.QueryRowContext(ctx, `
 		INSERT INTO logs (timestamp, trace_id, span_id, severity_text, severity_number,
-			body, service_name, attributes, resource_attributes)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			body, body_json, service_name, attributes, resource_attributes, estimated_timestamp, event_name,
+			observed_timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id
 	`, log.Timestamp, log.TraceID, log.SpanID, log.SeverityText, log.SeverityNumber,
-		log.Body, log.ServiceName, string(attributesJSON), string(resourceAttrJSON)).Scan(&log.ID)
+		log.Body, bodyJSONValue(log.BodyJSON), log.ServiceName,
+		string(attributesJSON), string(resourceAttrJSON), log.EstimatedTimestamp,
+		log.EventName, log.ObservedTimestamp).Scan(&log.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert log: %w", err)
@@ -58,12 +77,22 @@ func (ls *LogsStore) InsertLog(ctx context.Context, log *LogRecord) error {
 	return nil
 }
 
-// InsertLogs inserts multiple log records in a batch
-func (ls *LogsStore) InsertLogs(ctx context.Context, logs []LogRecord) error {
-	if len(logs) == 0 {
-		return nil
+// InsertLogs inserts multiple log records in a batch. Records are committed in
+// transactions of at most chunkSize rows: each chunk is atomic, but if a later
+// chunk fails the earlier ones stay stored. It returns how many records were
+// stored, which are always the first ones of logs.
+func (ls *LogsStore) InsertLogs(ctx context.Context, logs []LogRecord) (int, error) {
+	stored, err := inChunks(logs, ls.chunkSize, func(chunk []LogRecord) error {
+		return ls.insertLogChunk(ctx, chunk)
+	})
+	if err != nil && stored > 0 {
+		return stored, fmt.Errorf("stored %d of %d logs: %w", stored, len(logs), err)
 	}
+	return stored, err
+}
 
+// insertLogChunk inserts logs in a single transaction
+func (ls *LogsStore) insertLogChunk(ctx context.Context, logs []LogRecord) error {
 	tx, err := ls.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -76,10 +105,13 @@ func (ls *LogsStore) InsertLogs(ctx context.Context, logs []LogRecord) error {
 
 		_, err = tx.ExecContext(ctx, `
 			INSERT INTO logs (timestamp, trace_id, span_id, severity_text, severity_number,
-				body, service_name, attributes, resource_attributes)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				body, body_json, service_name, attributes, resource_attributes, estimated_timestamp, event_name,
+				observed_timestamp)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`, log.Timestamp, log.TraceID, log.SpanID, log.SeverityText, log.SeverityNumber,
-			log.Body, log.ServiceName, string(attributesJSON), string(resourceAttrJSON))
+			log.Body, bodyJSONValue(log.BodyJSON), log.ServiceName,
+			string(attributesJSON), string(resourceAttrJSON), log.EstimatedTimestamp,
+			log.EventName, log.ObservedTimestamp)
 
 		if err != nil {
 			return fmt.Errorf("failed to insert log: %w", err)
@@ -93,94 +125,152 @@ func (ls *LogsStore) InsertLogs(ctx context.Context, logs []LogRecord) error {
 	return nil
 }
 
-// GetLogs retrieves logs with filters
-func (ls *LogsStore) GetLogs(ctx context.Context, filters LogFilters) ([]LogRecord, error) {
-	query := `
-		SELECT id, timestamp, trace_id, span_id, severity_text, severity_number,
-			body, service_name, attributes, resource_attributes
-		FROM logs
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if !filters.StartTime.IsZero() {
-		query += " AND timestamp >= ?"
-		args = append(args, filters.StartTime)
+// bodyJSONValue returns the JSON column value for a structured body, or nil (NULL)
+// for plain bodies
+func bodyJSONValue(body map[string]interface{}) interface{} {
+	if body == nil {
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil
 	}
+	return string(data)
+}
 
-	if !filters.EndTime.IsZero() {
-		query += " AND timestamp <= ?"
-		args = append(args, filters.EndTime)
+// dedupLogColumns selects the most recent log of each body/severity/service
+// group plus the group size, in the same order as the plain GetLogs columns
+const dedupLogColumns = `arg_max(id, timestamp), max(timestamp), arg_max(trace_id, timestamp),
+			arg_max(span_id, timestamp), severity_text, arg_max(severity_number, timestamp),
+			body, arg_max(body_json, timestamp), service_name, arg_max(attributes, timestamp),
+			arg_max(resource_attributes, timestamp),
+			arg_max(COALESCE(estimated_timestamp, false), timestamp),
+			arg_max(COALESCE(event_name, ''), timestamp),
+			arg_max(observed_timestamp, timestamp), COUNT(*)`
+
+// GetLogs retrieves logs with filters. With filters.Dedup, identical logs are
+// collapsed into their most recent occurrence with Count set.
+func (ls *LogsStore) GetLogs(ctx context.Context, filters LogFilters) ([]LogRecord, error) {
+	where, args := buildLogFilters(filters)
+
+	var query string
+	switch {
+	case filters.Dedup:
+		query = "SELECT " + dedupLogColumns + " FROM logs WHERE 1=1" + where +
+			" GROUP BY body, severity_text, service_name ORDER BY max(timestamp) DESC LIMIT ? OFFSET ?"
+		args = append(args, filters.Limit, filters.Offset)
+	case filters.Before != nil || filters.After != nil:
+		cursorWhere, cursorArgs, order := buildLogCursor(filters)
+		query = logColumnsQuery + where + cursorWhere + " ORDER BY timestamp " + order + ", id " + order + " LIMIT ?"
+		args = append(append(args, cursorArgs...), filters.Limit)
+	default:
+		query = logColumnsQuery + where + " ORDER BY timestamp DESC, id DESC LIMIT ? OFFSET ?"
+		args = append(args, filters.Limit, filters.Offset)
 	}
 
-	if filters.ServiceName != "" {
-		query += " AND service_name = ?"
-		args = append(args, filters.ServiceName)
+	rows, err := ls.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
 	}
+	defer rows.Close()
 
-	if filters.TraceID != "" {
-		query += " AND trace_id = ?"
-		args = append(args, filters.TraceID)
+	logs := []LogRecord{}
+	for rows.Next() {
+		var log LogRecord
+		var extra []any
+		if filters.Dedup {
+			extra = append(extra, &log.Count)
+		}
+		if err := scanLogRow(rows, &log, extra...); err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, log)
 	}
 
-	if filters.MinSeverity > 0 {
-		query += " AND severity_number >= ?"
-		args = append(args, filters.MinSeverity)
+	// After pages are read oldest first so LIMIT keeps the rows next to the
+	// cursor; return them newest first like every other page
+	if filters.After != nil && filters.Before == nil {
+		slices.Reverse(logs)
 	}
 
-	if filters.SearchText != "" {
-		query += " AND body LIKE ?"
-		args = append(args, "%"+filters.SearchText+"%")
+	return logs, nil
+}
+
+// logColumnsQuery selects the columns scanned by GetLogs when not deduplicating
+const logColumnsQuery = `
+		SELECT id, timestamp, trace_id, span_id, severity_text, severity_number,
+			body, body_json, service_name, attributes, resource_attributes,
+			COALESCE(estimated_timestamp, false), COALESCE(event_name, ''),
+			observed_timestamp
+		FROM logs
+		WHERE 1=1
+	`
+
+// buildLogCursor returns the keyset conditions for filters.Before/After and
+// the sort direction that reads rows outward from the cursor
+func buildLogCursor(filters LogFilters) (string, []interface{}, string) {
+	query := ""
+	args := []interface{}{}
+	order := "DESC"
+
+	if filters.Before != nil {
+		query += " AND (timestamp < ? OR (timestamp = ? AND id < ?))"
+		args = append(args, filters.Before.Timestamp, filters.Before.Timestamp, filters.Before.ID)
+	}
+	if filters.After != nil {
+		query += " AND (timestamp > ? OR (timestamp = ? AND id > ?))"
+		args = append(args, filters.After.Timestamp, filters.After.Timestamp, filters.After.ID)
+		if filters.Before == nil {
+			order = "ASC"
+		}
 	}
 
+	return query, args, order
+}
+
+// StreamLogs calls fn for each log matching filters, newest first, without
+// holding the result set in memory. Only the timestamp, trace ID, severity,
+// body and service name are populated. Iteration stops at the first error fn returns.
+func (ls *LogsStore) StreamLogs(ctx context.Context, filters LogFilters, fn func(*LogRecord) error) error {
+	query := `
+		SELECT timestamp, trace_id, severity_text, severity_number, body, service_name
+		FROM logs
+		WHERE 1=1
+	`
+	where, args := buildLogFilters(filters)
+	query += where
+
 	query += " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 	args = append(args, filters.Limit, filters.Offset)
 
 	rows, err := ls.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query logs: %w", err)
+		return fmt.Errorf("failed to query logs: %w", err)
 	}
 	defer rows.Close()
 
-	logs := []LogRecord{}
 	for rows.Next() {
 		var log LogRecord
-		var attributesJSON, resourceAttrJSON any
-
-		err := rows.Scan(&log.ID, &log.Timestamp, &log.TraceID, &log.SpanID,
-			&log.SeverityText, &log.SeverityNumber, &log.Body, &log.ServiceName,
-			&attributesJSON, &resourceAttrJSON)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan log: %w", err)
+		if err := rows.Scan(&log.Timestamp, &log.TraceID, &log.SeverityText,
+			&log.SeverityNumber, &log.Body, &log.ServiceName); err != nil {
+			return fmt.Errorf("failed to scan log: %w", err)
 		}
-
-		// Handle JSON columns - DuckDB v2 returns map directly
-		if attributesJSON != nil {
-			if m, ok := attributesJSON.(map[string]any); ok {
-				log.Attributes = m
-			} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &log.Attributes)
-			}
-		}
-		if resourceAttrJSON != nil {
-			if m, ok := resourceAttrJSON.(map[string]any); ok {
-				log.ResourceAttributes = m
-			} else if bytes, ok := resourceAttrJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &log.ResourceAttributes)
-			}
+		if err := fn(&log); err != nil {
+			return err
 		}
-
-		logs = append(logs, log)
 	}
 
-	return logs, nil
+	return rows.Err()
 }
 
 // GetLogsByTraceID retrieves all logs associated with a trace
 func (ls *LogsStore) GetLogsByTraceID(ctx context.Context, traceID string) ([]LogRecord, error) {
 	rows, err := ls.db.QueryContext(ctx, `
 		SELECT id, timestamp, trace_id, span_id, severity_text, severity_number,
-			body, service_name, attributes, resource_attributes
+			body, body_json, service_name, attributes, resource_attributes,
+			COALESCE(estimated_timestamp, false), COALESCE(event_name, ''),
+			observed_timestamp
 		FROM logs
 		WHERE trace_id = ?
 		ORDER BY timestamp ASC
@@ -193,40 +283,204 @@ func (ls *LogsStore) GetLogsByTraceID(ctx context.Context, traceID string) ([]Lo
 	logs := []LogRecord{}
 	for rows.Next() {
 		var log LogRecord
-		var attributesJSON, resourceAttrJSON any
-
-		err := rows.Scan(&log.ID, &log.Timestamp, &log.TraceID, &log.SpanID,
-			&log.SeverityText, &log.SeverityNumber, &log.Body, &log.ServiceName,
-			&attributesJSON, &resourceAttrJSON)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan log: %w", err)
+		if err := scanLogRow(rows, &log); err != nil {
+			return nil, err
 		}
+		logs = append(logs, log)
+	}
 
-		// Handle JSON columns - DuckDB v2 returns map directly
-		if attributesJSON != nil {
-			if m, ok := attributesJSON.(map[string]any); ok {
-				log.Attributes = m
-			} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &log.Attributes)
-			}
+	return logs, nil
+}
+
+// CorrelationTimeBased marks logs that GetCorrelatedLogs matched by service
+// and time rather than by trace ID
+const CorrelationTimeBased = "time-based"
+
+// GetCorrelatedLogs returns the logs of a trace like GetLogsByTraceID, plus
+// logs without a trace ID from the trace's services whose timestamp falls
+// within the trace's start and end widened by window on both sides. Those are
+// flagged with Correlation set to CorrelationTimeBased. Unknown traces only
+// get their explicitly correlated logs, as there is no window to match.
+func (ls *LogsStore) GetCorrelatedLogs(ctx context.Context, traceID string, window time.Duration) ([]LogRecord, error) {
+	var start, end time.Time
+	err := ls.db.QueryRowContext(ctx,
+		"SELECT start_time, end_time FROM traces WHERE trace_id = ?", traceID).Scan(&start, &end)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ls.GetLogsByTraceID(ctx, traceID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trace window: %w", err)
+	}
+
+	rows, err := ls.db.QueryContext(ctx, `
+		SELECT id, timestamp, trace_id, span_id, severity_text, severity_number,
+			body, body_json, service_name, attributes, resource_attributes,
+			COALESCE(estimated_timestamp, false), COALESCE(event_name, ''),
+			observed_timestamp,
+			COALESCE(trace_id, '') = ''
+		FROM logs
+		WHERE trace_id = ?
+			OR (COALESCE(trace_id, '') = ''
+				AND timestamp BETWEEN ? AND ?
+				AND service_name IN (SELECT DISTINCT service_name FROM spans WHERE trace_id = ?))
+		ORDER BY timestamp ASC, id ASC
+	`, traceID, start.Add(-window), end.Add(window), traceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []LogRecord{}
+	for rows.Next() {
+		var log LogRecord
+		var timeBased bool
+		if err := scanLogRow(rows, &log, &timeBased); err != nil {
+			return nil, err
 		}
-		if resourceAttrJSON != nil {
-			if m, ok := resourceAttrJSON.(map[string]any); ok {
-				log.ResourceAttributes = m
-			} else if bytes, ok := resourceAttrJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &log.ResourceAttributes)
-			}
+		if timeBased {
+			log.Correlation = CorrelationTimeBased
 		}
-
 		logs = append(logs, log)
 	}
 
-	return logs, nil
+	return logs, rows.Err()
+}
+
+// scanLogRow scans the columns selected by logColumnsQuery into log, followed
+// by any extra destinations
+func scanLogRow(rows *sql.Rows, log *LogRecord, extra ...any) error {
+	var bodyJSON, attributesJSON, resourceAttrJSON any
+
+	dest := append([]any{&log.ID, &log.Timestamp, &log.TraceID, &log.SpanID,
+		&log.SeverityText, &log.SeverityNumber, &log.Body, &bodyJSON, &log.ServiceName,
+		&attributesJSON, &resourceAttrJSON, &log.EstimatedTimestamp, &log.EventName,
+		&log.ObservedTimestamp}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return fmt.Errorf("failed to scan log: %w", err)
+	}
+
+	// Handle JSON columns - DuckDB v2 returns map directly
+	if bodyJSON != nil {
+		if m, ok := bodyJSON.(map[string]any); ok {
+			log.BodyJSON = m
+		} else if bytes, ok := bodyJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &log.BodyJSON)
+		} else if s, ok := bodyJSON.(string); ok && s != "" {
+			json.Unmarshal([]byte(s), &log.BodyJSON)
+		}
+	}
+	if attributesJSON != nil {
+		if m, ok := attributesJSON.(map[string]any); ok {
+			log.Attributes = m
+		} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &log.Attributes)
+		}
+	}
+	if resourceAttrJSON != nil {
+		if m, ok := resourceAttrJSON.(map[string]any); ok {
+			log.ResourceAttributes = m
+		} else if bytes, ok := resourceAttrJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &log.ResourceAttributes)
+		}
+	}
+	return nil
 }
 
 // CountLogs returns the total count of logs matching the filters
 func (ls *LogsStore) CountLogs(ctx context.Context, filters LogFilters) (int64, error) {
-	query := "SELECT COUNT(*) FROM logs WHERE 1=1"
+	where, args := buildLogFilters(filters)
+	query := "SELECT COUNT(*) FROM logs WHERE 1=1" + where
+	if filters.Dedup {
+		query = "SELECT COUNT(*) FROM (SELECT 1 FROM logs WHERE 1=1" + where + " GROUP BY body, severity_text, service_name)"
+	}
+
+	var count int64
+	err := ls.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count logs: %w", err)
+	}
+
+	return count, nil
+}
+
+// LogVolumeSeries is the number of logs of one severity per time bucket
+type LogVolumeSeries struct {
+	Severity string           `json:"severity"`
+	Points   []LogVolumePoint `json:"points"`
+}
+
+// LogVolumePoint is the log count of one severity in one time bucket
+type LogVolumePoint struct {
+	TimeBucket time.Time `json:"time_bucket"`
+	Count      int64     `json:"count"`
+}
+
+// GetLogVolume counts the logs matching filters per time bucket, returning one
+// series per severity_text ordered by severity number so stacked bars keep a
+// stable order. bucket uses the AggregateMetrics sizes ("1 minute", "5 minutes",
+// ...); Limit and Offset are ignored. Buckets without logs of a severity are omitted.
+func (ls *LogsStore) GetLogVolume(ctx context.Context, filters LogFilters, bucket string) ([]LogVolumeSeries, error) {
+	bucketSeconds, err := parseBucketSize(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := buildLogFilters(filters)
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			COALESCE(severity_text, '') AS severity,
+			MIN(severity_number) AS severity_number,
+			COUNT(*) AS count
+		FROM logs
+		WHERE 1=1%s
+		GROUP BY bucket, severity
+		ORDER BY bucket ASC
+	`, timeBucketExpr("timestamp", bucketSeconds), where)
+
+	rows, err := ls.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query log volume: %w", err)
+	}
+	defer rows.Close()
+
+	series := []LogVolumeSeries{}
+	index := map[string]int{}
+	severityNumbers := map[string]int{}
+	for rows.Next() {
+		var point LogVolumePoint
+		var severity string
+		var severityNumber int
+		if err := rows.Scan(&point.TimeBucket, &severity, &severityNumber, &point.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan log volume: %w", err)
+		}
+
+		i, ok := index[severity]
+		if !ok {
+			i = len(series)
+			index[severity] = i
+			series = append(series, LogVolumeSeries{Severity: severity})
+		}
+		if n, ok := severityNumbers[severity]; !ok || severityNumber < n {
+			severityNumbers[severity] = severityNumber
+		}
+		series[i].Points = append(series[i].Points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log volume: %w", err)
+	}
+
+	sort.SliceStable(series, func(a, b int) bool {
+		return severityNumbers[series[a].Severity] < severityNumbers[series[b].Severity]
+	})
+
+	return series, nil
+}
+
+// buildLogFilters builds the WHERE conditions shared by GetLogs and CountLogs,
+// so pagination totals always match the returned rows
+func buildLogFilters(filters LogFilters) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 
 	if !filters.StartTime.IsZero() {
@@ -244,33 +498,103 @@ func (ls *LogsStore) CountLogs(ctx context.Context, filters LogFilters) (int64,
 		args = append(args, filters.ServiceName)
 	}
 
+	if filters.TraceID != "" {
+		query += " AND trace_id = ?"
+		args = append(args, filters.TraceID)
+	}
+
+	if filters.EventName != "" {
+		query += " AND event_name = ?"
+		args = append(args, filters.EventName)
+	}
+
+	if filters.Environment != "" {
+		query += " AND " + environmentExpr("resource_attributes") + " = ?"
+		args = append(args, filters.Environment)
+	}
+
 	if filters.MinSeverity > 0 {
 		query += " AND severity_number >= ?"
 		args = append(args, filters.MinSeverity)
 	}
 
-	if filters.SearchText != "" {
-		query += " AND body LIKE ?"
-		args = append(args, "%"+filters.SearchText+"%")
+	if filters.MaxSeverity > 0 {
+		query += " AND severity_number <= ?"
+		args = append(args, filters.MaxSeverity)
 	}
 
-	var count int64
-	err := ls.db.QueryRowContext(ctx, query, args...).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count logs: %w", err)
+	if filters.SearchText != "" {
+		searchPattern := "%" + filters.SearchText + "%"
+		if filters.SearchAttributes {
+			query += " AND (body LIKE ? OR CAST(attributes AS VARCHAR) LIKE ?)"
+			args = append(args, searchPattern, searchPattern)
+		} else {
+			query += " AND body LIKE ?"
+			args = append(args, searchPattern)
+		}
 	}
 
-	return count, nil
+	return query, args
+}
+
+// Matches reports whether log passes the filters, mirroring buildLogFilters for
+// records that are not read from the database (e.g. live tails). Limit and
+// Offset are ignored.
+func (f LogFilters) Matches(log *LogRecord) bool {
+	if !f.StartTime.IsZero() && log.Timestamp.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && log.Timestamp.After(f.EndTime) {
+		return false
+	}
+	if f.ServiceName != "" && log.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.TraceID != "" && (log.TraceID == nil || *log.TraceID != f.TraceID) {
+		return false
+	}
+	if f.EventName != "" && log.EventName != f.EventName {
+		return false
+	}
+	if f.Environment != "" && environmentOf(log.ResourceAttributes) != f.Environment {
+		return false
+	}
+	if f.MinSeverity > 0 && log.SeverityNumber < f.MinSeverity {
+		return false
+	}
+	if f.MaxSeverity > 0 && log.SeverityNumber > f.MaxSeverity {
+		return false
+	}
+	if f.SearchText != "" && !strings.Contains(log.Body, f.SearchText) {
+		if !f.SearchAttributes {
+			return false
+		}
+		attrs, _ := json.Marshal(log.Attributes)
+		if !strings.Contains(string(attrs), f.SearchText) {
+			return false
+		}
+	}
+	return true
 }
 
 // LogFilters holds filter parameters for log queries
 type LogFilters struct {
-	StartTime   time.Time
-	EndTime     time.Time
-	ServiceName string
-	TraceID     string
-	MinSeverity int
-	SearchText  string
-	Limit       int
-	Offset      int
+	StartTime        time.Time
+	EndTime          time.Time
+	ServiceName      string
+	TraceID          string
+	EventName        string
+	Environment      string // deployment.environment resource attribute
+	MinSeverity      int
+	MaxSeverity      int // Zero means no upper bound
+	SearchText       string
+	SearchAttributes bool // Also match SearchText against the attributes JSON
+	Dedup            bool // GetLogs/CountLogs: collapse logs with the same body, severity and service
+	Limit            int
+	Offset           int
+	// Before and After page GetLogs by position instead of Offset: Before returns
+	// logs older than the cursor, After logs newer than it. Ignored with Dedup;
+	// CountLogs still counts every match.
+	Before *LogCursor
+	After  *LogCursor
 }
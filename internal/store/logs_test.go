@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -128,6 +129,35 @@ func TestLogFilters(t *testing.T) {
 		}
 	})
 
+	// Test severity band (WARN only, excluding ERROR)
+	t.Run("Filter by severity range", func(t *testing.T) {
+		filters := LogFilters{
+			MinSeverity: 13,
+			MaxSeverity: 16,
+			Limit:       10,
+		}
+
+		results, err := store.Logs.GetLogs(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to get logs: %v", err)
+		}
+
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 WARN log, got %d", len(results))
+		}
+		if results[0].SeverityText != "WARN" {
+			t.Errorf("Expected WARN log, got %s", results[0].SeverityText)
+		}
+
+		total, err := store.Logs.CountLogs(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to count logs: %v", err)
+		}
+		if total != 1 {
+			t.Errorf("Expected count 1, got %d", total)
+		}
+	})
+
 	// Test filter by service
 	t.Run("Filter by service", func(t *testing.T) {
 		filters := LogFilters{
@@ -221,3 +251,595 @@ func TestGetLogsByTraceID(t *testing.T) {
 		t.Errorf("Expected 3 logs for trace, got %d", len(results))
 	}
 }
+
+func TestGetCorrelatedLogs(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	start := time.Now().UTC().Truncate(time.Second)
+	end := start.Add(2 * time.Second)
+	traceID := "correlated-trace"
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:       traceID,
+		ServiceName:   "api",
+		OperationName: "GET /users",
+		StartTime:     start,
+		EndTime:       end,
+		SpanCount:     1,
+		Spans: []Span{{
+			SpanID:        "span-1",
+			TraceID:       traceID,
+			ServiceName:   "api",
+			OperationName: "GET /users",
+			StartTime:     start,
+			EndTime:       end,
+		}},
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	otherTrace := "other-trace"
+	logs := []LogRecord{
+		{Timestamp: start.Add(time.Second), TraceID: &traceID, ServiceName: "api", Body: "explicit"},
+		{Timestamp: start.Add(time.Second), ServiceName: "api", Body: "inside window"},
+		{Timestamp: end.Add(3 * time.Second), ServiceName: "api", Body: "just after"},
+		{Timestamp: end.Add(time.Minute), ServiceName: "api", Body: "long after"},
+		{Timestamp: start.Add(time.Second), ServiceName: "billing", Body: "other service"},
+		{Timestamp: start.Add(time.Second), TraceID: &otherTrace, ServiceName: "api", Body: "other trace"},
+	}
+	for i := range logs {
+		logs[i].SeverityText = "INFO"
+		logs[i].SeverityNumber = 9
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	bodies := func(results []LogRecord) map[string]string {
+		m := make(map[string]string, len(results))
+		for _, log := range results {
+			m[log.Body] = log.Correlation
+		}
+		return m
+	}
+
+	results, err := store.Logs.GetCorrelatedLogs(ctx, traceID, 0)
+	if err != nil {
+		t.Fatalf("Failed to get correlated logs: %v", err)
+	}
+	got := bodies(results)
+	if len(got) != 2 {
+		t.Errorf("Expected 2 correlated logs, got %v", got)
+	}
+	if correlation, ok := got["explicit"]; !ok || correlation != "" {
+		t.Errorf("Expected the trace's own log without a correlation flag, got %v", got)
+	}
+	if got["inside window"] != CorrelationTimeBased {
+		t.Errorf("Expected the in-window log flagged %q, got %v", CorrelationTimeBased, got)
+	}
+
+	// Widening the window picks up nearby logs but never other services or traces
+	results, err = store.Logs.GetCorrelatedLogs(ctx, traceID, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to get correlated logs: %v", err)
+	}
+	got = bodies(results)
+	if len(got) != 3 || got["just after"] != CorrelationTimeBased {
+		t.Errorf("Expected the 5s window to add \"just after\", got %v", got)
+	}
+
+	results, err = store.Logs.GetCorrelatedLogs(ctx, "missing-trace", time.Minute)
+	if err != nil || len(results) != 0 {
+		t.Errorf("Expected no logs for an unknown trace, got %v (err: %v)", results, err)
+	}
+}
+
+func TestSearchLogAttributes(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	logs := []LogRecord{
+		{
+			Timestamp:      time.Now().Add(-2 * time.Minute),
+			SeverityNumber: 9,
+			SeverityText:   "INFO",
+			ServiceName:    "service-a",
+			Body:           "Order placed",
+			Attributes:     map[string]interface{}{"user.id": "user-4821"},
+		},
+		{
+			Timestamp:      time.Now().Add(-1 * time.Minute),
+			SeverityNumber: 9,
+			SeverityText:   "INFO",
+			ServiceName:    "service-a",
+			Body:           "Cart updated",
+			Attributes:     map[string]interface{}{"user.id": "user-1000"},
+		},
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	t.Run("Body only by default", func(t *testing.T) {
+		filters := LogFilters{SearchText: "user-4821", Limit: 10}
+
+		results, err := store.Logs.GetLogs(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to get logs: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no body matches, got %d", len(results))
+		}
+	})
+
+	t.Run("Attributes when enabled", func(t *testing.T) {
+		filters := LogFilters{SearchText: "user-4821", SearchAttributes: true, Limit: 10}
+
+		results, err := store.Logs.GetLogs(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to get logs: %v", err)
+		}
+		if len(results) != 1 || results[0].Body != "Order placed" {
+			t.Fatalf("Expected the 'Order placed' log, got %+v", results)
+		}
+
+		total, err := store.Logs.CountLogs(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to count logs: %v", err)
+		}
+		if total != 1 {
+			t.Errorf("Expected CountLogs to match GetLogs (1), got %d", total)
+		}
+	})
+}
+
+func TestLogBodyJSON(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	log := &LogRecord{
+		Timestamp:      time.Now(),
+		SeverityNumber: 9,
+		SeverityText:   "INFO",
+		ServiceName:    "test-service",
+		Body:           `{"event":"order.created","order_id":42}`,
+		BodyJSON:       map[string]interface{}{"event": "order.created", "order_id": 42},
+	}
+	if err := store.Logs.InsertLog(ctx, log); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+
+	results, err := store.Logs.GetLogs(ctx, LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 log, got %d", len(results))
+	}
+	if results[0].BodyJSON["event"] != "order.created" {
+		t.Errorf("Expected body_json to round-trip, got %v", results[0].BodyJSON)
+	}
+	if results[0].Body != log.Body {
+		t.Errorf("Expected body fallback to be preserved, got %s", results[0].Body)
+	}
+}
+
+func TestStreamLogs(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	logs := []LogRecord{
+		{Timestamp: now.Add(-2 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "first", TraceID: strPtr("trace-1")},
+		{Timestamp: now.Add(-1 * time.Second), SeverityNumber: 17, SeverityText: "ERROR", ServiceName: "api", Body: "second"},
+		{Timestamp: now, SeverityNumber: 9, SeverityText: "INFO", ServiceName: "worker", Body: "other service"},
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	var bodies []string
+	var traceIDs []*string
+	err := store.Logs.StreamLogs(ctx, LogFilters{ServiceName: "api", Limit: 10}, func(log *LogRecord) error {
+		bodies = append(bodies, log.Body)
+		traceIDs = append(traceIDs, log.TraceID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream logs: %v", err)
+	}
+
+	// Newest first, filtered by service
+	if len(bodies) != 2 || bodies[0] != "second" || bodies[1] != "first" {
+		t.Fatalf("Expected [second first], got %v", bodies)
+	}
+	if traceIDs[0] != nil || traceIDs[1] == nil || *traceIDs[1] != "trace-1" {
+		t.Errorf("Expected only the first log to carry trace-1")
+	}
+
+	// An error from the callback stops the iteration
+	calls := 0
+	err = store.Logs.StreamLogs(ctx, LogFilters{Limit: 10}, func(log *LogRecord) error {
+		calls++
+		return context.Canceled
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("Expected iteration to stop after 1 call with the callback error, got %d calls (err: %v)", calls, err)
+	}
+}
+
+func TestLogEstimatedTimestamp(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.Logs.InsertLog(ctx, &LogRecord{
+		Timestamp:          time.Now(),
+		ServiceName:        "api",
+		Body:               "no exporter timestamp",
+		EstimatedTimestamp: true,
+	}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+
+	results, err := store.Logs.GetLogs(ctx, LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(results) != 1 || !results[0].EstimatedTimestamp {
+		t.Errorf("Expected the estimated timestamp flag to be stored")
+	}
+}
+
+func TestLogEventName(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	if _, err := store.Logs.InsertLogs(ctx, []LogRecord{
+		{Timestamp: now, ServiceName: "web", Body: "page viewed", EventName: "browser.page_view"},
+		{Timestamp: now, ServiceName: "web", Body: "plain log"},
+	}); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	results, err := store.Logs.GetLogs(ctx, LogFilters{EventName: "browser.page_view", Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(results) != 1 || results[0].EventName != "browser.page_view" {
+		t.Fatalf("Expected only the page_view event, got %v", results)
+	}
+	if !(LogFilters{EventName: "browser.page_view"}).Matches(&results[0]) {
+		t.Errorf("Expected Matches to agree with the event_name filter")
+	}
+
+	results, err = store.Logs.GetLogs(ctx, LogFilters{Limit: 10, Dedup: true})
+	if err != nil {
+		t.Fatalf("Failed to get deduplicated logs: %v", err)
+	}
+	for _, log := range results {
+		if log.Body == "page viewed" && log.EventName != "browser.page_view" {
+			t.Errorf("Expected dedup to keep the event name, got %q", log.EventName)
+		}
+	}
+}
+
+func TestLogObservedTimestamp(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	observed := time.Unix(1700000000, 0).UTC()
+	if err := store.Logs.InsertLog(ctx, &LogRecord{
+		Timestamp:          observed,
+		ObservedTimestamp:  &observed,
+		EstimatedTimestamp: true,
+		ServiceName:        "api",
+		Body:               "observed only",
+	}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+	if err := store.Logs.InsertLog(ctx, &LogRecord{
+		Timestamp:   observed,
+		ServiceName: "api",
+		Body:        "no observed time",
+	}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+
+	results, err := store.Logs.GetLogs(ctx, LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	for _, log := range results {
+		switch log.Body {
+		case "observed only":
+			if !log.Timestamp.Equal(observed) {
+				t.Errorf("Expected timestamp %v, got %v", observed, log.Timestamp)
+			}
+			if log.ObservedTimestamp == nil || !log.ObservedTimestamp.Equal(observed) {
+				t.Errorf("Expected observed timestamp %v, got %v", observed, log.ObservedTimestamp)
+			}
+		case "no observed time":
+			if log.ObservedTimestamp != nil {
+				t.Errorf("Expected no observed timestamp, got %v", log.ObservedTimestamp)
+			}
+		}
+	}
+}
+
+func TestGetLogVolume(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	base := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	logs := []LogRecord{
+		{Timestamp: base.Add(10 * time.Second), SeverityNumber: 17, SeverityText: "ERROR", ServiceName: "api", Body: "failed"},
+		{Timestamp: base.Add(20 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "ok"},
+		{Timestamp: base.Add(30 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "ok"},
+		{Timestamp: base.Add(90 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "ok"},
+		{Timestamp: base.Add(150 * time.Second), SeverityNumber: 17, SeverityText: "ERROR", ServiceName: "api", Body: "failed"},
+		{Timestamp: base.Add(150 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "worker", Body: "other service"},
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	series, err := store.Logs.GetLogVolume(ctx, LogFilters{ServiceName: "api"}, "1 minute")
+	if err != nil {
+		t.Fatalf("Failed to get log volume: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("Expected 2 series, got %d", len(series))
+	}
+
+	// Series are ordered by severity number
+	info, errors := series[0], series[1]
+	if info.Severity != "INFO" || errors.Severity != "ERROR" {
+		t.Fatalf("Expected INFO then ERROR series, got %s and %s", info.Severity, errors.Severity)
+	}
+
+	if len(info.Points) != 2 || info.Points[0].Count != 2 || info.Points[1].Count != 1 {
+		t.Errorf("Expected INFO counts [2 1], got %+v", info.Points)
+	}
+	if !info.Points[0].TimeBucket.Equal(base) || !info.Points[1].TimeBucket.Equal(base.Add(time.Minute)) {
+		t.Errorf("Expected INFO buckets at %v and %v, got %+v", base, base.Add(time.Minute), info.Points)
+	}
+
+	if len(errors.Points) != 2 || errors.Points[0].Count != 1 || errors.Points[1].Count != 1 {
+		t.Errorf("Expected ERROR counts [1 1], got %+v", errors.Points)
+	}
+	if len(errors.Points) == 2 && !errors.Points[1].TimeBucket.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("Expected second ERROR bucket at %v, got %v", base.Add(2*time.Minute), errors.Points[1].TimeBucket)
+	}
+}
+
+func TestGetLogsDedup(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	base := time.Now().Truncate(time.Second).Add(-time.Minute)
+	logs := []LogRecord{
+		{Timestamp: base, SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "cache miss"},
+		{Timestamp: base.Add(time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "cache miss"},
+		{Timestamp: base.Add(2 * time.Second), SeverityNumber: 17, SeverityText: "ERROR", ServiceName: "api", Body: "cache miss"},
+		{Timestamp: base.Add(3 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "cache miss"},
+		{Timestamp: base.Add(4 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "worker", Body: "cache miss"},
+		{Timestamp: base.Add(5 * time.Second), SeverityNumber: 9, SeverityText: "INFO", ServiceName: "api", Body: "request done"},
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	all, err := store.Logs.GetLogs(ctx, LogFilters{Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(all) != len(logs) {
+		t.Errorf("Expected %d logs without dedup, got %d", len(logs), len(all))
+	}
+
+	deduped, err := store.Logs.GetLogs(ctx, LogFilters{Dedup: true, Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get deduplicated logs: %v", err)
+	}
+	if len(deduped) != 4 {
+		t.Fatalf("Expected 4 distinct logs, got %d", len(deduped))
+	}
+
+	// Newest occurrence first; the INFO "cache miss" group from api collapses three rows
+	expected := []struct {
+		service, severity, body string
+		count                   int64
+	}{
+		{"api", "INFO", "request done", 1},
+		{"worker", "INFO", "cache miss", 1},
+		{"api", "INFO", "cache miss", 3},
+		{"api", "ERROR", "cache miss", 1},
+	}
+	for i, e := range expected {
+		got := deduped[i]
+		if got.ServiceName != e.service || got.SeverityText != e.severity || got.Body != e.body || got.Count != e.count {
+			t.Errorf("Expected row %d to be %s/%s/%q x%d, got %s/%s/%q x%d",
+				i, e.service, e.severity, e.body, e.count, got.ServiceName, got.SeverityText, got.Body, got.Count)
+		}
+	}
+	if !deduped[2].Timestamp.Equal(logs[3].Timestamp) {
+		t.Errorf("Expected collapsed row to carry the latest timestamp %v, got %v", logs[3].Timestamp, deduped[2].Timestamp)
+	}
+
+	total, err := store.Logs.CountLogs(ctx, LogFilters{Dedup: true})
+	if err != nil {
+		t.Fatalf("Failed to count deduplicated logs: %v", err)
+	}
+	if total != 4 {
+		t.Errorf("Expected deduplicated total 4, got %d", total)
+	}
+}
+
+func TestLogFiltersMatches(t *testing.T) {
+	log := &LogRecord{
+		Timestamp:      time.Now(),
+		TraceID:        strPtr("trace-1"),
+		SeverityNumber: 13,
+		ServiceName:    "api",
+		Body:           "payment failed",
+		Attributes:     map[string]interface{}{"order.id": "A-17"},
+	}
+
+	tests := []struct {
+		name    string
+		filters LogFilters
+		want    bool
+	}{
+		{"no filters", LogFilters{}, true},
+		{"service", LogFilters{ServiceName: "api"}, true},
+		{"other service", LogFilters{ServiceName: "worker"}, false},
+		{"trace", LogFilters{TraceID: "trace-2"}, false},
+		{"min severity", LogFilters{MinSeverity: 17}, false},
+		{"max severity", LogFilters{MaxSeverity: 9}, false},
+		{"body search", LogFilters{SearchText: "payment"}, true},
+		{"attribute search without flag", LogFilters{SearchText: "A-17"}, false},
+		{"attribute search", LogFilters{SearchText: "A-17", SearchAttributes: true}, true},
+		{"before start time", LogFilters{StartTime: time.Now().Add(time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.Matches(log); got != tt.want {
+				t.Errorf("Expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLogsCursorPagination(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	// Pairs of logs share a timestamp so the id tie-break is exercised
+	base := time.Now().Truncate(time.Second).Add(-time.Hour)
+	var logs []LogRecord
+	for i := 0; i < 10; i++ {
+		logs = append(logs, LogRecord{
+			Timestamp:    base.Add(time.Duration(i/2) * time.Second),
+			SeverityText: "INFO",
+			ServiceName:  "api",
+			Body:         fmt.Sprintf("log-%d", i),
+		})
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+
+	seen := map[string]int{}
+	var firstPage []LogRecord
+	var before *LogCursor
+	for page := 0; ; page++ {
+		results, err := store.Logs.GetLogs(ctx, LogFilters{ServiceName: "api", Limit: 3, Before: before})
+		if err != nil {
+			t.Fatalf("Failed to get page %d: %v", page, err)
+		}
+		if page == 0 {
+			firstPage = results
+		}
+		if len(results) == 0 {
+			break
+		}
+		for _, log := range results {
+			seen[log.Body]++
+		}
+
+		// New logs arriving mid-scroll must not shift later pages
+		if err := store.Logs.InsertLog(ctx, &LogRecord{
+			Timestamp:    time.Now(),
+			SeverityText: "INFO",
+			ServiceName:  "api",
+			Body:         fmt.Sprintf("late-%d", page),
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+
+		cursor, err := ParseLogCursor(NewLogCursor(results[len(results)-1]).String())
+		if err != nil {
+			t.Fatalf("Failed to round-trip cursor: %v", err)
+		}
+		before = &cursor
+	}
+
+	if len(seen) != len(logs) {
+		t.Errorf("Expected %d distinct logs across pages, got %d: %v", len(logs), len(seen), seen)
+	}
+	for body, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected %s once, got %d times", body, n)
+		}
+	}
+
+	// After returns only the logs newer than the first page, newest first
+	after := NewLogCursor(firstPage[0])
+	newer, err := store.Logs.GetLogs(ctx, LogFilters{ServiceName: "api", Limit: 100, After: &after})
+	if err != nil {
+		t.Fatalf("Failed to get newer logs: %v", err)
+	}
+	if len(newer) != 4 {
+		t.Fatalf("Expected 4 logs inserted while paging, got %d", len(newer))
+	}
+	if newer[0].Body != "late-3" || newer[len(newer)-1].Body != "late-0" {
+		t.Errorf("Expected newer logs newest first, got %s ... %s", newer[0].Body, newer[len(newer)-1].Body)
+	}
+
+	if _, err := ParseLogCursor("not a cursor"); err == nil {
+		t.Errorf("Expected an error for a malformed cursor")
+	}
+}
+
+func TestScanLogRowBodyJSONString(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	// A VARCHAR body_json, as produced by aggregates over the column
+	rows, err := store.db.QueryContext(context.Background(), `
+		SELECT 1, TIMESTAMP '2024-01-01 00:00:00', NULL, NULL, 'INFO', 9, 'login',
+			'{"user":"alice"}', 'api', NULL, NULL, false, '', NULL`)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatalf("Expected a row: %v", rows.Err())
+	}
+	var log LogRecord
+	if err := scanLogRow(rows, &log); err != nil {
+		t.Fatalf("Failed to scan log: %v", err)
+	}
+	if log.BodyJSON["user"] != "alice" {
+		t.Errorf("Expected body_json decoded from a string, got %v", log.BodyJSON)
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,15 +17,23 @@ import (
 
 // MetricsStore handles metric storage and retrieval
 type MetricsStore struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db        *sql.DB
+	logger    *zap.Logger
+	chunkSize int // Rows per InsertMetrics transaction
+
+	// rolledUpAt is, per rollup bucket name, the latest created_at of the
+	// points seen by the previous RollupMetrics run
+	rollupMu   sync.Mutex
+	rolledUpAt map[string]time.Time
 }
 
 // NewMetricsStore creates a new metrics store
 func NewMetricsStore(db *sql.DB, logger *zap.Logger) *MetricsStore {
 	return &MetricsStore{
-		db:     db,
-		logger: logger,
+		db:         db,
+		logger:     logger,
+		chunkSize:  DefaultInsertChunkSize,
+		rolledUpAt: make(map[string]time.Time),
 	}
 }
 
@@ -32,8 +45,26 @@ type MetricRecord struct {
 	MetricType  string                 `json:"metric_type"` // gauge, sum, histogram, exponential_histogram
 	ServiceName string                 `json:"service_name"`
 	Value       *float64               `json:"value,omitempty"`
+	Unit        string                 `json:"unit,omitempty"`
+	Description string                 `json:"description,omitempty"`
 	Attributes  map[string]interface{} `json:"attributes,omitempty"`
 	Exemplars   []Exemplar             `json:"exemplars,omitempty"`
+	Histogram   *HistogramData         `json:"histogram,omitempty"`
+	// EstimatedTimestamp is set when the data point had no timestamp and receipt time was used
+	EstimatedTimestamp bool `json:"estimated_timestamp,omitempty"`
+	// IsMonotonic and Temporality ("cumulative" or "delta") describe sums, so
+	// their values can be read as running totals or per-interval increases
+	IsMonotonic bool   `json:"is_monotonic,omitempty"`
+	Temporality string `json:"temporality,omitempty"`
+}
+
+// HistogramData holds the explicit-bucket distribution of a histogram data point.
+// BucketCounts has one more entry than Bounds; the last bucket is the +Inf overflow.
+type HistogramData struct {
+	Bounds       []float64 `json:"bounds"`
+	BucketCounts []uint64  `json:"bucket_counts"`
+	Count        uint64    `json:"count"`
+	Sum          float64   `json:"sum"`
 }
 
 // Exemplar represents an exemplar linking a metric to a trace
@@ -52,11 +83,14 @@ func (ms *MetricsStore) InsertMetric(ctx context.Context, metric *MetricRecord)
 
 	err := ms.db.QueryRowContext(ctx, `
 		INSERT INTO metrics (timestamp, metric_name, metric_type, service_name,
-			value, attributes, exemplars)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+			value, unit, description, attributes, exemplars, histogram, estimated_timestamp,
+			is_monotonic, temporality)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id
 	`, metric.Timestamp, metric.MetricName, metric.MetricType, metric.ServiceName,
-		metric.Value, string(attributesJSON), string(exemplarsJSON)).Scan(&metric.ID)
+		metric.Value, metric.Unit, metric.Description,
+		string(attributesJSON), string(exemplarsJSON), histogramToJSON(metric.Histogram),
+		metric.EstimatedTimestamp, metric.IsMonotonic, metric.Temporality).Scan(&metric.ID)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert metric: %w", err)
@@ -65,12 +99,21 @@ func (ms *MetricsStore) InsertMetric(ctx context.Context, metric *MetricRecord)
 	return nil
 }
 
-// InsertMetrics inserts multiple metric records in a batch
-func (ms *MetricsStore) InsertMetrics(ctx context.Context, metrics []MetricRecord) error {
-	if len(metrics) == 0 {
-		return nil
+// InsertMetrics inserts multiple metric records in a batch, committing every
+// chunkSize rows. As with InsertLogs, a failure leaves earlier chunks stored
+// and the returned count says how many.
+func (ms *MetricsStore) InsertMetrics(ctx context.Context, metrics []MetricRecord) (int, error) {
+	stored, err := inChunks(metrics, ms.chunkSize, func(chunk []MetricRecord) error {
+		return ms.insertMetricChunk(ctx, chunk)
+	})
+	if err != nil && stored > 0 {
+		return stored, fmt.Errorf("stored %d of %d metrics: %w", stored, len(metrics), err)
 	}
+	return stored, err
+}
 
+// insertMetricChunk inserts metrics in a single transaction
+func (ms *MetricsStore) insertMetricChunk(ctx context.Context, metrics []MetricRecord) error {
 	tx, err := ms.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -83,10 +126,13 @@ func (ms *MetricsStore) InsertMetrics(ctx context.Context, metrics []MetricRecor
 
 		_, err = tx.ExecContext(ctx, `
 			INSERT INTO metrics (timestamp, metric_name, metric_type, service_name,
-				value, attributes, exemplars)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
+				value, unit, description, attributes, exemplars, histogram, estimated_timestamp,
+				is_monotonic, temporality)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`, metric.Timestamp, metric.MetricName, metric.MetricType, metric.ServiceName,
-			metric.Value, string(attributesJSON), string(exemplarsJSON))
+			metric.Value, metric.Unit, metric.Description,
+			string(attributesJSON), string(exemplarsJSON), histogramToJSON(metric.Histogram),
+			metric.EstimatedTimestamp, metric.IsMonotonic, metric.Temporality)
 
 		if err != nil {
 			return fmt.Errorf("failed to insert metric: %w", err)
@@ -103,8 +149,7 @@ func (ms *MetricsStore) InsertMetrics(ctx context.Context, metrics []MetricRecor
 // GetMetrics retrieves metrics with filters
 func (ms *MetricsStore) GetMetrics(ctx context.Context, filters MetricFilters) ([]MetricRecord, error) {
 	query := `
-		SELECT id, timestamp, metric_name, metric_type, service_name,
-			value, attributes, exemplars
+		SELECT ` + metricColumns + `
 		FROM metrics
 		WHERE 1=1
 	`
@@ -135,6 +180,22 @@ func (ms *MetricsStore) GetMetrics(ctx context.Context, filters MetricFilters) (
 		args = append(args, filters.ServiceName)
 	}
 
+	// Metrics keep resource attributes merged into their own
+	if filters.Environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, filters.Environment)
+	}
+
+	if filters.MinValue != nil {
+		query += " AND value >= ?"
+		args = append(args, *filters.MinValue)
+	}
+
+	if filters.MaxValue != nil {
+		query += " AND value <= ?"
+		args = append(args, *filters.MaxValue)
+	}
+
 	query += " ORDER BY timestamp DESC"
 
 	if filters.Limit > 0 {
@@ -153,14 +214,214 @@ func (ms *MetricsStore) GetMetrics(ctx context.Context, filters MetricFilters) (
 	}
 	defer rows.Close()
 
+	return scanMetrics(rows)
+}
+
+// GetMetricsByExemplarTrace returns metric data points with an exemplar pointing at the given trace
+func (ms *MetricsStore) GetMetricsByExemplarTrace(ctx context.Context, traceID string) ([]MetricRecord, error) {
+	rows, err := ms.db.QueryContext(ctx, `
+		SELECT `+metricColumns+`
+		FROM metrics
+		WHERE exemplars IS NOT NULL
+			AND list_contains(json_extract_string(exemplars, '$[*].trace_id'), ?)
+		ORDER BY timestamp DESC
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics by exemplar trace: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMetrics(rows)
+}
+
+// ExemplarPoint is a single exemplar flattened for plotting on a chart
+type ExemplarPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	SpanID    string    `json:"span_id,omitempty"`
+}
+
+// maxExemplarRows caps how many data points GetExemplars reads exemplars from
+const maxExemplarRows = 5000
+
+// GetExemplars returns the exemplars recorded on a metric within the last
+// timeRange, oldest first. serviceName and environment are optional. Exemplars
+// without their own timestamp use the data point's timestamp.
+func (ms *MetricsStore) GetExemplars(ctx context.Context, metricName, serviceName, environment string, timeRange time.Duration) ([]ExemplarPoint, error) {
+	query := `
+		SELECT id, timestamp, exemplars
+		FROM metrics
+		WHERE metric_name = ? AND timestamp >= ?
+			AND exemplars IS NOT NULL AND json_array_length(exemplars) > 0`
+	args := []interface{}{metricName, time.Now().Add(-timeRange)}
+
+	if serviceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, serviceName)
+	}
+
+	if environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, environment)
+	}
+
+	query += " ORDER BY timestamp LIMIT ?"
+	args = append(args, maxExemplarRows)
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exemplars: %w", err)
+	}
+	defer rows.Close()
+
+	points := []ExemplarPoint{}
+	for rows.Next() {
+		var id int64
+		var timestamp time.Time
+		var exemplarsJSON any
+		if err := rows.Scan(&id, &timestamp, &exemplarsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan exemplars: %w", err)
+		}
+
+		var exemplars []Exemplar
+		if err := decodeExemplars(exemplarsJSON, &exemplars); err != nil {
+			return nil, fmt.Errorf("failed to decode exemplars of metric %d: %w", id, err)
+		}
+		for _, ex := range exemplars {
+			point := ExemplarPoint{
+				Timestamp: ex.Timestamp,
+				Value:     ex.Value,
+				TraceID:   ex.TraceID,
+				SpanID:    ex.SpanID,
+			}
+			if point.Timestamp.Unix() <= 0 {
+				point.Timestamp = timestamp
+			}
+			points = append(points, point)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exemplars: %w", err)
+	}
+
+	return points, nil
+}
+
+// HistogramExemplar is an exemplar of a histogram data point together with the
+// bucket its value falls in, linking a latency bucket to an example trace.
+// Le is the bucket's upper bound; it is null for the +Inf overflow bucket.
+type HistogramExemplar struct {
+	ExemplarPoint
+	Le *float64 `json:"le"`
+}
+
+// GetHistogramExemplars returns the exemplars recorded on the histogram data
+// points of a metric in the given time range (zero times leave the range open),
+// oldest first, each with the upper bound of its bucket. serviceName and
+// environment are optional.
+func (ms *MetricsStore) GetHistogramExemplars(ctx context.Context, metricName, serviceName, environment string, startTime, endTime time.Time) ([]HistogramExemplar, error) {
+	query := `
+		SELECT id, timestamp, exemplars, histogram
+		FROM metrics
+		WHERE metric_name = ? AND histogram IS NOT NULL
+			AND exemplars IS NOT NULL AND json_array_length(exemplars) > 0`
+	args := []interface{}{metricName}
+
+	if serviceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, serviceName)
+	}
+
+	if environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, environment)
+	}
+
+	if !startTime.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, startTime)
+	}
+
+	if !endTime.IsZero() {
+		query += " AND timestamp <= ?"
+		args = append(args, endTime)
+	}
+
+	query += " ORDER BY timestamp LIMIT ?"
+	args = append(args, maxExemplarRows)
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query histogram exemplars: %w", err)
+	}
+	defer rows.Close()
+
+	points := []HistogramExemplar{}
+	for rows.Next() {
+		var id int64
+		var timestamp time.Time
+		var exemplarsJSON, histogramJSON any
+		if err := rows.Scan(&id, &timestamp, &exemplarsJSON, &histogramJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan histogram exemplars: %w", err)
+		}
+
+		var exemplars []Exemplar
+		if err := decodeExemplars(exemplarsJSON, &exemplars); err != nil {
+			return nil, fmt.Errorf("failed to decode exemplars of metric %d: %w", id, err)
+		}
+		histogram := histogramFromJSON(histogramJSON)
+		for _, ex := range exemplars {
+			point := HistogramExemplar{ExemplarPoint: ExemplarPoint{
+				Timestamp: ex.Timestamp,
+				Value:     ex.Value,
+				TraceID:   ex.TraceID,
+				SpanID:    ex.SpanID,
+			}}
+			if point.Timestamp.Unix() <= 0 {
+				point.Timestamp = timestamp
+			}
+			if histogram != nil {
+				point.Le = bucketUpperBound(histogram.Bounds, ex.Value)
+			}
+			points = append(points, point)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read histogram exemplars: %w", err)
+	}
+
+	return points, nil
+}
+
+// bucketUpperBound returns the upper bound of the explicit bucket holding value,
+// or nil for the +Inf bucket. OTLP buckets include their upper bound.
+func bucketUpperBound(bounds []float64, value float64) *float64 {
+	i := sort.SearchFloat64s(bounds, value)
+	if i == len(bounds) {
+		return nil
+	}
+	return &bounds[i]
+}
+
+// metricColumns is the select list matching scanMetrics
+const metricColumns = `id, timestamp, metric_name, metric_type, service_name,
+			value, COALESCE(unit, ''), COALESCE(description, ''), attributes, exemplars,
+			histogram, COALESCE(estimated_timestamp, false),
+			COALESCE(is_monotonic, false), COALESCE(temporality, '')`
+
+// scanMetrics reads all rows selected with metricColumns
+func scanMetrics(rows *sql.Rows) ([]MetricRecord, error) {
 	metrics := []MetricRecord{}
 	for rows.Next() {
 		var metric MetricRecord
-		var attributesJSON, exemplarsJSON any
+		var attributesJSON, exemplarsJSON, histogramJSON any
 
 		err := rows.Scan(&metric.ID, &metric.Timestamp, &metric.MetricName,
 			&metric.MetricType, &metric.ServiceName, &metric.Value,
-			&attributesJSON, &exemplarsJSON)
+			&metric.Unit, &metric.Description, &attributesJSON, &exemplarsJSON,
+			&histogramJSON, &metric.EstimatedTimestamp,
+			&metric.IsMonotonic, &metric.Temporality)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan metric: %w", err)
 		}
@@ -175,18 +436,11 @@ func (ms *MetricsStore) GetMetrics(ctx context.Context, filters MetricFilters) (
 				json.Unmarshal([]byte(str), &metric.Attributes)
 			}
 		}
-		if exemplarsJSON != nil {
-			// For complex types, convert to JSON and unmarshal
-			if bytes, ok := exemplarsJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &metric.Exemplars)
-			} else if str, ok := exemplarsJSON.(string); ok && len(str) > 0 {
-				json.Unmarshal([]byte(str), &metric.Exemplars)
-			} else if exemplarsJSON != nil {
-				// DuckDB v2 might return a Go type, marshal and unmarshal
-				if jsonBytes, err := json.Marshal(exemplarsJSON); err == nil {
-					json.Unmarshal(jsonBytes, &metric.Exemplars)
-				}
-			}
+		if err := decodeExemplars(exemplarsJSON, &metric.Exemplars); err != nil {
+			return nil, fmt.Errorf("failed to decode exemplars of metric %d: %w", metric.ID, err)
+		}
+		if histogramJSON != nil {
+			metric.Histogram = histogramFromJSON(histogramJSON)
 		}
 
 		metrics = append(metrics, metric)
@@ -205,16 +459,22 @@ func (ms *MetricsStore) GetMetricsCount(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
-// GetMetricNames returns a list of unique metric names
-func (ms *MetricsStore) GetMetricNames(ctx context.Context, serviceName string) ([]string, error) {
-	query := "SELECT DISTINCT metric_name FROM metrics"
+// GetMetricNames returns a list of unique metric names, optionally of one
+// service and environment
+func (ms *MetricsStore) GetMetricNames(ctx context.Context, serviceName, environment string) ([]string, error) {
+	query := "SELECT DISTINCT metric_name FROM metrics WHERE 1=1"
 	args := []interface{}{}
 
 	if serviceName != "" {
-		query += " WHERE service_name = ?"
+		query += " AND service_name = ?"
 		args = append(args, serviceName)
 	}
 
+	if environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, environment)
+	}
+
 	query += " ORDER BY metric_name"
 
 	rows, err := ms.db.QueryContext(ctx, query, args...)
@@ -252,29 +512,106 @@ func (ms *MetricsStore) AggregateMetrics(ctx context.Context, req AggregationReq
 		aggFunc = "AVG"
 	}
 
-	// Convert bucket size to DuckDB-compatible interval
-	bucketSeconds := parseBucketSizeToSeconds(req.BucketSize)
+	bucketSeconds, err := parseBucketSize(req.BucketSize)
+	if err != nil {
+		return nil, err
+	}
+
+	valueExpr := aggFunc + "(value)"
+	source := "metrics"
+	if req.Aggregation == "rate" {
+		// Per-second increase of a monotonic counter: each cumulative point
+		// contributes its delta from the previous point of the same series. A
+		// drop means the counter was reset, so the point's whole value is the
+		// increase, as it is for delta points. The window runs before the time
+		// range filter, so the first point in range is compared with the one
+		// just before it.
+		valueExpr = fmt.Sprintf("COALESCE(SUM(increase), 0) / %d", bucketSeconds)
+		source = `(
+			SELECT *,
+				CASE
+					WHEN temporality = 'delta' THEN value
+					WHEN value < lag(value) OVER series THEN value
+					ELSE value - lag(value) OVER series
+				END AS increase
+			FROM metrics
+			WINDOW series AS (
+				PARTITION BY metric_name, service_name, CAST(attributes AS VARCHAR)
+				ORDER BY timestamp
+			)
+		)`
+	}
+
+	// Large ranges read the buckets the rollup job has covered from the
+	// pre-aggregated rollups and the rest from raw points. Rollups keep
+	// neither attributes nor point order, so grouped, environment-filtered and
+	// rate requests always read raw points. Only buckets lying wholly inside
+	// the range come from rollups; the partial edge buckets are read raw.
+	results := []AggregationResult{}
+	var rollupFrom, rollupUntil time.Time
+	useRollup := len(req.GroupBy) == 0 && req.Environment == "" && req.Aggregation != "rate"
+	if rollup := rollupForRange(req.EndTime.Sub(req.StartTime), bucketSeconds); rollup != nil && useRollup {
+		split, err := ms.rollupSplit(ctx, rollup, bucketSeconds)
+		if err != nil {
+			return nil, err
+		}
+		startSeconds := req.StartTime.Unix()
+		if req.StartTime.Nanosecond() > 0 {
+			startSeconds++
+		}
+		from := time.Unix((startSeconds+bucketSeconds-1)/bucketSeconds*bucketSeconds, 0)
+		until := time.Unix(req.EndTime.Unix()/bucketSeconds*bucketSeconds, 0)
+		if split.Before(until) {
+			until = split
+		}
+		if from.Before(until) {
+			results, err = ms.aggregateRollup(ctx, req, rollup, bucketSeconds, from, until)
+			if err != nil {
+				return nil, err
+			}
+			rollupFrom, rollupUntil = from, until
+		}
+	}
+
+	// One column per group-by attribute, "" for points without it
+	dimensionColumns := ""
+	groupBy := ""
+	for i, key := range req.GroupBy {
+		dimensionColumns += fmt.Sprintf(",\n\t\t\tCOALESCE(json_extract_string(attributes, %s), '') AS dim_%d",
+			sqlStringLiteral(attributeJSONPath(key)), i)
+		groupBy += fmt.Sprintf("dim_%d, ", i)
+	}
 
-	// Build the bucket expression using epoch seconds and integer division
-	// This floors the timestamp to the nearest bucket
 	query := fmt.Sprintf(`
 		SELECT
-			to_timestamp((CAST(EXTRACT(epoch FROM timestamp) AS BIGINT) / %d) * %d) AS bucket,
-			%s(value) AS value
-		FROM metrics
+			%s AS bucket,
+			%s AS value,
+			COALESCE(MAX(unit), '') AS unit%s
+		FROM %s
 		WHERE metric_name = ?
 			AND timestamp >= ?
 			AND timestamp <= ?
-	`, bucketSeconds, bucketSeconds, aggFunc)
+	`, timeBucketExpr("timestamp", bucketSeconds), valueExpr, dimensionColumns, source)
 
 	args := []interface{}{req.MetricName, req.StartTime, req.EndTime}
 
+	if !rollupFrom.IsZero() {
+		query += " AND NOT (timestamp >= ? AND timestamp < ?)"
+		args = append(args, rollupFrom, rollupUntil)
+	}
+
 	if req.ServiceName != "" {
 		query += " AND service_name = ?"
 		args = append(args, req.ServiceName)
 	}
 
-	query += " GROUP BY bucket ORDER BY bucket ASC"
+	if req.Environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, req.Environment)
+	}
+
+	// Each dimension combination is returned as a contiguous series
+	query += " GROUP BY " + groupBy + "bucket ORDER BY " + groupBy + "bucket ASC"
 
 	rows, err := ms.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -282,22 +619,432 @@ func (ms *MetricsStore) AggregateMetrics(ctx context.Context, req AggregationReq
 	}
 	defer rows.Close()
 
-	results := []AggregationResult{}
 	for rows.Next() {
 		var result AggregationResult
-		if err := rows.Scan(&result.TimeBucket, &result.Value); err != nil {
+		dimensions := make([]string, len(req.GroupBy))
+		dest := []interface{}{&result.TimeBucket, &result.Value, &result.Unit}
+		for i := range dimensions {
+			dest = append(dest, &dimensions[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("failed to scan aggregation result: %w", err)
 		}
+		if len(req.GroupBy) > 0 {
+			result.Dimensions = make(map[string]string, len(req.GroupBy))
+			for i, key := range req.GroupBy {
+				result.Dimensions[key] = dimensions[i]
+			}
+		}
 		// Fill in the metadata
 		result.MetricName = req.MetricName
 		result.AggregationType = req.Aggregation
-		// Unit could be fetched from the first metric record, but we'll leave it empty for now
 		results = append(results, result)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aggregation results: %w", err)
+	}
+
+	// The leading raw edge bucket sorts before the rollup buckets
+	if !rollupFrom.IsZero() {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].TimeBucket.Before(results[j].TimeBucket)
+		})
+	}
+
+	return results, nil
+}
+
+// metricRollup describes one of the pre-aggregated metric rollup tables
+type metricRollup struct {
+	table         string
+	bucketSeconds int64
+	// minRange is the smallest query range that is served from this rollup
+	minRange time.Duration
+}
+
+// metricRollups maps rollup bucket names to their tables
+var metricRollups = map[string]*metricRollup{
+	"1h": {table: "metrics_rollup_1h", bucketSeconds: 3600, minRange: 7 * 24 * time.Hour},
+	"1m": {table: "metrics_rollup_1m", bucketSeconds: 60, minRange: 6 * time.Hour},
+}
+
+// rollupForRange picks the coarsest rollup that suits the query, or nil to read raw points.
+// The requested bucket size must be a multiple of the rollup bucket.
+func rollupForRange(queryRange time.Duration, bucketSeconds int64) *metricRollup {
+	for _, name := range []string{"1h", "1m"} {
+		rollup := metricRollups[name]
+		if queryRange >= rollup.minRange && bucketSeconds%rollup.bucketSeconds == 0 {
+			return rollup
+		}
+	}
+	return nil
+}
+
+// RollupMetrics pre-aggregates raw metric values into the "1m" or "1h" rollup table.
+// Only buckets from the latest rolled-up bucket onwards, and older buckets that
+// received late points since the previous run, are recomputed, so it is cheap
+// to call periodically.
+func (ms *MetricsStore) RollupMetrics(ctx context.Context, bucket string) error {
+	rollup, ok := metricRollups[bucket]
+	if !ok {
+		return fmt.Errorf("unknown rollup bucket %q: expected 1m or 1h", bucket)
+	}
+
+	ms.rollupMu.Lock()
+	defer ms.rollupMu.Unlock()
+
+	// The latest bucket may have been partial when it was rolled up, so recompute it
+	var watermark int64
+	err := ms.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COALESCE(MAX(bucket), 0) FROM %s", rollup.table)).Scan(&watermark)
+	if err != nil {
+		return fmt.Errorf("failed to read rollup watermark: %w", err)
+	}
+
+	// Points created since the previous run may be late arrivals in buckets
+	// before the watermark; those buckets are recomputed too. After a restart
+	// the previous run is unknown and only the watermark applies.
+	var createdUntil sql.NullTime
+	if err := ms.db.QueryRowContext(ctx, "SELECT MAX(created_at) FROM metrics").Scan(&createdUntil); err != nil {
+		return fmt.Errorf("failed to read latest metric: %w", err)
+	}
+	touched := "timestamp >= ?"
+	args := []interface{}{time.Unix(watermark, 0).UTC()}
+	if since, ok := ms.rolledUpAt[bucket]; ok {
+		touched += " OR created_at >= ?"
+		args = append(args, since)
+	}
+
+	bucketExpr := fmt.Sprintf("(CAST(EXTRACT(epoch FROM timestamp) AS BIGINT) // %d) * %d", rollup.bucketSeconds, rollup.bucketSeconds)
+	_, err = ms.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT OR REPLACE INTO %s (bucket, metric_name, service_name, unit,
+			value_sum, value_count, value_min, value_max)
+		WITH touched AS (
+			SELECT DISTINCT %s AS bucket, metric_name, service_name
+			FROM metrics
+			WHERE value IS NOT NULL AND (%s)
+		)
+		SELECT
+			points.bucket,
+			points.metric_name,
+			points.service_name,
+			COALESCE(MAX(unit), ''),
+			SUM(value),
+			COUNT(value),
+			MIN(value),
+			MAX(value)
+		FROM (SELECT *, %s AS bucket FROM metrics WHERE value IS NOT NULL) points
+		JOIN touched USING (bucket, metric_name, service_name)
+		GROUP BY points.bucket, points.metric_name, points.service_name
+	`, rollup.table, bucketExpr, touched, bucketExpr), args...)
+	if err != nil {
+		return fmt.Errorf("failed to roll up metrics into %s: %w", rollup.table, err)
+	}
+
+	if createdUntil.Valid {
+		ms.rolledUpAt[bucket] = createdUntil.Time
+	}
+	return nil
+}
+
+// rollupSplit returns where a query with the given bucket size switches from
+// the rollup to raw points: the start of the query bucket holding the rollup
+// watermark. Buckets before it were complete when rolled up; the watermark
+// bucket may have been partial. It is the zero time when nothing is rolled up.
+func (ms *MetricsStore) rollupSplit(ctx context.Context, rollup *metricRollup, bucketSeconds int64) (time.Time, error) {
+	var watermark int64
+	err := ms.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COALESCE(MAX(bucket), 0) FROM %s", rollup.table)).Scan(&watermark)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read rollup watermark: %w", err)
+	}
+	if watermark == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(watermark/bucketSeconds*bucketSeconds, 0), nil
+}
+
+// aggregateRollup answers an aggregation request from a rollup table for the
+// buckets in [from, until)
+func (ms *MetricsStore) aggregateRollup(ctx context.Context, req AggregationRequest, rollup *metricRollup, bucketSeconds int64, from, until time.Time) ([]AggregationResult, error) {
+	valueExpr := "SUM(value_sum) / SUM(value_count)"
+	switch req.Aggregation {
+	case "sum":
+		valueExpr = "SUM(value_sum)"
+	case "min":
+		valueExpr = "MIN(value_min)"
+	case "max":
+		valueExpr = "MAX(value_max)"
+	case "count":
+		valueExpr = "SUM(value_count)"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			to_timestamp((bucket // %d) * %d) AS time_bucket,
+			CAST(%s AS DOUBLE) AS value,
+			COALESCE(MAX(unit), '') AS unit
+		FROM %s
+		WHERE metric_name = ?
+			AND bucket >= ?
+			AND bucket < ?
+	`, bucketSeconds, bucketSeconds, valueExpr, rollup.table)
+
+	args := []interface{}{req.MetricName, from.Unix(), until.Unix()}
+
+	if req.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, req.ServiceName)
+	}
+
+	query += " GROUP BY time_bucket ORDER BY time_bucket ASC"
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate metric rollup: %w", err)
+	}
+	defer rows.Close()
+
+	results := []AggregationResult{}
+	for rows.Next() {
+		var result AggregationResult
+		if err := rows.Scan(&result.TimeBucket, &result.Value, &result.Unit); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregation result: %w", err)
+		}
+		result.MetricName = req.MetricName
+		result.AggregationType = req.Aggregation
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metric rollup: %w", err)
+	}
 
 	return results, nil
 }
 
+// GetHistogram merges the bucket counts of all histogram data points recorded
+// for a metric in the given time range (zero times leave the range open).
+// Data points with differing bounds are merged by upper bound.
+func (ms *MetricsStore) GetHistogram(ctx context.Context, metricName, serviceName string, startTime, endTime time.Time) (*HistogramData, error) {
+	query := `
+		SELECT histogram
+		FROM metrics
+		WHERE metric_name = ? AND histogram IS NOT NULL
+	`
+	args := []interface{}{metricName}
+
+	if serviceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, serviceName)
+	}
+
+	if !startTime.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, startTime)
+	}
+
+	if !endTime.IsZero() {
+		query += " AND timestamp <= ?"
+		args = append(args, endTime)
+	}
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query histogram: %w", err)
+	}
+	defer rows.Close()
+
+	points := []*HistogramData{}
+	for rows.Next() {
+		var histogramJSON any
+		if err := rows.Scan(&histogramJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan histogram: %w", err)
+		}
+		if h := histogramFromJSON(histogramJSON); h != nil {
+			points = append(points, h)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read histogram: %w", err)
+	}
+
+	return mergeHistograms(points), nil
+}
+
+// GetHistogramHeatmap returns histogram bucket counts grouped by time bucket and
+// upper bound, suitable for rendering a latency heatmap. The result is a flattened
+// 2D grid of {time_bucket, le, count} cells ordered by time then bound.
+func (ms *MetricsStore) GetHistogramHeatmap(ctx context.Context, req HistogramHeatmapRequest) ([]HeatmapCell, error) {
+	bucketSeconds, err := parseBucketSize(req.BucketSize)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			histogram
+		FROM metrics
+		WHERE metric_name = ?
+			AND histogram IS NOT NULL
+			AND timestamp >= ?
+			AND timestamp <= ?
+	`, timeBucketExpr("timestamp", bucketSeconds))
+
+	args := []interface{}{req.MetricName, req.StartTime, req.EndTime}
+
+	if req.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, req.ServiceName)
+	}
+
+	if req.Environment != "" {
+		query += " AND " + environmentExpr("attributes") + " = ?"
+		args = append(args, req.Environment)
+	}
+
+	query += " ORDER BY bucket ASC"
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query histogram heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	// Keyed by epoch seconds; time.Time values scanned per row are not comparable with ==
+	buckets := []time.Time{}
+	pointsByBucket := make(map[int64][]*HistogramData)
+	for rows.Next() {
+		var bucket time.Time
+		var histogramJSON any
+		if err := rows.Scan(&bucket, &histogramJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan histogram heatmap row: %w", err)
+		}
+		h := histogramFromJSON(histogramJSON)
+		if h == nil {
+			continue
+		}
+		key := bucket.Unix()
+		if _, seen := pointsByBucket[key]; !seen {
+			buckets = append(buckets, bucket)
+		}
+		pointsByBucket[key] = append(pointsByBucket[key], h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read histogram heatmap: %w", err)
+	}
+
+	cells := []HeatmapCell{}
+	for _, bucket := range buckets {
+		merged := mergeHistograms(pointsByBucket[bucket.Unix()])
+		for i, count := range merged.BucketCounts {
+			cell := HeatmapCell{TimeBucket: bucket, Count: count}
+			if i < len(merged.Bounds) {
+				le := merged.Bounds[i]
+				cell.Le = &le
+			}
+			cells = append(cells, cell)
+		}
+	}
+
+	return cells, nil
+}
+
+// mergeHistograms sums bucket counts across data points, keyed by upper bound
+func mergeHistograms(points []*HistogramData) *HistogramData {
+	merged := &HistogramData{Bounds: []float64{}, BucketCounts: []uint64{}}
+	if len(points) == 0 {
+		return merged
+	}
+
+	countsByBound := make(map[float64]uint64)
+	for _, p := range points {
+		merged.Count += p.Count
+		merged.Sum += p.Sum
+		for i, count := range p.BucketCounts {
+			bound := math.Inf(1)
+			if i < len(p.Bounds) {
+				bound = p.Bounds[i]
+			}
+			countsByBound[bound] += count
+		}
+	}
+
+	for bound := range countsByBound {
+		if !math.IsInf(bound, 1) {
+			merged.Bounds = append(merged.Bounds, bound)
+		}
+	}
+	sort.Float64s(merged.Bounds)
+
+	for _, bound := range merged.Bounds {
+		merged.BucketCounts = append(merged.BucketCounts, countsByBound[bound])
+	}
+	merged.BucketCounts = append(merged.BucketCounts, countsByBound[math.Inf(1)])
+
+	return merged
+}
+
+// histogramToJSON serializes histogram data for storage, returning nil for SQL NULL
+func histogramToJSON(h *HistogramData) interface{} {
+	if h == nil {
+		return nil
+	}
+	data, _ := json.Marshal(h)
+	return string(data)
+}
+
+// decodeExemplars decodes an exemplars JSON column value. Depending on how the
+// value was produced DuckDB returns raw JSON ([]byte or string) or an already
+// decoded []any of map[string]any; all are converted through encoding/json so
+// nested attributes and timestamps decode the same way.
+func decodeExemplars(value any, dst *[]Exemplar) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = jsonBytes
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// histogramFromJSON decodes a histogram JSON column value
+func histogramFromJSON(value any) *HistogramData {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		// DuckDB v2 might return a Go type, marshal and unmarshal
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		data = jsonBytes
+	}
+
+	var h HistogramData
+	if len(data) == 0 || json.Unmarshal(data, &h) != nil {
+		return nil
+	}
+	return &h
+}
+
 // MetricFilters holds filter parameters for metric queries
 type MetricFilters struct {
 	StartTime   time.Time
@@ -305,6 +1052,9 @@ type MetricFilters struct {
 	MetricName  string
 	MetricType  string
 	ServiceName string
+	Environment string   // deployment.environment resource attribute
+	MinValue    *float64 // Inclusive; nil means no lower bound
+	MaxValue    *float64 // Inclusive; nil means no upper bound
 	Limit       int
 	Offset      int
 }
@@ -313,10 +1063,14 @@ type MetricFilters struct {
 type AggregationRequest struct {
 	MetricName  string    `json:"metric_name"`
 	ServiceName string    `json:"service_name,omitempty"`
+	Environment string    `json:"environment,omitempty"` // deployment.environment resource attribute
 	StartTime   time.Time `json:"start_time"`
 	EndTime     time.Time `json:"end_time"`
-	Aggregation string    `json:"aggregation_type"` // avg, sum, min, max, count
+	Aggregation string    `json:"aggregation_type"` // avg, sum, min, max, count, rate
 	BucketSize  string    `json:"time_bucket"`      // e.g., "1 minute", "5 minutes", "1 hour"
+	// GroupBy splits the result into one series per combination of these
+	// attribute values, e.g. ["http.method"]
+	GroupBy []string `json:"group_by,omitempty"`
 }
 
 // AggregationResult holds the result of a metric aggregation
@@ -326,24 +1080,65 @@ type AggregationResult struct {
 	AggregationType string    `json:"aggregation_type"`
 	Value           float64   `json:"value"`
 	Unit            string    `json:"unit,omitempty"`
+	// Dimensions holds the group-by attribute values of the result's series
+	Dimensions map[string]string `json:"dimensions,omitempty"`
 }
 
-// parseBucketSizeToSeconds converts bucket size strings to seconds
-func parseBucketSizeToSeconds(bucketSize string) int64 {
-	switch bucketSize {
-	case "1 minute":
-		return 60
-	case "5 minutes":
-		return 300
-	case "15 minutes":
-		return 900
-	case "30 minutes":
-		return 1800
-	case "1 hour":
-		return 3600
-	case "6 hours":
-		return 21600
-	default:
-		return 60 // default to 1 minute
+// HistogramHeatmapRequest holds parameters for a histogram heatmap query
+type HistogramHeatmapRequest struct {
+	MetricName  string    `json:"metric_name"`
+	ServiceName string    `json:"service_name,omitempty"`
+	Environment string    `json:"environment,omitempty"` // deployment.environment resource attribute
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	BucketSize  string    `json:"time_bucket"` // e.g., "1 minute", "5 minutes", "1 hour"
+}
+
+// HeatmapCell holds the count of observations in one time bucket and histogram bucket.
+// Le is the bucket's upper bound; it is null for the +Inf overflow bucket.
+type HeatmapCell struct {
+	TimeBucket time.Time `json:"time_bucket"`
+	Le         *float64  `json:"le"`
+	Count      uint64    `json:"count"`
+}
+
+// timeBucketExpr builds a SQL expression that floors a timestamp column to the
+// start of its bucket using epoch seconds. DuckDB's "/" returns a DOUBLE for
+// integers, so "//" is required for the division to actually floor.
+func timeBucketExpr(column string, bucketSeconds int64) string {
+	return fmt.Sprintf("to_timestamp((CAST(EXTRACT(epoch FROM %s) AS BIGINT) // %d) * %d)", column, bucketSeconds, bucketSeconds)
+}
+
+// bucketUnits maps the units accepted by parseBucketSize to seconds
+var bucketUnits = map[string]int64{
+	"second": 1,
+	"minute": 60,
+	"hour":   3600,
+	"day":    86400,
+}
+
+// parseBucketSize converts a bucket size to whole seconds. It accepts
+// "<n> <unit>" with a unit of second, minute, hour or day (singular or plural),
+// e.g. "10 minutes", and Go durations such as "10m" or "1h30m". An empty size
+// is one minute. Anything else wraps ErrInvalidBucketSize.
+func parseBucketSize(bucketSize string) (int64, error) {
+	bucketSize = strings.TrimSpace(bucketSize)
+	if bucketSize == "" {
+		return 60, nil
+	}
+
+	if count, unit, ok := strings.Cut(bucketSize, " "); ok {
+		n, err := strconv.ParseInt(count, 10, 64)
+		seconds, known := bucketUnits[strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), "s")]
+		if err != nil || !known || n <= 0 || n > math.MaxInt64/seconds {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidBucketSize, bucketSize)
+		}
+		return n * seconds, nil
+	}
+
+	d, err := time.ParseDuration(bucketSize)
+	if err != nil || d < time.Second || d%time.Second != 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidBucketSize, bucketSize)
 	}
+	return int64(d / time.Second), nil
 }
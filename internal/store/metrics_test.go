@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -30,6 +32,8 @@ func TestInsertAndGetMetrics(t *testing.T) {
 		MetricType:  "histogram",
 		ServiceName: "test-service",
 		Value:       &value,
+		Unit:        "ms",
+		Description: "Duration of HTTP server requests",
 		Attributes:  map[string]interface{}{"http.method": "GET"},
 	}
 
@@ -57,6 +61,12 @@ func TestInsertAndGetMetrics(t *testing.T) {
 		if *results[0].Value != 42.5 {
 			t.Errorf("Expected value 42.5, got %f", *results[0].Value)
 		}
+		if results[0].Unit != "ms" {
+			t.Errorf("Expected unit 'ms', got %s", results[0].Unit)
+		}
+		if results[0].Description != "Duration of HTTP server requests" {
+			t.Errorf("Expected description to be preserved, got %s", results[0].Description)
+		}
 	}
 }
 
@@ -91,7 +101,7 @@ func TestGetMetricNames(t *testing.T) {
 	}
 
 	// Get metric names
-	names, err := store.Metrics.GetMetricNames(ctx, "")
+	names, err := store.Metrics.GetMetricNames(ctx, "", "")
 	if err != nil {
 		t.Fatalf("Failed to get metric names: %v", err)
 	}
@@ -125,6 +135,7 @@ func TestAggregateMetrics(t *testing.T) {
 			MetricType:  "sum",
 			ServiceName: "test-service",
 			Value:       &value,
+			Unit:        "{request}",
 		}
 		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
 			t.Fatalf("Failed to insert metric: %v", err)
@@ -159,6 +170,9 @@ func TestAggregateMetrics(t *testing.T) {
 			if results[0].AggregationType != "avg" {
 				t.Errorf("Expected aggregation_type 'avg', got %s", results[0].AggregationType)
 			}
+			if results[0].Unit != "{request}" {
+				t.Errorf("Expected unit '{request}', got %s", results[0].Unit)
+			}
 		}
 	})
 
@@ -180,4 +194,851 @@ func TestAggregateMetrics(t *testing.T) {
 			t.Error("Expected aggregation results, got none")
 		}
 	})
+
+	t.Run("invalid bucket size", func(t *testing.T) {
+		_, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+			MetricName:  "http.server.requests",
+			StartTime:   now.Add(-1 * time.Minute),
+			EndTime:     now.Add(11 * time.Minute),
+			Aggregation: "sum",
+			BucketSize:  "a fortnight",
+		})
+		if !errors.Is(err, ErrInvalidBucketSize) {
+			t.Errorf("Expected ErrInvalidBucketSize, got %v", err)
+		}
+	})
+}
+
+func TestAggregateMetricsFloorsBuckets(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Three points in the same minute, none on the bucket boundary
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{5 * time.Second, 40 * time.Second, 59 * time.Second} {
+		value := 1.0
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   start.Add(offset),
+			MetricName:  "queue.depth",
+			MetricType:  "gauge",
+			ServiceName: "test-service",
+			Value:       &value,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "queue.depth",
+		StartTime:   start,
+		EndTime:     start.Add(time.Minute),
+		Aggregation: "count",
+		BucketSize:  "1 minute",
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected the points to share one bucket, got %+v", results)
+	}
+	if !results[0].TimeBucket.Equal(start) || results[0].Value != 3 {
+		t.Errorf("Expected 3 points in the bucket starting at %v, got %+v", start, results[0])
+	}
+}
+
+func TestAggregateMetricsGroupBy(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Hour)
+
+	// Two GET points and one POST point, plus one without a method
+	points := []struct {
+		method string
+		value  float64
+	}{
+		{"GET", 10},
+		{"GET", 20},
+		{"POST", 5},
+		{"", 1},
+	}
+	for i, point := range points {
+		value := point.value
+		attributes := map[string]interface{}{}
+		if point.method != "" {
+			attributes["http.method"] = point.method
+		}
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i) * time.Second),
+			MetricName:  "http.server.requests",
+			MetricType:  "sum",
+			ServiceName: "test-service",
+			Value:       &value,
+			Attributes:  attributes,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "http.server.requests",
+		StartTime:   now.Add(-time.Minute),
+		EndTime:     now.Add(time.Minute),
+		Aggregation: "sum",
+		BucketSize:  "1 hour",
+		GroupBy:     []string{"http.method"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+
+	expected := map[string]float64{"": 1, "GET": 30, "POST": 5}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d series, got %v", len(expected), results)
+	}
+	for _, result := range results {
+		method, ok := result.Dimensions["http.method"]
+		if !ok {
+			t.Fatalf("Expected an http.method dimension, got %v", result.Dimensions)
+		}
+		if result.Value != expected[method] {
+			t.Errorf("Expected %v for method %q, got %v", expected[method], method, result.Value)
+		}
+	}
+}
+
+func TestAggregateMetricsRate(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Hour)
+
+	// A cumulative counter sampled every 10 seconds that resets after 160
+	values := []float64{100, 130, 160, 10, 40, 70}
+	for i, v := range values {
+		value := v
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i*10) * time.Second),
+			MetricName:  "http.server.request.count",
+			MetricType:  "sum",
+			ServiceName: "test-service",
+			Value:       &value,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "http.server.request.count",
+		StartTime:   now,
+		EndTime:     now.Add(time.Minute),
+		Aggregation: "rate",
+		BucketSize:  "1 minute",
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 bucket, got %v", results)
+	}
+
+	// 30 + 30 + 10 (counted from the reset) + 30 + 30 over 60 seconds
+	expected := 130.0 / 60
+	if math.Abs(results[0].Value-expected) > 1e-9 {
+		t.Errorf("Expected rate %v, got %v", expected, results[0].Value)
+	}
+}
+
+func TestMetricTemporality(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Hour)
+
+	// The same request count reported as a delta series: each point is the
+	// increase since the previous one
+	for i, v := range []float64{30, 30, 60} {
+		value := v
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i*10) * time.Second),
+			MetricName:  "http.server.request.count",
+			MetricType:  "sum",
+			ServiceName: "test-service",
+			Value:       &value,
+			IsMonotonic: true,
+			Temporality: "delta",
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	metrics, err := store.Metrics.GetMetrics(ctx, MetricFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("Expected 3 metrics, got %d", len(metrics))
+	}
+	if !metrics[0].IsMonotonic || metrics[0].Temporality != "delta" {
+		t.Errorf("Expected a monotonic delta sum, got monotonic=%v temporality=%q",
+			metrics[0].IsMonotonic, metrics[0].Temporality)
+	}
+
+	// Delta points are increases already, so none are differenced or read as resets
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "http.server.request.count",
+		StartTime:   now,
+		EndTime:     now.Add(time.Minute),
+		Aggregation: "rate",
+		BucketSize:  "1 minute",
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+	if len(results) != 1 || math.Abs(results[0].Value-2) > 1e-9 {
+		t.Errorf("Expected a rate of 2 per second, got %v", results)
+	}
+}
+
+func TestParseBucketSize(t *testing.T) {
+	valid := map[string]int64{
+		"":           60,
+		"1 minute":   60,
+		"10 minutes": 600,
+		"30 seconds": 30,
+		"6 hours":    21600,
+		"1 day":      86400,
+		"2 Days":     172800,
+		"10m":        600,
+		"1h30m":      5400,
+		"45s":        45,
+	}
+	for size, expected := range valid {
+		seconds, err := parseBucketSize(size)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", size, err)
+		} else if seconds != expected {
+			t.Errorf("%q: expected %d seconds, got %d", size, expected, seconds)
+		}
+	}
+
+	for _, size := range []string{"10 fortnights", "minute", "0 minutes", "-5 minutes", "1.5 hours", "500ms", "0s", "10"} {
+		if _, err := parseBucketSize(size); !errors.Is(err, ErrInvalidBucketSize) {
+			t.Errorf("%q: expected ErrInvalidBucketSize, got %v", size, err)
+		}
+	}
+}
+
+func TestGetHistogramMergesBuckets(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	points := []*HistogramData{
+		{Bounds: []float64{10, 50}, BucketCounts: []uint64{1, 2, 0}, Count: 3, Sum: 40},
+		{Bounds: []float64{10, 50}, BucketCounts: []uint64{4, 0, 1}, Count: 5, Sum: 90},
+		// Extra bound only present on one data point
+		{Bounds: []float64{10, 25, 50}, BucketCounts: []uint64{0, 2, 1, 0}, Count: 3, Sum: 60},
+	}
+	for i, h := range points {
+		value := h.Sum
+		metric := &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i) * time.Second),
+			MetricName:  "http.server.duration",
+			MetricType:  "histogram",
+			ServiceName: "test-service",
+			Value:       &value,
+			Histogram:   h,
+		}
+		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	merged, err := store.Metrics.GetHistogram(ctx, "http.server.duration", "test-service", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to get histogram: %v", err)
+	}
+
+	wantBounds := []float64{10, 25, 50}
+	wantCounts := []uint64{5, 2, 3, 1}
+	if len(merged.Bounds) != len(wantBounds) {
+		t.Fatalf("Expected bounds %v, got %v", wantBounds, merged.Bounds)
+	}
+	for i := range wantBounds {
+		if merged.Bounds[i] != wantBounds[i] {
+			t.Errorf("Expected bound[%d] = %v, got %v", i, wantBounds[i], merged.Bounds[i])
+		}
+	}
+	if len(merged.BucketCounts) != len(wantCounts) {
+		t.Fatalf("Expected bucket counts %v, got %v", wantCounts, merged.BucketCounts)
+	}
+	for i := range wantCounts {
+		if merged.BucketCounts[i] != wantCounts[i] {
+			t.Errorf("Expected bucket[%d] = %d, got %d", i, wantCounts[i], merged.BucketCounts[i])
+		}
+	}
+	if merged.Count != 11 || merged.Sum != 190 {
+		t.Errorf("Expected count 11 and sum 190, got %d and %v", merged.Count, merged.Sum)
+	}
+
+	// Histogram data should also come back through GetMetrics
+	results, err := store.Metrics.GetMetrics(ctx, MetricFilters{MetricName: "http.server.duration", Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	for _, m := range results {
+		if m.Histogram == nil || len(m.Histogram.BucketCounts) == 0 {
+			t.Errorf("Expected histogram data on metric %d", m.ID)
+		}
+	}
+}
+
+func TestGetHistogramHeatmap(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// Two data points in the first minute, one in the second
+	base := time.Now().Truncate(time.Minute)
+	offsets := []time.Duration{5 * time.Second, 20 * time.Second, 70 * time.Second}
+	for _, offset := range offsets {
+		value := 1.0
+		metric := &MetricRecord{
+			Timestamp:   base.Add(offset),
+			MetricName:  "http.server.duration",
+			MetricType:  "histogram",
+			ServiceName: "test-service",
+			Value:       &value,
+			Histogram:   &HistogramData{Bounds: []float64{100}, BucketCounts: []uint64{2, 1}, Count: 3},
+		}
+		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	cells, err := store.Metrics.GetHistogramHeatmap(ctx, HistogramHeatmapRequest{
+		MetricName: "http.server.duration",
+		StartTime:  base.Add(-time.Minute),
+		EndTime:    base.Add(2 * time.Minute),
+		BucketSize: "1 minute",
+	})
+	if err != nil {
+		t.Fatalf("Failed to get heatmap: %v", err)
+	}
+
+	// 2 time buckets x 2 histogram buckets
+	if len(cells) != 4 {
+		t.Fatalf("Expected 4 cells, got %d", len(cells))
+	}
+	if cells[0].Le == nil || *cells[0].Le != 100 || cells[0].Count != 4 {
+		t.Errorf("Expected first cell le=100 count=4, got %+v", cells[0])
+	}
+	if cells[1].Le != nil || cells[1].Count != 2 {
+		t.Errorf("Expected +Inf cell with count 2, got %+v", cells[1])
+	}
+	if !cells[2].TimeBucket.After(cells[0].TimeBucket) || cells[2].Count != 2 {
+		t.Errorf("Expected second time bucket with count 2, got %+v", cells[2])
+	}
+}
+
+func TestRollupMetrics(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// One point every 30 minutes over the last 10 hours
+	now := time.Now().Truncate(time.Hour)
+	for i := 0; i < 20; i++ {
+		value := float64(i)
+		metric := &MetricRecord{
+			Timestamp:   now.Add(-time.Duration(i) * 30 * time.Minute),
+			MetricName:  "queue.depth",
+			MetricType:  "gauge",
+			ServiceName: "test-service",
+			Value:       &value,
+			Unit:        "{item}",
+		}
+		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics: %v", err)
+	}
+	// Running it again must not duplicate buckets
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics again: %v", err)
+	}
+	if err := store.Metrics.RollupMetrics(ctx, "1d"); err == nil {
+		t.Error("Expected error for unknown rollup bucket")
+	}
+
+	var buckets int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM metrics_rollup_1m").Scan(&buckets); err != nil {
+		t.Fatalf("Failed to count rollup rows: %v", err)
+	}
+	if buckets != 20 {
+		t.Errorf("Expected 20 rollup buckets, got %d", buckets)
+	}
+
+	// Drop the raw points before the 6-hour bucket holding the watermark so
+	// those buckets can only be answered from the rollup
+	split := time.Unix(now.Unix()/21600*21600, 0)
+	if _, err := store.db.ExecContext(ctx, "DELETE FROM metrics WHERE timestamp < ?", split); err != nil {
+		t.Fatalf("Failed to delete raw metrics: %v", err)
+	}
+
+	results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+		MetricName:  "queue.depth",
+		StartTime:   split.Add(-12 * time.Hour),
+		EndTime:     now.Add(time.Hour),
+		Aggregation: "count",
+		BucketSize:  "6 hours",
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate metrics: %v", err)
+	}
+
+	var total float64
+	for _, r := range results {
+		total += r.Value
+		if r.Unit != "{item}" {
+			t.Errorf("Expected unit '{item}', got %s", r.Unit)
+		}
+	}
+	if total != 20 {
+		t.Errorf("Expected 20 points across rollup buckets, got %v", total)
+	}
+}
+
+func TestAggregateMetricsRollupWatermark(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	insert := func(ts time.Time, value float64) {
+		t.Helper()
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   ts,
+			MetricName:  "queue.depth",
+			MetricType:  "gauge",
+			ServiceName: "test-service",
+			Value:       &value,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+	total := func() float64 {
+		t.Helper()
+		results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+			MetricName:  "queue.depth",
+			StartTime:   watermarkBase.Add(-12 * time.Hour),
+			EndTime:     watermarkBase.Add(12 * time.Hour),
+			Aggregation: "count",
+			BucketSize:  "1 hour",
+		})
+		if err != nil {
+			t.Fatalf("Failed to aggregate metrics: %v", err)
+		}
+		var sum float64
+		for _, r := range results {
+			sum += r.Value
+		}
+		return sum
+	}
+
+	// Five points before the watermark, rolled up
+	for i := 1; i <= 5; i++ {
+		insert(watermarkBase.Add(-time.Duration(i)*time.Hour), 1)
+	}
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics: %v", err)
+	}
+
+	// Three points after the watermark, not rolled up yet
+	for i := 1; i <= 3; i++ {
+		insert(watermarkBase.Add(time.Duration(i)*time.Hour), 1)
+	}
+	if got := total(); got != 8 {
+		t.Errorf("Expected 8 points on both sides of the watermark, got %v", got)
+	}
+
+	// A late point in a bucket that was already rolled up
+	insert(watermarkBase.Add(-5*time.Hour), 1)
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics again: %v", err)
+	}
+	if got := total(); got != 9 {
+		t.Errorf("Expected the late point to be rolled up, got %v points", got)
+	}
+}
+
+func TestAggregateMetricsRollupEdgeBuckets(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// One point every 7 minutes, 45 seconds past the minute, over the 10 hours
+	// before the base
+	for i := 0; i < 86; i++ {
+		value := float64(i)
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   watermarkBase.Add(-10*time.Hour + time.Duration(i)*7*time.Minute + 45*time.Second),
+			MetricName:  "queue.depth",
+			MetricType:  "gauge",
+			ServiceName: "test-service",
+			Value:       &value,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	// Both ends of the range fall 10 seconds before a point, inside a rollup
+	// bucket, and before the rollup watermark
+	aggregate := func(aggregation string) []AggregationResult {
+		t.Helper()
+		results, err := store.Metrics.AggregateMetrics(ctx, AggregationRequest{
+			MetricName:  "queue.depth",
+			StartTime:   watermarkBase.Add(-9*time.Hour - 25*time.Minute + 35*time.Second),
+			EndTime:     watermarkBase.Add(-3*time.Hour + 35*time.Second),
+			Aggregation: aggregation,
+			BucketSize:  "1 hour",
+		})
+		if err != nil {
+			t.Fatalf("Failed to aggregate metrics: %v", err)
+		}
+		return results
+	}
+
+	aggregations := []string{"count", "avg", "min", "max"}
+	raw := make(map[string][]AggregationResult)
+	for _, aggregation := range aggregations {
+		raw[aggregation] = aggregate(aggregation)
+	}
+
+	if err := store.Metrics.RollupMetrics(ctx, "1m"); err != nil {
+		t.Fatalf("Failed to roll up metrics: %v", err)
+	}
+
+	for _, aggregation := range aggregations {
+		rolled := aggregate(aggregation)
+		if len(rolled) != len(raw[aggregation]) {
+			t.Fatalf("%s: expected %d buckets, got %d", aggregation, len(raw[aggregation]), len(rolled))
+		}
+		for i, r := range rolled {
+			want := raw[aggregation][i]
+			if !r.TimeBucket.Equal(want.TimeBucket) || math.Abs(r.Value-want.Value) > 1e-9 {
+				t.Errorf("%s: expected bucket %v = %v, got %v = %v",
+					aggregation, want.TimeBucket, want.Value, r.TimeBucket, r.Value)
+			}
+		}
+	}
+}
+
+// watermarkBase is an hour boundary so test points fall into distinct buckets
+var watermarkBase = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGetMetricsByExemplarTrace(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	exemplarTraces := [][]string{{"trace-a"}, {"trace-b", "trace-a"}, {"trace-c"}, nil}
+	for i, traceIDs := range exemplarTraces {
+		value := float64(i)
+		metric := &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i) * time.Second),
+			MetricName:  "http.server.duration",
+			MetricType:  "histogram",
+			ServiceName: "test-service",
+			Value:       &value,
+		}
+		for _, traceID := range traceIDs {
+			metric.Exemplars = append(metric.Exemplars, Exemplar{
+				Value:     value,
+				Timestamp: metric.Timestamp,
+				TraceID:   traceID,
+				SpanID:    "span-1",
+			})
+		}
+		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	results, err := store.Metrics.GetMetricsByExemplarTrace(ctx, "trace-a")
+	if err != nil {
+		t.Fatalf("Failed to get metrics by exemplar trace: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 metrics referencing trace-a, got %d", len(results))
+	}
+	for _, m := range results {
+		if len(m.Exemplars) == 0 {
+			t.Errorf("Expected exemplars on metric %d", m.ID)
+		}
+	}
+
+	results, err = store.Metrics.GetMetricsByExemplarTrace(ctx, "trace-missing")
+	if err != nil {
+		t.Fatalf("Failed to get metrics by exemplar trace: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no metrics for unknown trace, got %d", len(results))
+	}
+}
+
+func TestGetMetricsValueRange(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, logger)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	for i, v := range []float64{1, 50, 100, 250, 900} {
+		value := v
+		metric := &MetricRecord{
+			Timestamp:   now.Add(time.Duration(i) * time.Second),
+			MetricName:  "queue.depth",
+			MetricType:  "gauge",
+			ServiceName: "test-service",
+			Value:       &value,
+		}
+		if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	minValue := 100.0
+	results, err := store.Metrics.GetMetrics(ctx, MetricFilters{MinValue: &minValue})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 metrics with value >= 100, got %d", len(results))
+	}
+	for _, m := range results {
+		if m.Value == nil || *m.Value < minValue {
+			t.Errorf("Expected value >= 100, got %v", m.Value)
+		}
+	}
+
+	maxValue := 250.0
+	results, err = store.Metrics.GetMetrics(ctx, MetricFilters{MinValue: &minValue, MaxValue: &maxValue})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 metrics between 100 and 250, got %d", len(results))
+	}
+}
+
+func TestGetMetricsReturnsExemplars(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	value := 12.5
+
+	metric := &MetricRecord{
+		Timestamp:   now,
+		MetricName:  "http.server.duration",
+		MetricType:  "histogram",
+		ServiceName: "test-service",
+		Value:       &value,
+		Exemplars: []Exemplar{
+			{Value: 3.5, Timestamp: now, TraceID: "trace-one", SpanID: "span-one", Attributes: map[string]interface{}{"http.route": "/a"}},
+			{Value: 9, Timestamp: now.Add(time.Second), TraceID: "trace-two", SpanID: "span-two"},
+		},
+	}
+	if err := store.Metrics.InsertMetric(ctx, metric); err != nil {
+		t.Fatalf("Failed to insert metric: %v", err)
+	}
+
+	results, err := store.Metrics.GetMetrics(ctx, MetricFilters{MetricName: "http.server.duration"})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 metric, got %d", len(results))
+	}
+
+	exemplars := results[0].Exemplars
+	if len(exemplars) != 2 {
+		t.Fatalf("Expected 2 exemplars, got %d", len(exemplars))
+	}
+	for i, expected := range metric.Exemplars {
+		got := exemplars[i]
+		if got.TraceID != expected.TraceID || got.SpanID != expected.SpanID {
+			t.Errorf("Expected exemplar %d to reference %s/%s, got %s/%s", i, expected.TraceID, expected.SpanID, got.TraceID, got.SpanID)
+		}
+		if got.Value != expected.Value {
+			t.Errorf("Expected exemplar %d value %v, got %v", i, expected.Value, got.Value)
+		}
+		if !got.Timestamp.Equal(expected.Timestamp) {
+			t.Errorf("Expected exemplar %d timestamp %v, got %v", i, expected.Timestamp, got.Timestamp)
+		}
+	}
+	if exemplars[0].Attributes["http.route"] != "/a" {
+		t.Errorf("Expected exemplar attributes to round-trip, got %v", exemplars[0].Attributes)
+	}
+}
+
+func TestDecodeExemplars(t *testing.T) {
+	raw := `[{"value":1,"timestamp":"2024-01-01T00:00:00Z","trace_id":"t1","span_id":"s1"},` +
+		`{"value":2,"timestamp":"2024-01-01T00:00:01Z","trace_id":"t2","span_id":"s2","attributes":{"k":"v"}}]`
+	decoded := []any{
+		map[string]any{"value": 1.0, "timestamp": "2024-01-01T00:00:00Z", "trace_id": "t1", "span_id": "s1"},
+		map[string]any{"value": 2.0, "timestamp": "2024-01-01T00:00:01Z", "trace_id": "t2", "span_id": "s2", "attributes": map[string]any{"k": "v"}},
+	}
+
+	for name, value := range map[string]any{"bytes": []byte(raw), "string": raw, "decoded": decoded} {
+		var exemplars []Exemplar
+		if err := decodeExemplars(value, &exemplars); err != nil {
+			t.Fatalf("%s: failed to decode exemplars: %v", name, err)
+		}
+		if len(exemplars) != 2 {
+			t.Fatalf("%s: expected 2 exemplars, got %d", name, len(exemplars))
+		}
+		if exemplars[0].TraceID != "t1" || exemplars[1].SpanID != "s2" {
+			t.Errorf("%s: unexpected exemplars %+v", name, exemplars)
+		}
+		if exemplars[1].Attributes["k"] != "v" {
+			t.Errorf("%s: expected nested attributes, got %v", name, exemplars[1].Attributes)
+		}
+	}
+
+	var exemplars []Exemplar
+	if err := decodeExemplars(nil, &exemplars); err != nil || exemplars != nil {
+		t.Errorf("Expected nil exemplars for NULL, got %v (%v)", exemplars, err)
+	}
+	if err := decodeExemplars("not json", &exemplars); err == nil {
+		t.Errorf("Expected error for malformed exemplars")
+	}
+}
+
+func TestGetExemplars(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	records := []struct {
+		service   string
+		offset    time.Duration
+		exemplars []Exemplar
+	}{
+		{"api", -3 * time.Minute, []Exemplar{{Value: 1, Timestamp: now.Add(-3 * time.Minute), TraceID: "trace-1", SpanID: "span-1"}}},
+		{"api", -2 * time.Minute, nil},
+		{"api", -time.Minute, []Exemplar{
+			{Value: 2, Timestamp: now.Add(-time.Minute), TraceID: "trace-2", SpanID: "span-2"},
+			{Value: 3, TraceID: "trace-3", SpanID: "span-3"},
+		}},
+		{"worker", -time.Minute, []Exemplar{{Value: 4, Timestamp: now, TraceID: "trace-4", SpanID: "span-4"}}},
+		{"api", -2 * time.Hour, []Exemplar{{Value: 5, Timestamp: now.Add(-2 * time.Hour), TraceID: "trace-old", SpanID: "span-old"}}},
+	}
+	for _, r := range records {
+		value := 1.0
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{
+			Timestamp:   now.Add(r.offset),
+			MetricName:  "http.server.duration",
+			MetricType:  "histogram",
+			ServiceName: r.service,
+			Value:       &value,
+			Exemplars:   r.exemplars,
+		}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+	}
+
+	points, err := store.Metrics.GetExemplars(ctx, "http.server.duration", "api", "", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get exemplars: %v", err)
+	}
+	expected := []string{"trace-1", "trace-2", "trace-3"}
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %d exemplars, got %d", len(expected), len(points))
+	}
+	for i, traceID := range expected {
+		if points[i].TraceID != traceID {
+			t.Errorf("Expected exemplar %d to reference %s, got %s", i, traceID, points[i].TraceID)
+		}
+	}
+	// An exemplar without a timestamp takes its data point's timestamp
+	if points[2].Timestamp.IsZero() || points[2].Timestamp.Before(now.Add(-2*time.Minute)) {
+		t.Errorf("Expected exemplar without timestamp to use the data point time, got %v", points[2].Timestamp)
+	}
+
+	points, err = store.Metrics.GetExemplars(ctx, "http.server.duration", "", "", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get exemplars: %v", err)
+	}
+	if len(points) != 4 {
+		t.Errorf("Expected 4 exemplars across services, got %d", len(points))
+	}
+
+	points, err = store.Metrics.GetExemplars(ctx, "unknown.metric", "", "", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get exemplars: %v", err)
+	}
+	if points == nil || len(points) != 0 {
+		t.Errorf("Expected empty list for unknown metric, got %v", points)
+	}
 }
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// snapshotTables lists the tables written by ExportToParquet and cleared by
+// Truncate. Rows of tables with a sequence-generated id get fresh ids on import
+// so the sequence stays ahead.
+var snapshotTables = []struct {
+	name        string
+	generatedID bool
+}{
+	{name: "traces"},
+	{name: "spans"},
+	{name: "logs", generatedID: true},
+	{name: "metrics", generatedID: true},
+	{name: "metrics_rollup_1m"},
+	{name: "metrics_rollup_1h"},
+}
+
+// ExportToParquet writes every table to dir as <table>.parquet, replacing the
+// previous snapshot. All tables are read in one transaction so the snapshot is
+// consistent, and files are renamed into place only once all are written.
+func (s *Store) ExportToParquet(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin snapshot: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range snapshotTables {
+		tmp := snapshotPath(dir, table.name) + ".tmp"
+		query := fmt.Sprintf("COPY %s TO %s (FORMAT PARQUET)", table.name, sqlStringLiteral(tmp))
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", table.name, err)
+		}
+	}
+
+	for _, table := range snapshotTables {
+		path := snapshotPath(dir, table.name)
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return fmt.Errorf("failed to replace snapshot of %s: %w", table.name, err)
+		}
+	}
+
+	s.logger.Debug("Wrote Parquet snapshot", zap.String("dir", dir))
+	return nil
+}
+
+// ImportFromParquet loads a snapshot written by ExportToParquet, normally into
+// a fresh store at startup. Missing files are skipped and columns are matched by
+// name so snapshots from older versions still load. Traces, spans and rollups
+// that already exist are ignored; logs and metrics are always appended.
+func (s *Store) ImportFromParquet(ctx context.Context, dir string) error {
+	for _, table := range snapshotTables {
+		path := snapshotPath(dir, table.name)
+		if _, err := os.Stat(path); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("failed to read snapshot of %s: %w", table.name, err)
+		}
+
+		query := fmt.Sprintf("INSERT OR IGNORE INTO %s BY NAME SELECT * FROM read_parquet(%s)",
+			table.name, sqlStringLiteral(path))
+		if table.generatedID {
+			query = fmt.Sprintf("INSERT INTO %s BY NAME SELECT * EXCLUDE (id) FROM read_parquet(%s)",
+				table.name, sqlStringLiteral(path))
+		}
+		result, err := s.db.ExecContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to import snapshot of %s: %w", table.name, err)
+		}
+
+		rows, _ := result.RowsAffected()
+		s.logger.Info("Imported Parquet snapshot", zap.String("table", table.name), zap.Int64("rows", rows))
+	}
+
+	return nil
+}
+
+func snapshotPath(dir, table string) string {
+	return filepath.Join(dir, table+".parquet")
+}
+
+// sqlStringLiteral quotes s for statements such as COPY that can't take parameters
+func sqlStringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
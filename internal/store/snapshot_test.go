@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParquetSnapshot(t *testing.T) {
+	source := setupTestStore(t)
+	defer source.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	value := 42.0
+
+	if err := source.Traces.InsertTrace(ctx, &Trace{
+		TraceID:       "snapshot-trace",
+		ServiceName:   "api",
+		OperationName: "GET /",
+		StartTime:     now,
+		EndTime:       now,
+		Spans: []Span{{
+			SpanID:        "snapshot-span",
+			TraceID:       "snapshot-trace",
+			ServiceName:   "api",
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+			Attributes:    map[string]interface{}{"http.method": "GET"},
+		}},
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+	if err := source.Logs.InsertLog(ctx, &LogRecord{Timestamp: now, SeverityText: "INFO", ServiceName: "api", Body: "archived"}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+	if err := source.Metrics.InsertMetric(ctx, &MetricRecord{Timestamp: now, MetricName: "requests", MetricType: "sum", ServiceName: "api", Value: &value}); err != nil {
+		t.Fatalf("Failed to insert metric: %v", err)
+	}
+
+	// The directory is created, and a second snapshot replaces the first
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	for i := 0; i < 2; i++ {
+		if err := source.ExportToParquet(ctx, dir); err != nil {
+			t.Fatalf("Failed to export snapshot: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logs.parquet")); err != nil {
+		t.Fatalf("Expected logs.parquet to be written: %v", err)
+	}
+
+	target := setupTestStore(t)
+	defer target.Close()
+	if err := target.Logs.InsertLog(ctx, &LogRecord{Timestamp: now, SeverityText: "INFO", ServiceName: "api", Body: "already here"}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+	if err := target.ImportFromParquet(ctx, dir); err != nil {
+		t.Fatalf("Failed to import snapshot: %v", err)
+	}
+
+	trace, err := target.Traces.GetTraceByID(ctx, "snapshot-trace")
+	if err != nil {
+		t.Fatalf("Expected the trace to be restored: %v", err)
+	}
+	if len(trace.Spans) != 1 || trace.Spans[0].Attributes["http.method"] != "GET" {
+		t.Errorf("Expected the span and its attributes to be restored, got %+v", trace.Spans)
+	}
+
+	// Restored logs get fresh ids instead of colliding with existing ones
+	logs, err := target.Logs.GetLogs(ctx, LogFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Errorf("Expected 2 logs after import, got %d", len(logs))
+	}
+
+	metrics, err := target.Metrics.GetMetrics(ctx, MetricFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Value == nil || *metrics[0].Value != 42 {
+		t.Errorf("Expected the metric to be restored, got %+v", metrics)
+	}
+
+	// Importing again ignores rows that already exist
+	if err := target.ImportFromParquet(ctx, dir); err != nil {
+		t.Fatalf("Failed to re-import snapshot: %v", err)
+	}
+	if count, err := target.Traces.CountTraces(ctx, TraceFilters{}); err != nil || count != 1 {
+		t.Errorf("Expected 1 trace after re-import, got %d (err: %v)", count, err)
+	}
+}
+
+func TestImportMissingSnapshot(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	if err := store.ImportFromParquet(context.Background(), t.TempDir()); err != nil {
+		t.Errorf("Expected a missing snapshot to be skipped, got %v", err)
+	}
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"slices"
+	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"go.uber.org/zap"
 )
 
+// driverName is the name github.com/duckdb/duckdb-go/v2 registers with database/sql
+const driverName = "duckdb"
+
 // Store manages database connections and operations
 type Store struct {
 	db     *sql.DB
@@ -18,37 +24,231 @@ type Store struct {
 	Traces  *TracesStore
 	Logs    *LogsStore
 	Metrics *MetricsStore
+
+	attributeKeys *attributeKeyCache
 }
 
+// Options configures the underlying DuckDB database
+type Options struct {
+	// Path is the database file; empty keeps everything in memory.
+	// File-backed databases use DuckDB's write-ahead log automatically.
+	Path string
+	// MemoryLimit caps DuckDB memory usage, e.g. "512MB" or "2GB". Empty uses DuckDB's default.
+	MemoryLimit string
+	// Threads sets the DuckDB worker thread count. Zero uses DuckDB's default.
+	Threads int
+	// MaxOpenConns caps the connection pool. Zero uses DefaultMaxOpenConns.
+	// DuckDB runs queries in parallel internally but has a single writer per
+	// table: concurrent inserts from the HTTP and gRPC receivers only contend
+	// with each other, so a small pool is faster than an unbounded one.
+	MaxOpenConns int
+	// InsertChunkSize is how many rows InsertLogs and InsertMetrics write per
+	// transaction. Zero uses DefaultInsertChunkSize.
+	InsertChunkSize int
+}
+
+// DefaultMaxOpenConns is the connection pool size used when Options.MaxOpenConns is zero
+const DefaultMaxOpenConns = 4
+
+// DefaultInsertChunkSize is the rows per transaction used when
+// Options.InsertChunkSize is zero; it bounds how long a large batch holds the
+// table's write lock and how much DuckDB buffers before a commit
+const DefaultInsertChunkSize = 10000
+
+// connMaxIdleTime closes pooled connections that have been unused for this long
+const connMaxIdleTime = 5 * time.Minute
+
+// memoryLimitPattern matches DuckDB size strings such as "512MB", "2GB" or "1.5 GiB"
+var memoryLimitPattern = regexp.MustCompile(`(?i)^\d+(\.\d+)?\s*(b|kb|mb|gb|tb|kib|mib|gib|tib)$`)
+
 // NewStore creates a new database store with DuckDB in-memory database
 func NewStore(ctx context.Context, logger *zap.Logger) (*Store, error) {
-	// Open DuckDB in-memory database
-	db, err := sql.Open("duckdb", "")
+	return NewStoreWithOptions(ctx, logger, Options{})
+}
+
+// NewStoreWithOptions creates a new database store using the given DuckDB options
+func NewStoreWithOptions(ctx context.Context, logger *zap.Logger, opts Options) (*Store, error) {
+	if opts.MemoryLimit != "" && !memoryLimitPattern.MatchString(opts.MemoryLimit) {
+		return nil, fmt.Errorf("invalid memory limit %q: expected a size such as 512MB or 2GB", opts.MemoryLimit)
+	}
+	if opts.Threads < 0 {
+		return nil, fmt.Errorf("invalid thread count %d: must be zero or positive", opts.Threads)
+	}
+	if opts.MaxOpenConns < 0 {
+		return nil, fmt.Errorf("invalid max open connections %d: must be zero or positive", opts.MaxOpenConns)
+	}
+	if opts.MaxOpenConns == 0 {
+		opts.MaxOpenConns = DefaultMaxOpenConns
+	}
+	if opts.InsertChunkSize < 0 {
+		return nil, fmt.Errorf("invalid insert chunk size %d: must be zero or positive", opts.InsertChunkSize)
+	}
+	if opts.InsertChunkSize == 0 {
+		opts.InsertChunkSize = DefaultInsertChunkSize
+	}
+
+	// Open DuckDB database (in-memory when no path is given)
+	db, err := openDatabase(driverName, opts.Path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open DuckDB: %w", err)
+		return nil, err
 	}
 
+	// The database lives in the connector, so idle connections can be closed
+	// without losing in-memory data
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxOpenConns)
+	db.SetConnMaxIdleTime(connMaxIdleTime)
+
 	// Test connection
 	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	logger.Info("Successfully connected to DuckDB in-memory database")
+	if opts.MemoryLimit != "" {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("SET GLOBAL memory_limit = '%s'", opts.MemoryLimit)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("DuckDB rejected memory limit %q: %w", opts.MemoryLimit, err)
+		}
+	}
+
+	if opts.Threads > 0 {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("SET GLOBAL threads = %d", opts.Threads)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("DuckDB rejected thread count %d: %w", opts.Threads, err)
+		}
+	}
+
+	if opts.Path == "" {
+		logger.Info("Successfully connected to DuckDB in-memory database")
+	} else {
+		logger.Info("Successfully connected to DuckDB database file", zap.String("path", opts.Path))
+	}
 
 	store := &Store{
-		db:     db,
-		logger: logger,
+		db:            db,
+		logger:        logger,
+		attributeKeys: newAttributeKeyCache(),
 	}
 
 	// Initialize sub-stores
 	store.Traces = NewTracesStore(db, logger)
 	store.Logs = NewLogsStore(db, logger)
+	store.Logs.chunkSize = opts.InsertChunkSize
 	store.Metrics = NewMetricsStore(db, logger)
+	store.Metrics.chunkSize = opts.InsertChunkSize
 
 	return store, nil
 }
 
+// inChunks calls fn with consecutive slices of items holding at most size
+// elements. It stops at the first error and returns it along with the number
+// of items handled by earlier calls.
+func inChunks[T any](items []T, size int, fn func([]T) error) (int, error) {
+	if size <= 0 {
+		size = len(items)
+	}
+	for start := 0; start < len(items); start += size {
+		end := min(start+size, len(items))
+		if err := fn(items[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return len(items), nil
+}
+
+// openDatabase opens path with driver. When sql.Open fails because the driver
+// is not registered, e.g. after the driver module moved import paths, the
+// error points at the dependency instead of just naming the unknown driver.
+func openDatabase(driver, path string) (*sql.DB, error) {
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		if !slices.Contains(sql.Drivers(), driver) {
+			return nil, fmt.Errorf("DuckDB driver %q is not registered (available: %v): check that github.com/duckdb/duckdb-go/v2 is imported and matches the version in go.mod", driver, sql.Drivers())
+		}
+		return nil, fmt.Errorf("failed to open DuckDB: %w", err)
+	}
+	return db, nil
+}
+
+// Ping verifies the database connection is usable
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// DBStats returns the connection pool statistics of the underlying database
+func (s *Store) DBStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Truncate deletes every row from all tables in one transaction and returns
+// the number of rows removed per table. The schema and id sequences are kept.
+func (s *Store) Truncate(ctx context.Context) (map[string]int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin truncate: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted := make(map[string]int64, len(snapshotTables))
+	for _, table := range snapshotTables {
+		result, err := tx.ExecContext(ctx, "DELETE FROM "+table.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", table.name, err)
+		}
+		deleted[table.name], _ = result.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit truncate: %w", err)
+	}
+
+	s.attributeKeys.reset()
+	s.Traces.services.reset()
+	s.logger.Info("Deleted all stored data", zap.Any("rows", deleted))
+	return deleted, nil
+}
+
+// DeleteTrace removes a trace and its spans, and with withLogs the logs
+// correlated with it, in one transaction. It returns the number of spans and
+// logs deleted, or ErrTraceNotFound if the trace doesn't exist.
+func (s *Store) DeleteTrace(ctx context.Context, traceID string, withLogs bool) (spans, logs int64, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM traces WHERE trace_id = ?", traceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete trace: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, 0, ErrTraceNotFound
+	}
+
+	result, err = tx.ExecContext(ctx, "DELETE FROM spans WHERE trace_id = ?", traceID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete spans: %w", err)
+	}
+	spans, _ = result.RowsAffected()
+
+	if withLogs {
+		result, err = tx.ExecContext(ctx, "DELETE FROM logs WHERE trace_id = ?", traceID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to delete logs: %w", err)
+		}
+		logs, _ = result.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	s.attributeKeys.reset()
+	s.Traces.services.reset()
+	return spans, logs, nil
+}
+
 // Close closes the database connection
 func (s *Store) Close() {
 	s.db.Close()
@@ -127,6 +327,53 @@ func (s *Store) Migrate(ctx context.Context) error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);`,
 
+		// Metric rollup tables (pre-aggregated per bucket, keyed by bucket start in epoch seconds)
+		`CREATE TABLE IF NOT EXISTS metrics_rollup_1m (
+			bucket BIGINT NOT NULL,
+			metric_name VARCHAR NOT NULL,
+			service_name VARCHAR NOT NULL,
+			unit VARCHAR,
+			value_sum DOUBLE NOT NULL,
+			value_count BIGINT NOT NULL,
+			value_min DOUBLE NOT NULL,
+			value_max DOUBLE NOT NULL,
+			PRIMARY KEY (bucket, metric_name, service_name)
+		);`,
+		`CREATE TABLE IF NOT EXISTS metrics_rollup_1h (
+			bucket BIGINT NOT NULL,
+			metric_name VARCHAR NOT NULL,
+			service_name VARCHAR NOT NULL,
+			unit VARCHAR,
+			value_sum DOUBLE NOT NULL,
+			value_count BIGINT NOT NULL,
+			value_min DOUBLE NOT NULL,
+			value_max DOUBLE NOT NULL,
+			PRIMARY KEY (bucket, metric_name, service_name)
+		);`,
+
+		// Column additions for existing tables
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS scope_name VARCHAR;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS scope_version VARCHAR;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS dropped_attributes_count INTEGER;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS dropped_events_count INTEGER;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS dropped_links_count INTEGER;`,
+		`ALTER TABLE logs ADD COLUMN IF NOT EXISTS body_json JSON;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS estimated_timestamp BOOLEAN;`,
+		`ALTER TABLE logs ADD COLUMN IF NOT EXISTS estimated_timestamp BOOLEAN;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS estimated_timestamp BOOLEAN;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS unit VARCHAR;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS description VARCHAR;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS histogram JSON;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS trace_state VARCHAR;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS sampled BOOLEAN;`,
+		`ALTER TABLE traces ADD COLUMN IF NOT EXISTS truncated BOOLEAN;`,
+		`ALTER TABLE logs ADD COLUMN IF NOT EXISTS event_name VARCHAR;`,
+		`ALTER TABLE logs ADD COLUMN IF NOT EXISTS observed_timestamp TIMESTAMP;`,
+		`ALTER TABLE traces ADD COLUMN IF NOT EXISTS resource_attributes JSON;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS is_monotonic BOOLEAN;`,
+		`ALTER TABLE spans ADD COLUMN IF NOT EXISTS resource_attributes JSON;`,
+		`ALTER TABLE metrics ADD COLUMN IF NOT EXISTS temporality VARCHAR;`,
+
 		// Create indexes for performance (DuckDB creates them automatically for PKs)
 		`CREATE INDEX IF NOT EXISTS idx_traces_start_time ON traces(start_time);`,
 		`CREATE INDEX IF NOT EXISTS idx_traces_service_name ON traces(service_name);`,
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestOpenDatabaseDriver(t *testing.T) {
+	db, err := openDatabase(driverName, "")
+	if err != nil {
+		t.Fatalf("Expected the DuckDB driver to be registered: %v", err)
+	}
+	db.Close()
+
+	_, err = openDatabase("no-such-driver", "")
+	if err == nil || !strings.Contains(err.Error(), "not registered") || !strings.Contains(err.Error(), "duckdb-go") {
+		t.Errorf("Expected an error pointing at the driver dependency, got %v", err)
+	}
+}
+
+func TestNewStoreWithOptions(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	t.Run("Memory limit and threads applied", func(t *testing.T) {
+		store, err := NewStoreWithOptions(ctx, logger, Options{MemoryLimit: "512MB", Threads: 2})
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		var threads int64
+		if err := store.db.QueryRowContext(ctx, "SELECT current_setting('threads')").Scan(&threads); err != nil {
+			t.Fatalf("Failed to read threads setting: %v", err)
+		}
+		if threads != 2 {
+			t.Errorf("Expected threads 2, got %d", threads)
+		}
+	})
+
+	t.Run("Invalid memory limit rejected", func(t *testing.T) {
+		for _, limit := range []string{"lots", "2GB'; DROP TABLE traces; --", "-1GB"} {
+			if store, err := NewStoreWithOptions(ctx, logger, Options{MemoryLimit: limit}); err == nil {
+				store.Close()
+				t.Errorf("Expected error for memory limit %q", limit)
+			}
+		}
+	})
+
+	t.Run("File-backed database persists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "otel.duckdb")
+
+		store, err := NewStoreWithOptions(ctx, logger, Options{Path: path})
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		if err := store.Migrate(ctx); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		value := 1.0
+		if err := store.Metrics.InsertMetric(ctx, &MetricRecord{MetricName: "m", MetricType: "gauge", ServiceName: "svc", Value: &value}); err != nil {
+			t.Fatalf("Failed to insert metric: %v", err)
+		}
+		store.Close()
+
+		reopened, err := NewStoreWithOptions(ctx, logger, Options{Path: path})
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		defer reopened.Close()
+		if err := reopened.Migrate(ctx); err != nil {
+			t.Fatalf("Failed to re-run migrations: %v", err)
+		}
+
+		count, err := reopened.Metrics.GetMetricsCount(ctx)
+		if err != nil {
+			t.Fatalf("Failed to count metrics: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected 1 persisted metric, got %d", count)
+		}
+	})
+
+	t.Run("Connection pool bounded", func(t *testing.T) {
+		store, err := NewStoreWithOptions(ctx, logger, Options{})
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		if max := store.DBStats().MaxOpenConnections; max != DefaultMaxOpenConns {
+			t.Errorf("Expected max open connections %d, got %d", DefaultMaxOpenConns, max)
+		}
+
+		if store, err := NewStoreWithOptions(ctx, logger, Options{MaxOpenConns: -1}); err == nil {
+			store.Close()
+			t.Errorf("Expected error for negative max open connections")
+		}
+	})
+}
+
+func TestInsertChunked(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStoreWithOptions(ctx, zap.NewNop(), Options{InsertChunkSize: 3})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	// 10 rows span four chunks, the last one partial
+	const n = 10
+	now := time.Now()
+	logs := make([]LogRecord, n)
+	metrics := make([]MetricRecord, n)
+	for i := 0; i < n; i++ {
+		logs[i] = LogRecord{Timestamp: now, SeverityText: "INFO", SeverityNumber: 9, Body: fmt.Sprintf("log %d", i), ServiceName: "api"}
+		value := float64(i)
+		metrics[i] = MetricRecord{Timestamp: now, MetricName: "requests", MetricType: "sum", ServiceName: "api", Value: &value}
+	}
+	if _, err := store.Logs.InsertLogs(ctx, logs); err != nil {
+		t.Fatalf("Failed to insert logs: %v", err)
+	}
+	if _, err := store.Metrics.InsertMetrics(ctx, metrics); err != nil {
+		t.Fatalf("Failed to insert metrics: %v", err)
+	}
+
+	for _, table := range []string{"logs", "metrics"} {
+		var count int
+		if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != n {
+			t.Errorf("Expected %d %s, got %d", n, table, count)
+		}
+	}
+
+	if store, err := NewStoreWithOptions(ctx, zap.NewNop(), Options{InsertChunkSize: -1}); err == nil {
+		store.Close()
+		t.Errorf("Expected error for negative insert chunk size")
+	}
+}
+
+func TestInChunks(t *testing.T) {
+	var sizes []int
+	done, err := inChunks(make([]int, 7), 3, func(chunk []int) error {
+		sizes = append(sizes, len(chunk))
+		if len(sizes) == 3 {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+	if err == nil || done != 6 {
+		t.Errorf("Expected failure after 6 items, got %d, %v", done, err)
+	}
+	if fmt.Sprint(sizes) != "[3 3 1]" {
+		t.Errorf("Expected chunk sizes [3 3 1], got %v", sizes)
+	}
+}
+
+func TestConcurrentInsertMetric(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	const writers, perWriter = 16, 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				value := float64(i)
+				errs <- store.Metrics.InsertMetric(ctx, &MetricRecord{
+					Timestamp:   time.Now(),
+					MetricName:  fmt.Sprintf("concurrent.metric.%d", w),
+					MetricType:  "gauge",
+					ServiceName: "svc",
+					Value:       &value,
+				})
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent insert failed: %v", err)
+		}
+	}
+
+	count, err := store.Metrics.GetMetricsCount(ctx)
+	if err != nil {
+		t.Fatalf("Failed to count metrics: %v", err)
+	}
+	if count != writers*perWriter {
+		t.Errorf("Expected %d metrics, got %d", writers*perWriter, count)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	value := 1.0
+
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:       "truncate-trace",
+		ServiceName:   "api",
+		OperationName: "GET /",
+		StartTime:     now,
+		EndTime:       now,
+		Spans: []Span{
+			{SpanID: "span-1", TraceID: "truncate-trace", ServiceName: "api", OperationName: "GET /", StartTime: now, EndTime: now},
+			{SpanID: "span-2", TraceID: "truncate-trace", ServiceName: "api", OperationName: "SELECT", StartTime: now, EndTime: now},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+	if err := store.Logs.InsertLog(ctx, &LogRecord{Timestamp: now, ServiceName: "api", Body: "hello"}); err != nil {
+		t.Fatalf("Failed to insert log: %v", err)
+	}
+	if err := store.Metrics.InsertMetric(ctx, &MetricRecord{Timestamp: now, MetricName: "requests", MetricType: "sum", ServiceName: "api", Value: &value}); err != nil {
+		t.Fatalf("Failed to insert metric: %v", err)
+	}
+
+	deleted, err := store.Truncate(ctx)
+	if err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+	for table, expected := range map[string]int64{"traces": 1, "spans": 2, "logs": 1, "metrics": 1} {
+		if deleted[table] != expected {
+			t.Errorf("Expected %d rows deleted from %s, got %d", expected, table, deleted[table])
+		}
+	}
+
+	for _, table := range []string{"traces", "spans", "logs", "metrics", "metrics_rollup_1m", "metrics_rollup_1h"} {
+		var count int64
+		if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("Expected %s to be empty after truncate, got %d rows", table, count)
+		}
+	}
+
+	// The store keeps working after a wipe
+	if err := store.Logs.InsertLog(ctx, &LogRecord{Timestamp: now, ServiceName: "api", Body: "again"}); err != nil {
+		t.Errorf("Failed to insert log after truncate: %v", err)
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,8 +17,9 @@ import (
 
 // TracesStore handles trace and span storage and retrieval
 type TracesStore struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db       *sql.DB
+	logger   *zap.Logger
+	services serviceCache
 }
 
 // NewTracesStore creates a new traces store
@@ -36,7 +42,14 @@ type Trace struct {
 	ErrorCount    int                    `json:"error_count"`
 	StatusCode    int                    `json:"status_code"`
 	Attributes    map[string]interface{} `json:"attributes,omitempty"`
-	Spans         []Span                 `json:"spans,omitempty"`
+	// ResourceAttributes are the root span's resource attributes alone, e.g.
+	// deployment.environment; Attributes holds them merged with the span's
+	ResourceAttributes map[string]interface{} `json:"resource_attributes,omitempty"`
+	Spans              []Span                 `json:"spans,omitempty"`
+	// HasDroppedData is set when any span reports dropped attributes, events or links
+	HasDroppedData bool `json:"has_dropped_data,omitempty"`
+	// Truncated is set when spans were discarded because the trace exceeded the per-trace span limit
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // Span represents a single span within a trace
@@ -55,6 +68,23 @@ type Span struct {
 	Attributes    map[string]interface{} `json:"attributes,omitempty"`
 	Events        []SpanEvent            `json:"events,omitempty"`
 	Links         []SpanLink             `json:"links,omitempty"`
+	ScopeName     string                 `json:"scope_name,omitempty"`
+	ScopeVersion  string                 `json:"scope_version,omitempty"`
+	// Counts of data discarded by the instrumentation before export
+	DroppedAttributesCount int `json:"dropped_attributes_count,omitempty"`
+	DroppedEventsCount     int `json:"dropped_events_count,omitempty"`
+	DroppedLinksCount      int `json:"dropped_links_count,omitempty"`
+	// EstimatedTimestamp is set when the exporter sent no timestamps and receipt time was used
+	EstimatedTimestamp bool `json:"estimated_timestamp,omitempty"`
+	// W3C trace state and the sampled trace flag; Sampled is false when the
+	// exporter sends no trace flags
+	TraceState string `json:"trace_state,omitempty"`
+	Sampled    bool   `json:"sampled"`
+	// OrphanedParent is set when ParentSpanID references a span missing from the trace
+	OrphanedParent bool `json:"orphaned_parent,omitempty"`
+	// ResourceAttributes of the span's resource; only stored so the trace
+	// summary can copy them from its local root
+	ResourceAttributes map[string]interface{} `json:"-"`
 }
 
 // SpanEvent represents an event within a span
@@ -73,45 +103,91 @@ type SpanLink struct {
 
 // InsertTrace inserts a new trace with its spans
 func (ts *TracesStore) InsertTrace(ctx context.Context, trace *Trace) error {
+	return ts.InsertTraces(ctx, []*Trace{trace})
+}
+
+// InsertTraces inserts multiple traces with their spans in a single transaction,
+// preparing the insert statements once for the whole batch
+func (ts *TracesStore) InsertTraces(ctx context.Context, traces []*Trace) error {
+	if len(traces) == 0 {
+		return nil
+	}
+
 	tx, err := ts.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Insert trace
-	attributesJSON, _ := json.Marshal(trace.Attributes)
-	_, err = tx.ExecContext(ctx, `
+	stmts, err := prepareTraceStatements(ctx, tx)
+	if err != nil {
+		return err
+	}
+	defer stmts.Close()
+
+	for _, trace := range traces {
+		if err := stmts.insertTrace(ctx, trace); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// traceStatements holds the prepared statements used to write traces within a transaction
+type traceStatements struct {
+	trace   *sql.Stmt
+	span    *sql.Stmt
+	summary *sql.Stmt
+}
+
+func prepareTraceStatements(ctx context.Context, tx *sql.Tx) (*traceStatements, error) {
+	stmts := &traceStatements{}
+	var err error
+
+	stmts.trace, err = tx.PrepareContext(ctx, `
 		INSERT INTO traces (trace_id, service_name, operation_name, start_time, end_time,
-			duration_ms, span_count, error_count, status_code, attributes)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			duration_ms, span_count, error_count, status_code, attributes, resource_attributes, truncated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT (trace_id) DO UPDATE SET
 			start_time = EXCLUDED.start_time,
 			end_time = EXCLUDED.end_time,
 			duration_ms = EXCLUDED.duration_ms,
 			span_count = EXCLUDED.span_count,
-			error_count = EXCLUDED.error_count
-	`, trace.TraceID, trace.ServiceName, trace.OperationName, trace.StartTime, trace.EndTime,
-		trace.DurationMs, trace.SpanCount, trace.ErrorCount, trace.StatusCode, string(attributesJSON))
-
+			error_count = EXCLUDED.error_count,
+			truncated = COALESCE(traces.truncated, false) OR EXCLUDED.truncated
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to insert trace: %w", err)
+		return nil, fmt.Errorf("failed to prepare trace insert: %w", err)
 	}
 
-	// Insert spans
-	for _, span := range trace.Spans {
-		if err := ts.insertSpan(ctx, tx, &span); err != nil {
-			return fmt.Errorf("failed to insert span: %w", err)
-		}
+	stmts.span, err = tx.PrepareContext(ctx, `
+		INSERT INTO spans (span_id, trace_id, parent_span_id, service_name, operation_name,
+			span_kind, start_time, end_time, duration_ms, status_code, status_message,
+			attributes, events, links, scope_name, scope_version,
+			dropped_attributes_count, dropped_events_count, dropped_links_count,
+			estimated_timestamp, trace_state, sampled, resource_attributes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (span_id) DO NOTHING
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare span insert: %w", err)
 	}
 
 	// Update trace summary
 	// - local root: parent_span_id NULL or not delivered; earliest wins)
-	// - operation_name, service_name: from local root
+	// - operation_name, service_name, resource_attributes: from local root
+	// - attributes: local root's resource attributes overlaid with its own
 	// - status_code: max across all spans, Unset (0) < Ok (1) < Error (2)
-	_, err = tx.ExecContext(ctx, `
+	stmts.summary, err = tx.PrepareContext(ctx, `
 		WITH local_root AS (
-			SELECT s.operation_name, s.service_name, s.status_code
+			SELECT s.operation_name, s.service_name, s.status_code,
+				s.attributes, s.resource_attributes
 			FROM spans s
 			WHERE s.trace_id = $1
 			AND (s.parent_span_id IS NULL
@@ -122,84 +198,92 @@ func (ts *TracesStore) InsertTrace(ctx context.Context, trace *Trace) error {
 		UPDATE traces SET
 			operation_name = COALESCE((SELECT operation_name FROM local_root), operation_name),
 			service_name = COALESCE((SELECT service_name FROM local_root), service_name),
+			resource_attributes = COALESCE((SELECT resource_attributes FROM local_root), resource_attributes),
+			attributes = COALESCE((SELECT json_merge_patch(resource_attributes, attributes) FROM local_root), attributes),
 			status_code = COALESCE((SELECT max(s.status_code) FROM spans s WHERE s.trace_id = $1), status_code)
 		WHERE trace_id = $1
-	`, trace.TraceID)
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to update trace summary from spans: %w", err)
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare trace summary update: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return stmts, nil
+}
+
+// Close releases the prepared statements
+func (st *traceStatements) Close() {
+	for _, stmt := range []*sql.Stmt{st.trace, st.span, st.summary} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// insertTrace upserts a trace and its spans, then refreshes the trace summary
+func (st *traceStatements) insertTrace(ctx context.Context, trace *Trace) error {
+	attributesJSON, _ := json.Marshal(trace.Attributes)
+	resourceAttrJSON, _ := json.Marshal(trace.ResourceAttributes)
+	_, err := st.trace.ExecContext(ctx, trace.TraceID, trace.ServiceName, trace.OperationName,
+		trace.StartTime, trace.EndTime, trace.DurationMs, trace.SpanCount, trace.ErrorCount,
+		trace.StatusCode, string(attributesJSON), string(resourceAttrJSON), trace.Truncated)
+	if err != nil {
+		return fmt.Errorf("failed to insert trace: %w", err)
+	}
+
+	for _, span := range trace.Spans {
+		if err := st.insertSpan(ctx, &span); err != nil {
+			return fmt.Errorf("failed to insert span: %w", err)
+		}
+	}
+
+	if _, err := st.summary.ExecContext(ctx, trace.TraceID); err != nil {
+		return fmt.Errorf("failed to update trace summary from spans: %w", err)
 	}
 
 	return nil
 }
 
-func (ts *TracesStore) insertSpan(ctx context.Context, tx *sql.Tx, span *Span) error {
+func (st *traceStatements) insertSpan(ctx context.Context, span *Span) error {
 	attributesJSON, _ := json.Marshal(span.Attributes)
 	eventsJSON, _ := json.Marshal(span.Events)
 	linksJSON, _ := json.Marshal(span.Links)
+	// NULL rather than "null" so spans without a resource leave the trace's alone
+	var resourceAttrs interface{}
+	if span.ResourceAttributes != nil {
+		data, _ := json.Marshal(span.ResourceAttributes)
+		resourceAttrs = string(data)
+	}
 
-	_, err := tx.ExecContext(ctx, `
-		INSERT INTO spans (span_id, trace_id, parent_span_id, service_name, operation_name,
-			span_kind, start_time, end_time, duration_ms, status_code, status_message,
-			attributes, events, links)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT (span_id) DO NOTHING
-	`, span.SpanID, span.TraceID, span.ParentSpanID, span.ServiceName, span.OperationName,
-		span.SpanKind, span.StartTime, span.EndTime, span.DurationMs, span.StatusCode,
-		span.StatusMessage, string(attributesJSON), string(eventsJSON), string(linksJSON))
+	_, err := st.span.ExecContext(ctx, span.SpanID, span.TraceID, span.ParentSpanID,
+		span.ServiceName, span.OperationName, span.SpanKind, span.StartTime, span.EndTime,
+		span.DurationMs, span.StatusCode, span.StatusMessage, string(attributesJSON),
+		string(eventsJSON), string(linksJSON), span.ScopeName, span.ScopeVersion,
+		span.DroppedAttributesCount, span.DroppedEventsCount, span.DroppedLinksCount,
+		span.EstimatedTimestamp, span.TraceState, span.Sampled, resourceAttrs)
 
 	return err
 }
 
 // GetTraces retrieves traces with optional filters
 func (ts *TracesStore) GetTraces(ctx context.Context, filters TraceFilters) ([]Trace, error) {
-	query := `
+	// The list view rarely needs attributes and they dominate the row size
+	attributesColumns := "NULL, NULL"
+	if filters.IncludeAttributes {
+		attributesColumns = "attributes, resource_attributes"
+	}
+
+	query := fmt.Sprintf(`
 		SELECT trace_id, service_name, operation_name, start_time, end_time,
-			duration_ms, span_count, error_count, status_code, attributes
+			duration_ms, span_count, error_count, status_code, %s,
+			COALESCE(truncated, false)
 		FROM traces
 		WHERE 1=1
-	`
-	args := []interface{}{}
+	`, attributesColumns)
+	where, args := buildTraceFilters(filters)
+	query += where
 
-	if filters.ServiceName != "" {
-		query += " AND service_name = ?"
-		args = append(args, filters.ServiceName)
-	}
-
-	if filters.MinDuration > 0 {
-		query += " AND duration_ms >= ?"
-		args = append(args, filters.MinDuration)
-	}
-
-	if filters.MaxDuration > 0 {
-		query += " AND duration_ms <= ?"
-		args = append(args, filters.MaxDuration)
-	}
-
-	if filters.HasErrors {
-		query += " AND error_count > 0"
-	}
-
-	if filters.Search != "" {
-		query += " AND (operation_name LIKE ? OR trace_id LIKE ?)"
-		searchPattern := "%" + filters.Search + "%"
-		args = append(args, searchPattern, searchPattern)
-	}
-
-	if !filters.StartTime.IsZero() {
-		query += " AND start_time >= ?"
-		args = append(args, filters.StartTime)
-	}
-
-	if !filters.EndTime.IsZero() {
-		query += " AND start_time <= ?"
-		args = append(args, filters.EndTime)
-	}
-
-	query += " ORDER BY start_time DESC LIMIT ? OFFSET ?"
+	query += " ORDER BY " + traceOrderBy(filters) + " LIMIT ? OFFSET ?"
 	args = append(args, filters.Limit, filters.Offset)
 
 	rows, err := ts.db.QueryContext(ctx, query, args...)
@@ -211,23 +295,17 @@ func (ts *TracesStore) GetTraces(ctx context.Context, filters TraceFilters) ([]T
 	traces := []Trace{}
 	for rows.Next() {
 		var trace Trace
-		var attributesJSON any
+		var attributesJSON, resourceAttrJSON any
 
 		err := rows.Scan(&trace.TraceID, &trace.ServiceName, &trace.OperationName,
 			&trace.StartTime, &trace.EndTime, &trace.DurationMs, &trace.SpanCount,
-			&trace.ErrorCount, &trace.StatusCode, &attributesJSON)
+			&trace.ErrorCount, &trace.StatusCode, &attributesJSON, &resourceAttrJSON, &trace.Truncated)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trace: %w", err)
 		}
 
-		// Handle JSON columns - DuckDB v2 returns map directly
-		if attributesJSON != nil {
-			if m, ok := attributesJSON.(map[string]any); ok {
-				trace.Attributes = m
-			} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &trace.Attributes)
-			}
-		}
+		decodeAttributes(attributesJSON, &trace.Attributes)
+		decodeAttributes(resourceAttrJSON, &trace.ResourceAttributes)
 
 		traces = append(traces, trace)
 	}
@@ -235,36 +313,67 @@ func (ts *TracesStore) GetTraces(ctx context.Context, filters TraceFilters) ([]T
 	return traces, nil
 }
 
+// GetSlowestTraces returns the traces of serviceName (all services when empty)
+// whose duration is at or above the given percentile (0 < percentile < 1) of
+// their durations, slowest first, along with that threshold in milliseconds.
+func (ts *TracesStore) GetSlowestTraces(ctx context.Context, serviceName string, percentile float64, limit int) ([]Trace, float64, error) {
+	if percentile <= 0 || percentile >= 1 {
+		return nil, 0, fmt.Errorf("invalid percentile %v: must be between 0 and 1", percentile)
+	}
+
+	query := "SELECT quantile_cont(duration_ms, ?) FROM traces WHERE 1=1"
+	args := []interface{}{percentile}
+	if serviceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, serviceName)
+	}
+
+	var threshold sql.NullFloat64
+	if err := ts.db.QueryRowContext(ctx, query, args...).Scan(&threshold); err != nil {
+		return nil, 0, fmt.Errorf("failed to query duration percentile: %w", err)
+	}
+	if !threshold.Valid {
+		return []Trace{}, 0, nil
+	}
+
+	// Durations are whole milliseconds, so rounding up keeps the same traces
+	traces, err := ts.GetTraces(ctx, TraceFilters{
+		ServiceName: serviceName,
+		MinDuration: int64(math.Ceil(threshold.Float64)),
+		SortBy:      "duration_ms",
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return traces, threshold.Float64, nil
+}
+
 // GetTraceByID retrieves a single trace with all its spans
 func (ts *TracesStore) GetTraceByID(ctx context.Context, traceID string) (*Trace, error) {
 	// Get trace
 	var trace Trace
-	var attributesJSON any
+	var attributesJSON, resourceAttrJSON any
 
 	err := ts.db.QueryRowContext(ctx, `
 		SELECT trace_id, service_name, operation_name, start_time, end_time,
-			duration_ms, span_count, error_count, status_code, attributes
+			duration_ms, span_count, error_count, status_code, attributes,
+			resource_attributes, COALESCE(truncated, false)
 		FROM traces
 		WHERE trace_id = ?
 	`, traceID).Scan(&trace.TraceID, &trace.ServiceName, &trace.OperationName,
 		&trace.StartTime, &trace.EndTime, &trace.DurationMs, &trace.SpanCount,
-		&trace.ErrorCount, &trace.StatusCode, &attributesJSON)
+		&trace.ErrorCount, &trace.StatusCode, &attributesJSON, &resourceAttrJSON, &trace.Truncated)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("trace not found")
+			return nil, ErrTraceNotFound
 		}
 		return nil, fmt.Errorf("failed to query trace: %w", err)
 	}
 
-	// Handle JSON columns - DuckDB v2 returns map directly
-	if attributesJSON != nil {
-		if m, ok := attributesJSON.(map[string]any); ok {
-			trace.Attributes = m
-		} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
-			json.Unmarshal(bytes, &trace.Attributes)
-		}
-	}
+	decodeAttributes(attributesJSON, &trace.Attributes)
+	decodeAttributes(resourceAttrJSON, &trace.ResourceAttributes)
 
 	// Get spans
 	spans, err := ts.getSpansByTraceID(ctx, traceID)
@@ -273,14 +382,27 @@ func (ts *TracesStore) GetTraceByID(ctx context.Context, traceID string) (*Trace
 	}
 	trace.Spans = spans
 
+	for _, span := range spans {
+		if span.DroppedAttributesCount > 0 || span.DroppedEventsCount > 0 || span.DroppedLinksCount > 0 {
+			trace.HasDroppedData = true
+			break
+		}
+	}
+
 	return &trace, nil
 }
 
+// spanColumns is the select list matching scanSpan
+const spanColumns = `span_id, trace_id, parent_span_id, service_name, operation_name,
+			span_kind, start_time, end_time, duration_ms, status_code, status_message,
+			attributes, events, links, COALESCE(scope_name, ''), COALESCE(scope_version, ''),
+			COALESCE(dropped_attributes_count, 0), COALESCE(dropped_events_count, 0),
+			COALESCE(dropped_links_count, 0), COALESCE(estimated_timestamp, false),
+			COALESCE(trace_state, ''), COALESCE(sampled, false)`
+
 func (ts *TracesStore) getSpansByTraceID(ctx context.Context, traceID string) ([]Span, error) {
 	rows, err := ts.db.QueryContext(ctx, `
-		SELECT span_id, trace_id, parent_span_id, service_name, operation_name,
-			span_kind, start_time, end_time, duration_ms, status_code, status_message,
-			attributes, events, links
+		SELECT `+spanColumns+`
 		FROM spans
 		WHERE trace_id = ?
 		ORDER BY start_time ASC
@@ -292,61 +414,246 @@ func (ts *TracesStore) getSpansByTraceID(ctx context.Context, traceID string) ([
 
 	spans := []Span{}
 	for rows.Next() {
-		var span Span
-		var attributesJSON, eventsJSON, linksJSON any
+		span, err := scanSpan(rows)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, *span)
+	}
+
+	markOrphanedParents(spans)
+
+	return spans, nil
+}
+
+// GetSpanByID retrieves a single span without loading the rest of its trace
+func (ts *TracesStore) GetSpanByID(ctx context.Context, spanID string) (*Span, error) {
+	row := ts.db.QueryRowContext(ctx, `
+		SELECT `+spanColumns+`
+		FROM spans
+		WHERE span_id = ?
+	`, spanID)
+
+	span, err := scanSpan(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSpanNotFound
+		}
+		return nil, err
+	}
 
-		err := rows.Scan(&span.SpanID, &span.TraceID, &span.ParentSpanID, &span.ServiceName,
-			&span.OperationName, &span.SpanKind, &span.StartTime, &span.EndTime,
-			&span.DurationMs, &span.StatusCode, &span.StatusMessage,
-			&attributesJSON, &eventsJSON, &linksJSON)
+	return span, nil
+}
+
+// maxSpanEventResults caps SearchSpanEvents so a common event name can't return
+// every span in the store
+const maxSpanEventResults = 1000
+
+// SearchSpanEvents returns the spans started within the last timeRange that
+// recorded an event named eventName, newest first, with all of their events.
+// At most maxSpanEventResults spans are returned.
+func (ts *TracesStore) SearchSpanEvents(ctx context.Context, eventName string, timeRange time.Duration) ([]Span, error) {
+	rows, err := ts.db.QueryContext(ctx, `
+		SELECT `+spanColumns+`
+		FROM spans
+		WHERE start_time >= ?
+			AND list_contains(json_extract_string(events, '$[*].name'), ?)
+		ORDER BY start_time DESC
+		LIMIT ?
+	`, time.Now().Add(-timeRange), eventName, maxSpanEventResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search span events: %w", err)
+	}
+	defer rows.Close()
+
+	spans := []Span{}
+	for rows.Next() {
+		span, err := scanSpan(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan span: %w", err)
+			return nil, err
 		}
+		spans = append(spans, *span)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read span events: %w", err)
+	}
 
-		// Handle JSON columns - DuckDB v2 returns map/array directly
-		if attributesJSON != nil {
-			if m, ok := attributesJSON.(map[string]any); ok {
-				span.Attributes = m
-			} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &span.Attributes)
-			} else if str, ok := attributesJSON.(string); ok && len(str) > 0 {
-				json.Unmarshal([]byte(str), &span.Attributes)
-			}
+	return spans, nil
+}
+
+// scanSpan reads one row selected with spanColumns
+func scanSpan(row interface{ Scan(dest ...any) error }) (*Span, error) {
+	var span Span
+	var attributesJSON, eventsJSON, linksJSON any
+
+	err := row.Scan(&span.SpanID, &span.TraceID, &span.ParentSpanID, &span.ServiceName,
+		&span.OperationName, &span.SpanKind, &span.StartTime, &span.EndTime,
+		&span.DurationMs, &span.StatusCode, &span.StatusMessage,
+		&attributesJSON, &eventsJSON, &linksJSON, &span.ScopeName, &span.ScopeVersion,
+		&span.DroppedAttributesCount, &span.DroppedEventsCount, &span.DroppedLinksCount,
+		&span.EstimatedTimestamp, &span.TraceState, &span.Sampled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan span: %w", err)
+	}
+
+	// Handle JSON columns - DuckDB v2 returns map/array directly
+	if attributesJSON != nil {
+		if m, ok := attributesJSON.(map[string]any); ok {
+			span.Attributes = m
+		} else if bytes, ok := attributesJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &span.Attributes)
+		} else if str, ok := attributesJSON.(string); ok && len(str) > 0 {
+			json.Unmarshal([]byte(str), &span.Attributes)
 		}
-		// For complex types, convert to JSON and unmarshal
-		if eventsJSON != nil {
-			if bytes, ok := eventsJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &span.Events)
-			} else if str, ok := eventsJSON.(string); ok && len(str) > 0 {
-				json.Unmarshal([]byte(str), &span.Events)
-			} else if eventsJSON != nil {
-				// DuckDB v2 might return a Go type, marshal and unmarshal
-				if jsonBytes, err := json.Marshal(eventsJSON); err == nil {
-					json.Unmarshal(jsonBytes, &span.Events)
-				}
+	}
+	// For complex types, convert to JSON and unmarshal
+	if eventsJSON != nil {
+		if bytes, ok := eventsJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &span.Events)
+		} else if str, ok := eventsJSON.(string); ok && len(str) > 0 {
+			json.Unmarshal([]byte(str), &span.Events)
+		} else if eventsJSON != nil {
+			// DuckDB v2 might return a Go type, marshal and unmarshal
+			if jsonBytes, err := json.Marshal(eventsJSON); err == nil {
+				json.Unmarshal(jsonBytes, &span.Events)
 			}
 		}
-		if linksJSON != nil {
-			if bytes, ok := linksJSON.([]byte); ok && len(bytes) > 0 {
-				json.Unmarshal(bytes, &span.Links)
-			} else if str, ok := linksJSON.(string); ok && len(str) > 0 {
-				json.Unmarshal([]byte(str), &span.Links)
-			} else if linksJSON != nil {
-				// DuckDB v2 might return a Go type, marshal and unmarshal
-				if jsonBytes, err := json.Marshal(linksJSON); err == nil {
-					json.Unmarshal(jsonBytes, &span.Links)
-				}
+	}
+	if linksJSON != nil {
+		if bytes, ok := linksJSON.([]byte); ok && len(bytes) > 0 {
+			json.Unmarshal(bytes, &span.Links)
+		} else if str, ok := linksJSON.(string); ok && len(str) > 0 {
+			json.Unmarshal([]byte(str), &span.Links)
+		} else if linksJSON != nil {
+			// DuckDB v2 might return a Go type, marshal and unmarshal
+			if jsonBytes, err := json.Marshal(linksJSON); err == nil {
+				json.Unmarshal(jsonBytes, &span.Links)
 			}
 		}
+	}
+
+	return &span, nil
+}
 
-		spans = append(spans, span)
+// markOrphanedParents flags spans whose parent is not part of the given span set,
+// so clients can render them under a synthetic root
+func markOrphanedParents(spans []Span) {
+	spanIDs := make(map[string]bool, len(spans))
+	for _, span := range spans {
+		spanIDs[span.SpanID] = true
 	}
+	for i := range spans {
+		parentID := spans[i].ParentSpanID
+		spans[i].OrphanedParent = parentID != nil && *parentID != "" && !spanIDs[*parentID]
+	}
+}
 
-	return spans, nil
+// CountTraces returns the number of traces matching filters (ignoring Limit and Offset)
+func (ts *TracesStore) CountTraces(ctx context.Context, filters TraceFilters) (int64, error) {
+	where, args := buildTraceFilters(filters)
+
+	var count int64
+	err := ts.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM traces WHERE 1=1"+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count traces: %w", err)
+	}
+	return count, nil
+}
+
+// FilterExistingTraceIDs returns the subset of ids that have a row in traces,
+// in no particular order
+func (ts *TracesStore) FilterExistingTraceIDs(ctx context.Context, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+
+	rows, err := ts.db.QueryContext(ctx,
+		"SELECT DISTINCT trace_id FROM traces WHERE trace_id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing trace IDs: %w", err)
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan trace ID: %w", err)
+		}
+		existing = append(existing, id)
+	}
+	return existing, rows.Err()
+}
+
+// CountErrorTraces returns the number of traces containing at least one error span
+func (ts *TracesStore) CountErrorTraces(ctx context.Context) (int64, error) {
+	var count int64
+	err := ts.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM traces WHERE error_count > 0").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count error traces: %w", err)
+	}
+	return count, nil
+}
+
+// CountSpans returns the total number of spans
+func (ts *TracesStore) CountSpans(ctx context.Context) (int64, error) {
+	var count int64
+	err := ts.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM spans").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count spans: %w", err)
+	}
+	return count, nil
 }
 
-// GetServices returns a list of unique service names
+// servicesTTL is how long GetServices reuses its result; the list is read on
+// every dashboard refresh and new services rarely appear
+const servicesTTL = 10 * time.Second
+
+// serviceCache holds the last GetServices result
+type serviceCache struct {
+	mu       sync.Mutex
+	services []string
+	expires  time.Time
+}
+
+// get returns the cached services if they have not expired
+func (c *serviceCache) get() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.services == nil || !time.Now().Before(c.expires) {
+		return nil, false
+	}
+	return c.services, true
+}
+
+func (c *serviceCache) set(services []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services = services
+	c.expires = time.Now().Add(servicesTTL)
+}
+
+// reset drops the cached services, e.g. after traces were deleted
+func (c *serviceCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services = nil
+}
+
+// GetServices returns a list of unique service names. Results are cached for
+// servicesTTL, so a new service can take that long to appear; callers must not
+// modify the returned slice.
 func (ts *TracesStore) GetServices(ctx context.Context) ([]string, error) {
+	if services, ok := ts.services.get(); ok {
+		return services, nil
+	}
+
 	rows, err := ts.db.QueryContext(ctx, `
 		SELECT DISTINCT service_name FROM traces ORDER BY service_name
 	`)
@@ -363,19 +670,397 @@ func (ts *TracesStore) GetServices(ctx context.Context) ([]string, error) {
 		}
 		services = append(services, service)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read services: %w", err)
+	}
 
+	ts.services.set(services)
 	return services, nil
 }
 
+// OperationCount is the number of spans a service recorded for one operation
+type OperationCount struct {
+	OperationName string `json:"operation_name"`
+	Count         int64  `json:"count"`
+}
+
+// GetOperationsByService returns the distinct operations a service has
+// produced, counted over its spans (not only trace roots), most frequent first
+func (ts *TracesStore) GetOperationsByService(ctx context.Context, serviceName string) ([]OperationCount, error) {
+	rows, err := ts.db.QueryContext(ctx, `
+		SELECT operation_name, COUNT(*) AS count
+		FROM spans
+		WHERE service_name = ?
+		GROUP BY operation_name
+		ORDER BY count DESC, operation_name ASC
+	`, serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []OperationCount{}
+	for rows.Next() {
+		var op OperationCount
+		if err := rows.Scan(&op.OperationName, &op.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		operations = append(operations, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read operations: %w", err)
+	}
+
+	return operations, nil
+}
+
+// OperationStats holds latency and throughput figures for one root operation
+type OperationStats struct {
+	OperationName string  `json:"operation_name"`
+	Count         int64   `json:"count"`
+	ErrorCount    int64   `json:"error_count"`
+	P50Ms         float64 `json:"p50_ms"`
+	P95Ms         float64 `json:"p95_ms"`
+	P99Ms         float64 `json:"p99_ms"`
+	Throughput    float64 `json:"throughput_per_sec"`
+}
+
+// GetOperationStats computes per-operation latency percentiles and throughput over traces.
+// Zero times leave the range open; throughput then uses the span of observed traces.
+func (ts *TracesStore) GetOperationStats(ctx context.Context, serviceName string, startTime, endTime time.Time) ([]OperationStats, error) {
+	query := `
+		SELECT
+			operation_name,
+			COUNT(*) AS count,
+			COALESCE(SUM(error_count), 0) AS error_count,
+			quantile_cont(duration_ms, 0.50) AS p50,
+			quantile_cont(duration_ms, 0.95) AS p95,
+			quantile_cont(duration_ms, 0.99) AS p99,
+			EXTRACT(epoch FROM MAX(start_time)) - EXTRACT(epoch FROM MIN(start_time)) AS observed_seconds
+		FROM traces
+		WHERE 1=1
+	`
+	args := []interface{}{}
+
+	if serviceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, serviceName)
+	}
+
+	if !startTime.IsZero() {
+		query += " AND start_time >= ?"
+		args = append(args, startTime)
+	}
+
+	if !endTime.IsZero() {
+		query += " AND start_time <= ?"
+		args = append(args, endTime)
+	}
+
+	query += " GROUP BY operation_name ORDER BY count DESC, operation_name ASC"
+
+	rows, err := ts.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operation stats: %w", err)
+	}
+	defer rows.Close()
+
+	// A fixed window gives comparable throughput across operations
+	var windowSeconds float64
+	if !startTime.IsZero() && !endTime.IsZero() {
+		windowSeconds = endTime.Sub(startTime).Seconds()
+	}
+
+	stats := []OperationStats{}
+	for rows.Next() {
+		var op OperationStats
+		var observedSeconds float64
+		if err := rows.Scan(&op.OperationName, &op.Count, &op.ErrorCount,
+			&op.P50Ms, &op.P95Ms, &op.P99Ms, &observedSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan operation stats: %w", err)
+		}
+
+		seconds := windowSeconds
+		if seconds <= 0 {
+			seconds = observedSeconds
+		}
+		if seconds > 0 {
+			op.Throughput = float64(op.Count) / seconds
+		}
+
+		stats = append(stats, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read operation stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ErrorRatePoint is the error rate of one service over one time bucket
+type ErrorRatePoint struct {
+	TimeBucket time.Time `json:"time_bucket"`
+	TraceCount int64     `json:"trace_count"`
+	ErrorCount int64     `json:"error_count"`
+	ErrorRate  float64   `json:"error_rate"`
+}
+
+// GetErrorRateTimeSeries buckets a service's traces over the last timeRange and returns
+// errors per trace for each bucket. bucket uses the AggregateMetrics sizes ("1 minute",
+// "5 minutes", ...). Buckets without traces are omitted, so a gap means no data rather
+// than a zero error rate.
+func (ts *TracesStore) GetErrorRateTimeSeries(ctx context.Context, serviceName, bucket string, timeRange time.Duration) ([]ErrorRatePoint, error) {
+	bucketSeconds, err := parseBucketSize(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS bucket,
+			COUNT(*) AS trace_count,
+			COALESCE(SUM(error_count), 0) AS error_count,
+			COALESCE(SUM(error_count), 0)::DOUBLE / COUNT(*) AS error_rate
+		FROM traces
+		WHERE service_name = ?
+			AND start_time >= ?
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`, timeBucketExpr("start_time", bucketSeconds))
+
+	rows, err := ts.db.QueryContext(ctx, query, serviceName, time.Now().Add(-timeRange))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query error rate: %w", err)
+	}
+	defer rows.Close()
+
+	points := []ErrorRatePoint{}
+	for rows.Next() {
+		var point ErrorRatePoint
+		if err := rows.Scan(&point.TimeBucket, &point.TraceCount, &point.ErrorCount, &point.ErrorRate); err != nil {
+			return nil, fmt.Errorf("failed to scan error rate: %w", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read error rate: %w", err)
+	}
+
+	return points, nil
+}
+
+// DefaultDurationBuckets are the lower bounds, in milliseconds, of the trace
+// duration histogram buckets used when none are given
+var DefaultDurationBuckets = []int64{0, 10, 50, 100, 500, 1000, 5000}
+
+// DurationBucket is the number of traces whose duration falls in [MinMs, MaxMs).
+// MaxMs is nil for the last, open-ended bucket.
+type DurationBucket struct {
+	MinMs int64  `json:"min_ms"`
+	MaxMs *int64 `json:"max_ms"`
+	Count int64  `json:"count"`
+}
+
+// GetTraceDurationHistogram counts the traces matching filters per duration
+// bucket. bounds are ascending lower bounds in milliseconds; traces shorter than
+// the first bound are not counted. Every bucket is returned, including empty ones.
+func (ts *TracesStore) GetTraceDurationHistogram(ctx context.Context, filters TraceFilters, bounds []int64) ([]DurationBucket, error) {
+	buckets := make([]DurationBucket, len(bounds))
+	if len(bounds) == 0 {
+		return buckets, nil
+	}
+
+	// Checked from the highest bound down so each trace lands in exactly one bucket
+	caseExpr := "CASE"
+	for i := len(bounds) - 1; i >= 0; i-- {
+		caseExpr += fmt.Sprintf(" WHEN duration_ms >= %d THEN %d", bounds[i], i)
+	}
+	caseExpr += " END"
+
+	where, args := buildTraceFilters(filters)
+	query := fmt.Sprintf(`
+		SELECT bucket, COUNT(*)
+		FROM (SELECT %s AS bucket FROM traces WHERE 1=1%s)
+		WHERE bucket IS NOT NULL
+		GROUP BY bucket
+	`, caseExpr, where)
+
+	rows, err := ts.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duration histogram: %w", err)
+	}
+	defer rows.Close()
+
+	for i, bound := range bounds {
+		buckets[i].MinMs = bound
+		if i+1 < len(bounds) {
+			upper := bounds[i+1]
+			buckets[i].MaxMs = &upper
+		}
+	}
+
+	for rows.Next() {
+		var index int
+		var count int64
+		if err := rows.Scan(&index, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan duration histogram: %w", err)
+		}
+		buckets[index].Count = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read duration histogram: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// traceSortColumns whitelists the columns the trace list can be sorted by;
+// user input never reaches the ORDER BY clause directly
+var traceSortColumns = map[string]string{
+	"start_time":  "start_time",
+	"duration_ms": "duration_ms",
+	"span_count":  "span_count",
+	"error_count": "error_count",
+}
+
+// traceOrderBy returns the ORDER BY expression for filters, defaulting to
+// newest first. Unknown columns or orders fall back to the defaults.
+func traceOrderBy(filters TraceFilters) string {
+	column, ok := traceSortColumns[filters.SortBy]
+	if !ok {
+		column = "start_time"
+	}
+
+	order := "DESC"
+	if strings.EqualFold(filters.SortOrder, "asc") {
+		order = "ASC"
+	}
+
+	// trace_id keeps pagination stable when sort values tie
+	return column + " " + order + ", trace_id " + order
+}
+
+// buildTraceFilters returns the WHERE conditions (each prefixed with AND) and
+// arguments for the given trace filters
+func buildTraceFilters(filters TraceFilters) (string, []interface{}) {
+	query := ""
+	args := []interface{}{}
+
+	if filters.ServiceName != "" {
+		query += " AND service_name = ?"
+		args = append(args, filters.ServiceName)
+	}
+
+	if len(filters.ServiceNames) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filters.ServiceNames)), ",")
+		query += " AND service_name IN (" + placeholders + ")"
+		for _, name := range filters.ServiceNames {
+			args = append(args, name)
+		}
+	}
+
+	if filters.Environment != "" {
+		query += " AND " + environmentExpr("resource_attributes") + " = ?"
+		args = append(args, filters.Environment)
+	}
+
+	if filters.MinDuration > 0 {
+		query += " AND duration_ms >= ?"
+		args = append(args, filters.MinDuration)
+	}
+
+	if filters.MaxDuration > 0 {
+		query += " AND duration_ms <= ?"
+		args = append(args, filters.MaxDuration)
+	}
+
+	if filters.HasErrors {
+		query += " AND error_count > 0"
+	}
+
+	if filters.SpanKind != "" {
+		query += " AND trace_id IN (SELECT trace_id FROM spans WHERE span_kind = ?)"
+		args = append(args, filters.SpanKind)
+	}
+
+	if filters.Search != "" {
+		query += " AND (operation_name LIKE ? OR trace_id LIKE ?)"
+		searchPattern := "%" + filters.Search + "%"
+		args = append(args, searchPattern, searchPattern)
+	}
+
+	if !filters.StartTime.IsZero() {
+		query += " AND start_time >= ?"
+		args = append(args, filters.StartTime)
+	}
+
+	if !filters.EndTime.IsZero() {
+		query += " AND start_time <= ?"
+		args = append(args, filters.EndTime)
+	}
+
+	query, args = appendAttributeFilters(query, args, "attributes", filters.Attributes)
+	query, args = appendAttributeFilters(query, args, "resource_attributes", filters.ResourceAttributes)
+
+	return query, args
+}
+
+// appendAttributeFilters requires each key of attrs in the JSON column to equal
+// its value
+func appendAttributeFilters(query string, args []interface{}, column string, attrs map[string]string) (string, []interface{}) {
+	// Sorted so the generated query is stable
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query += " AND json_extract_string(" + column + ", ?) = ?"
+		args = append(args, attributeJSONPath(key), attrs[key])
+	}
+	return query, args
+}
+
+// decodeAttributes reads a JSON attributes column into dst. DuckDB v2 returns
+// the map directly; older drivers return the encoded bytes.
+func decodeAttributes(value any, dst *map[string]interface{}) {
+	if m, ok := value.(map[string]any); ok {
+		*dst = m
+	} else if bytes, ok := value.([]byte); ok && len(bytes) > 0 {
+		json.Unmarshal(bytes, dst)
+	}
+}
+
+// attributeJSONPath builds a JSON path for a top-level key; quoting keeps dotted
+// names such as http.status_code from being read as nested objects
+func attributeJSONPath(key string) string {
+	return `$."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+}
+
 // TraceFilters holds filter parameters for trace queries
 type TraceFilters struct {
 	ServiceName string
-	MinDuration int64
-	MaxDuration int64
-	HasErrors   bool
-	Search      string // Search in operation_name or trace_id
-	StartTime   time.Time
-	EndTime     time.Time
-	Limit       int
-	Offset      int
+	// ServiceNames matches traces from any of the services. Like ServiceName it
+	// compares the trace's service, which is its root span's service, so a trace
+	// that only passes through a service in a child span is not matched.
+	ServiceNames []string
+	Environment  string // deployment.environment of the root span's resource
+	MinDuration  int64
+	MaxDuration  int64
+	HasErrors    bool
+	Search       string // Search in operation_name or trace_id
+	SpanKind     string // Traces with at least one span of this kind, e.g. server
+	StartTime    time.Time
+	EndTime      time.Time
+	Limit        int
+	Offset       int
+	// IncludeAttributes returns trace attributes in the list; GetTraceByID always does
+	IncludeAttributes bool
+	// Attributes matches trace attributes (resource + root span) by exact string value
+	Attributes map[string]string
+	// ResourceAttributes matches only the root span's resource attributes, e.g.
+	// deployment.environment=production
+	ResourceAttributes map[string]string
+	SortBy             string // start_time (default), duration_ms, span_count or error_count
+	SortOrder          string // desc (default) or asc
 }
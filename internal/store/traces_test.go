@@ -2,6 +2,12 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -67,6 +73,13 @@ func TestInsertAndGetTrace(t *testing.T) {
 				DurationMs:    60,
 				StatusCode:    0,
 				Attributes:    map[string]interface{}{"db.system": "postgresql"},
+				ScopeName:     "go.opentelemetry.io/contrib/instrumentation/database/sql",
+				ScopeVersion:  "0.1.0",
+
+				DroppedAttributesCount: 3,
+				DroppedEventsCount:     1,
+				TraceState:             "vendor=abc,rojo=00f067aa0ba902b7",
+				Sampled:                true,
 			},
 		},
 	}
@@ -93,6 +106,124 @@ func TestInsertAndGetTrace(t *testing.T) {
 	if len(retrieved.Spans) != 2 {
 		t.Errorf("Expected 2 spans, got %d", len(retrieved.Spans))
 	}
+
+	// Verify instrumentation scope survives the round trip
+	for _, span := range retrieved.Spans {
+		if span.SpanID != "span-002" {
+			continue
+		}
+		if span.ScopeName != "go.opentelemetry.io/contrib/instrumentation/database/sql" {
+			t.Errorf("Expected scope_name to be preserved, got %q", span.ScopeName)
+		}
+		if span.ScopeVersion != "0.1.0" {
+			t.Errorf("Expected scope_version '0.1.0', got %q", span.ScopeVersion)
+		}
+		if span.DroppedAttributesCount != 3 || span.DroppedEventsCount != 1 || span.DroppedLinksCount != 0 {
+			t.Errorf("Expected dropped counts 3/1/0, got %d/%d/%d",
+				span.DroppedAttributesCount, span.DroppedEventsCount, span.DroppedLinksCount)
+		}
+		if span.TraceState != "vendor=abc,rojo=00f067aa0ba902b7" || !span.Sampled {
+			t.Errorf("Expected trace state and sampled flag to be preserved, got %q and %t", span.TraceState, span.Sampled)
+		}
+	}
+	if !retrieved.HasDroppedData {
+		t.Error("Expected has_dropped_data to be set")
+	}
+}
+
+func TestInsertTraces(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	traces := make([]*Trace, 0, 50)
+	for i := 0; i < 50; i++ {
+		traceID := fmt.Sprintf("batch-trace-%02d", i)
+		traces = append(traces, &Trace{
+			TraceID:       traceID,
+			ServiceName:   "batch-service",
+			OperationName: "op",
+			StartTime:     now,
+			EndTime:       now,
+			SpanCount:     1,
+			Spans: []Span{
+				{SpanID: traceID + "-root", TraceID: traceID, ServiceName: "batch-service", OperationName: fmt.Sprintf("op-%d", i), SpanKind: "server", StartTime: now, EndTime: now},
+			},
+		})
+	}
+
+	if err := store.Traces.InsertTraces(ctx, traces); err != nil {
+		t.Fatalf("Failed to insert traces: %v", err)
+	}
+
+	for i, trace := range traces {
+		got, err := store.Traces.GetTraceByID(ctx, trace.TraceID)
+		if err != nil {
+			t.Fatalf("Failed to get trace %s: %v", trace.TraceID, err)
+		}
+		if len(got.Spans) != 1 {
+			t.Errorf("Expected 1 span for %s, got %d", trace.TraceID, len(got.Spans))
+		}
+		// Summary is refreshed from the root span
+		if expected := fmt.Sprintf("op-%d", i); got.OperationName != expected {
+			t.Errorf("Expected operation %s, got %s", expected, got.OperationName)
+		}
+	}
+
+	if count, err := store.Traces.CountTraces(ctx, TraceFilters{}); err != nil || count != 50 {
+		t.Errorf("Expected 50 traces, got %d (err: %v)", count, err)
+	}
+}
+
+func TestGetSpanByID(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	trace := &Trace{
+		TraceID:       "trace-span-lookup",
+		ServiceName:   "svc",
+		OperationName: "GET /",
+		StartTime:     now.Add(-10 * time.Millisecond),
+		EndTime:       now,
+		DurationMs:    10,
+		SpanCount:     1,
+		Spans: []Span{
+			{
+				SpanID:        "span-lookup",
+				TraceID:       "trace-span-lookup",
+				ServiceName:   "svc",
+				OperationName: "GET /",
+				SpanKind:      "server",
+				StartTime:     now.Add(-10 * time.Millisecond),
+				EndTime:       now,
+				DurationMs:    10,
+				Attributes:    map[string]interface{}{"http.method": "GET"},
+			},
+		},
+	}
+	if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	span, err := store.Traces.GetSpanByID(ctx, "span-lookup")
+	if err != nil {
+		t.Fatalf("Failed to get span: %v", err)
+	}
+	if span.TraceID != "trace-span-lookup" || span.OperationName != "GET /" {
+		t.Errorf("Unexpected span: %+v", span)
+	}
+	if span.Attributes["http.method"] != "GET" {
+		t.Errorf("Expected attributes to be loaded, got %v", span.Attributes)
+	}
+
+	if _, err := store.Traces.GetSpanByID(ctx, "missing-span"); err == nil {
+		t.Error("Expected error for missing span")
+	}
 }
 
 func TestGetTracesWithFilters(t *testing.T) {
@@ -234,6 +365,524 @@ func TestGetTracesWithFilters(t *testing.T) {
 	})
 }
 
+func TestGetTracesIncludeAttributes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Typical merged resource + root span attributes from an OTel SDK
+	attributes := map[string]interface{}{
+		"service.name":           "checkout",
+		"service.version":        "1.4.2",
+		"deployment.environment": "production",
+		"host.name":              "checkout-7d9f8b6c5-x2x9k",
+		"telemetry.sdk.name":     "opentelemetry",
+		"telemetry.sdk.language": "go",
+		"telemetry.sdk.version":  "1.28.0",
+		"process.pid":            4242,
+		"http.method":            "POST",
+		"http.route":             "/api/checkout/{cartId}",
+		"http.target":            "/api/checkout/8f14e45f",
+		"http.status_code":       200,
+		"net.peer.ip":            "10.12.4.17",
+		"user_agent.original":    "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36",
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("%032x", i),
+			ServiceName:   "checkout",
+			OperationName: "POST /api/checkout/{cartId}",
+			StartTime:     now,
+			EndTime:       now,
+			SpanCount:     1,
+			Attributes:    attributes,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	without, err := store.Traces.GetTraces(ctx, TraceFilters{Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	with, err := store.Traces.GetTraces(ctx, TraceFilters{Limit: 100, IncludeAttributes: true})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+
+	if len(without) != 100 || len(with) != 100 {
+		t.Fatalf("Expected 100 traces each, got %d and %d", len(without), len(with))
+	}
+	if without[0].Attributes != nil {
+		t.Errorf("Expected no attributes by default, got %v", without[0].Attributes)
+	}
+	if len(with[0].Attributes) != len(attributes) {
+		t.Errorf("Expected %d attributes when included, got %d", len(attributes), len(with[0].Attributes))
+	}
+
+	// Typical attributes make up most of the page
+	withoutJSON, _ := json.Marshal(without)
+	withJSON, _ := json.Marshal(with)
+	if 2*len(withoutJSON) >= len(withJSON) {
+		t.Errorf("Expected the page without attributes to be under half the size, got %d bytes vs %d", len(withoutJSON), len(withJSON))
+	}
+}
+
+func TestGetTracesAttributeFilter(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, status := range []int{200, 500, 500} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("attr-trace-%d", i),
+			ServiceName:   "api",
+			OperationName: "GET /items",
+			StartTime:     now,
+			EndTime:       now,
+			Attributes: map[string]interface{}{
+				"http.status_code": status,
+				"http.method":      "GET",
+				"region":           fmt.Sprintf("eu-%d", i),
+			},
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	filters := TraceFilters{Attributes: map[string]string{"http.status_code": "500"}, Limit: 10}
+	results, err := store.Traces.GetTraces(ctx, filters)
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 traces with status 500, got %d", len(results))
+	}
+	if count, err := store.Traces.CountTraces(ctx, filters); err != nil || count != 2 {
+		t.Errorf("Expected count 2 to match the list, got %d (err: %v)", count, err)
+	}
+
+	// All attribute filters must match
+	filters.Attributes["region"] = "eu-2"
+	results, err = store.Traces.GetTraces(ctx, filters)
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 1 || results[0].TraceID != "attr-trace-2" {
+		t.Errorf("Expected only attr-trace-2, got %v", results)
+	}
+
+	// Missing keys never match
+	results, err = store.Traces.GetTraces(ctx, TraceFilters{Attributes: map[string]string{"missing": "x"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no traces for a missing attribute, got %d", len(results))
+	}
+}
+
+func TestGetTracesResourceAttributeFilter(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, env := range []string{"production", "staging", "production"} {
+		resource := map[string]interface{}{"service.name": "api", "deployment.environment": env}
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("env-trace-%d", i),
+			ServiceName:   "api",
+			OperationName: "GET /items",
+			StartTime:     now,
+			EndTime:       now,
+			// A span attribute with the same key must not match the resource filter
+			Attributes:         map[string]interface{}{"service.name": "api", "deployment.environment": "production"},
+			ResourceAttributes: resource,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	filters := TraceFilters{ResourceAttributes: map[string]string{"deployment.environment": "staging"}, Limit: 10}
+	results, err := store.Traces.GetTraces(ctx, filters)
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 1 || results[0].TraceID != "env-trace-1" {
+		t.Errorf("Expected only the staging trace, got %v", results)
+	}
+	if count, err := store.Traces.CountTraces(ctx, filters); err != nil || count != 1 {
+		t.Errorf("Expected count 1 to match the list, got %d (err: %v)", count, err)
+	}
+
+	filters.ResourceAttributes["deployment.environment"] = "production"
+	if results, err = store.Traces.GetTraces(ctx, filters); err != nil || len(results) != 2 {
+		t.Errorf("Expected 2 production traces, got %d (err: %v)", len(results), err)
+	}
+
+	trace, err := store.Traces.GetTraceByID(ctx, "env-trace-1")
+	if err != nil {
+		t.Fatalf("Failed to get trace: %v", err)
+	}
+	if trace.ResourceAttributes["deployment.environment"] != "staging" {
+		t.Errorf("Expected resource attributes to round-trip, got %v", trace.ResourceAttributes)
+	}
+}
+
+func TestLateRootUpdatesTraceAttributes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	rootID := "late-root"
+
+	// The child arrives first and names the trace
+	childResource := map[string]interface{}{"service.name": "db"}
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:            "late-trace",
+		ServiceName:        "db",
+		OperationName:      "SELECT",
+		StartTime:          now,
+		EndTime:            now,
+		Attributes:         map[string]interface{}{"service.name": "db", "db.system": "duckdb"},
+		ResourceAttributes: childResource,
+		Spans: []Span{{
+			SpanID: "late-child", TraceID: "late-trace", ParentSpanID: &rootID, ServiceName: "db", OperationName: "SELECT",
+			StartTime: now, EndTime: now, Attributes: map[string]interface{}{"db.system": "duckdb"}, ResourceAttributes: childResource,
+		}},
+	}); err != nil {
+		t.Fatalf("Failed to insert child: %v", err)
+	}
+
+	rootResource := map[string]interface{}{"service.name": "api", "deployment.environment": "production"}
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:            "late-trace",
+		ServiceName:        "api",
+		OperationName:      "GET /items",
+		StartTime:          now.Add(-time.Second),
+		EndTime:            now,
+		Attributes:         map[string]interface{}{"service.name": "api", "http.method": "GET"},
+		ResourceAttributes: rootResource,
+		Spans: []Span{{
+			SpanID: rootID, TraceID: "late-trace", ServiceName: "api", OperationName: "GET /items",
+			StartTime: now.Add(-time.Second), EndTime: now, Attributes: map[string]interface{}{"http.method": "GET"}, ResourceAttributes: rootResource,
+		}},
+	}); err != nil {
+		t.Fatalf("Failed to insert root: %v", err)
+	}
+
+	trace, err := store.Traces.GetTraceByID(ctx, "late-trace")
+	if err != nil {
+		t.Fatalf("Failed to get trace: %v", err)
+	}
+	if trace.OperationName != "GET /items" {
+		t.Errorf("Expected the trace to be named after the root, got %s", trace.OperationName)
+	}
+	if trace.ResourceAttributes["service.name"] != "api" || trace.ResourceAttributes["deployment.environment"] != "production" {
+		t.Errorf("Expected the root's resource attributes, got %v", trace.ResourceAttributes)
+	}
+	if trace.Attributes["http.method"] != "GET" || trace.Attributes["service.name"] != "api" || trace.Attributes["db.system"] != nil {
+		t.Errorf("Expected the root's attributes, got %v", trace.Attributes)
+	}
+}
+
+func TestGetTracesSorting(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Start times run opposite to durations so the orderings differ
+	for i, duration := range []int64{30, 120, 10, 75} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("sort-trace-%d", i),
+			ServiceName:   "api",
+			OperationName: "op",
+			StartTime:     now.Add(time.Duration(i) * time.Second),
+			EndTime:       now.Add(time.Duration(i) * time.Second),
+			DurationMs:    duration,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	durations := func(filters TraceFilters) []int64 {
+		filters.Limit = 10
+		results, err := store.Traces.GetTraces(ctx, filters)
+		if err != nil {
+			t.Fatalf("Failed to get traces: %v", err)
+		}
+		got := make([]int64, len(results))
+		for i, trace := range results {
+			got[i] = trace.DurationMs
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		filters  TraceFilters
+		expected []int64
+	}{
+		{"duration descending", TraceFilters{SortBy: "duration_ms"}, []int64{120, 75, 30, 10}},
+		{"duration ascending", TraceFilters{SortBy: "duration_ms", SortOrder: "asc"}, []int64{10, 30, 75, 120}},
+		{"default newest first", TraceFilters{}, []int64{75, 10, 120, 30}},
+		{"unknown column falls back", TraceFilters{SortBy: "duration_ms; DROP TABLE traces"}, []int64{75, 10, 120, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := durations(tt.filters)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected durations %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetTracesServiceNamesFilter(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, service := range []string{"api", "billing", "search"} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       service + "-trace",
+			ServiceName:   service,
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	filters := TraceFilters{ServiceNames: []string{"api", "search", "missing"}, SortBy: "start_time", Limit: 10}
+	results, err := store.Traces.GetTraces(ctx, filters)
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	ids := make([]string, 0, len(results))
+	for _, trace := range results {
+		ids = append(ids, trace.TraceID)
+	}
+	sort.Strings(ids)
+	if fmt.Sprint(ids) != "[api-trace search-trace]" {
+		t.Errorf("Expected [api-trace search-trace], got %v", ids)
+	}
+	if count, err := store.Traces.CountTraces(ctx, filters); err != nil || count != 2 {
+		t.Errorf("Expected count 2 to match the list, got %d (err: %v)", count, err)
+	}
+
+	// A single service still uses ServiceName
+	results, err = store.Traces.GetTraces(ctx, TraceFilters{ServiceName: "billing", Limit: 10})
+	if err != nil || len(results) != 1 || results[0].TraceID != "billing-trace" {
+		t.Errorf("Expected only billing-trace, got %v (err: %v)", results, err)
+	}
+}
+
+func TestGetTracesSpanKindFilter(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	traces := map[string][]string{
+		"server-trace": {"server", "client"},
+		"client-trace": {"client"},
+		"batch-trace":  {"internal", "producer"},
+	}
+	for traceID, kinds := range traces {
+		trace := &Trace{
+			TraceID:       traceID,
+			ServiceName:   "api",
+			OperationName: traceID,
+			StartTime:     now,
+			EndTime:       now,
+			SpanCount:     len(kinds),
+		}
+		for i, kind := range kinds {
+			trace.Spans = append(trace.Spans, Span{
+				SpanID:        fmt.Sprintf("%s-%d", traceID, i),
+				TraceID:       traceID,
+				ServiceName:   "api",
+				OperationName: kind,
+				SpanKind:      kind,
+				StartTime:     now,
+				EndTime:       now,
+			})
+		}
+		if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	filters := TraceFilters{SpanKind: "server", Limit: 10}
+	results, err := store.Traces.GetTraces(ctx, filters)
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 1 || results[0].TraceID != "server-trace" {
+		t.Errorf("Expected only server-trace, got %v", results)
+	}
+	if count, err := store.Traces.CountTraces(ctx, filters); err != nil || count != 1 {
+		t.Errorf("Expected count 1 to match the list, got %d (err: %v)", count, err)
+	}
+
+	// Any span of the kind matches, not just the root
+	results, err = store.Traces.GetTraces(ctx, TraceFilters{SpanKind: "client", Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 traces with client spans, got %d", len(results))
+	}
+}
+
+func TestFilterExistingTraceIDs(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, traceID := range []string{"stored-1", "stored-2"} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       traceID,
+			ServiceName:   "api",
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	existing, err := store.Traces.FilterExistingTraceIDs(ctx, []string{"stored-1", "missing-1", "stored-2", "missing-2", "stored-1"})
+	if err != nil {
+		t.Fatalf("Failed to filter trace IDs: %v", err)
+	}
+	sort.Strings(existing)
+	if fmt.Sprint(existing) != "[stored-1 stored-2]" {
+		t.Errorf("Expected [stored-1 stored-2], got %v", existing)
+	}
+
+	existing, err = store.Traces.FilterExistingTraceIDs(ctx, nil)
+	if err != nil || len(existing) != 0 {
+		t.Errorf("Expected no IDs for an empty lookup, got %v (err: %v)", existing, err)
+	}
+}
+
+func TestDeleteTrace(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, traceID := range []string{"delete-me", "keep-me"} {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       traceID,
+			ServiceName:   "api",
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+			SpanCount:     2,
+			Spans: []Span{
+				{SpanID: traceID + "-root", TraceID: traceID, ServiceName: "api", OperationName: "GET /", StartTime: now, EndTime: now},
+				{SpanID: traceID + "-child", TraceID: traceID, ServiceName: "api", OperationName: "SELECT", StartTime: now, EndTime: now},
+			},
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	for _, traceID := range []string{"delete-me", "keep-me"} {
+		if err := store.Logs.InsertLog(ctx, &LogRecord{
+			Timestamp:   now,
+			TraceID:     strPtr(traceID),
+			ServiceName: "api",
+			Body:        "request handled",
+			Attributes:  map[string]interface{}{"deleted.only": true},
+		}); err != nil {
+			t.Fatalf("Failed to insert log: %v", err)
+		}
+	}
+	if keys, _ := store.GetAttributeKeys(ctx, "logs"); len(keys) == 0 {
+		t.Fatalf("Expected attribute keys before the delete")
+	}
+
+	spans, logs, err := store.DeleteTrace(ctx, "delete-me", true)
+	if err != nil {
+		t.Fatalf("Failed to delete trace: %v", err)
+	}
+	if spans != 2 || logs != 1 {
+		t.Errorf("Expected 2 spans and 1 log deleted, got %d and %d", spans, logs)
+	}
+	if remaining, _ := store.Logs.GetLogsByTraceID(ctx, "keep-me"); len(remaining) != 1 {
+		t.Errorf("Expected the other trace to keep its log, got %d", len(remaining))
+	}
+	if _, cached := store.attributeKeys.entries["logs"]; cached {
+		t.Errorf("Expected the attribute key cache to be reset")
+	}
+
+	if _, err := store.Traces.GetTraceByID(ctx, "delete-me"); !errors.Is(err, ErrTraceNotFound) {
+		t.Errorf("Expected deleted trace to be gone, got %v", err)
+	}
+	if _, err := store.Traces.GetSpanByID(ctx, "delete-me-child"); err == nil {
+		t.Errorf("Expected spans of the deleted trace to be gone")
+	}
+
+	kept, err := store.Traces.GetTraceByID(ctx, "keep-me")
+	if err != nil {
+		t.Fatalf("Expected other trace to remain: %v", err)
+	}
+	if len(kept.Spans) != 2 {
+		t.Errorf("Expected other trace to keep 2 spans, got %d", len(kept.Spans))
+	}
+
+	if _, _, err := store.DeleteTrace(ctx, "delete-me", false); !errors.Is(err, ErrTraceNotFound) {
+		t.Errorf("Expected ErrTraceNotFound deleting a missing trace, got %v", err)
+	}
+}
+
+func TestNotFoundErrors(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	_, err := store.Traces.GetTraceByID(ctx, "no-such-trace")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrTraceNotFound) {
+		t.Errorf("Expected ErrTraceNotFound wrapping ErrNotFound, got %v", err)
+	}
+	if err != nil && err.Error() != "trace not found" {
+		t.Errorf("Expected message 'trace not found', got %q", err.Error())
+	}
+
+	_, err = store.Traces.GetSpanByID(ctx, "no-such-span")
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrSpanNotFound) {
+		t.Errorf("Expected ErrSpanNotFound wrapping ErrNotFound, got %v", err)
+	}
+
+	// Query failures are not reported as missing records
+	store.Close()
+	if _, err := store.Traces.GetTraceByID(ctx, "no-such-trace"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a non-ErrNotFound error from a closed store, got %v", err)
+	}
+}
+
 func TestGetServices(t *testing.T) {
 	store := setupTestStore(t)
 	defer store.Close()
@@ -270,14 +919,138 @@ func TestGetServices(t *testing.T) {
 		}
 	}
 
-	// Get services
-	results, err := store.Traces.GetServices(ctx)
+	// Get services
+	results, err := store.Traces.GetServices(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get services: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Errorf("Expected 3 services, got %d", len(results))
+	}
+}
+
+func TestGetServicesCached(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	insert := func(id, service string) {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       id,
+			ServiceName:   service,
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	insert("trace-1", "api")
+	services, err := store.Traces.GetServices(ctx)
 	if err != nil {
 		t.Fatalf("Failed to get services: %v", err)
 	}
+	if fmt.Sprint(services) != "[api]" {
+		t.Fatalf("Expected [api], got %v", services)
+	}
 
-	if len(results) != 3 {
-		t.Errorf("Expected 3 services, got %d", len(results))
+	// Within the TTL concurrent readers all get the cached list
+	insert("trace-2", "billing")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cached, err := store.Traces.GetServices(ctx); err != nil || len(cached) != 1 {
+				t.Errorf("Expected cached [api], got %v (err: %v)", cached, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	store.Traces.services.expires = now.Add(-time.Second)
+	services, _ = store.Traces.GetServices(ctx)
+	if fmt.Sprint(services) != "[api billing]" {
+		t.Errorf("Expected [api billing] after expiry, got %v", services)
+	}
+
+	// Deleting a trace drops the cache immediately
+	if _, _, err := store.DeleteTrace(ctx, "trace-2", false); err != nil {
+		t.Fatalf("Failed to delete trace: %v", err)
+	}
+	services, _ = store.Traces.GetServices(ctx)
+	if fmt.Sprint(services) != "[api]" {
+		t.Errorf("Expected [api] after deleting billing's trace, got %v", services)
+	}
+}
+
+func TestGetSlowestTraces(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	insert := func(id, service string, durationMs int64) {
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       id,
+			ServiceName:   service,
+			OperationName: "GET /",
+			StartTime:     now,
+			EndTime:       now.Add(time.Duration(durationMs) * time.Millisecond),
+			DurationMs:    durationMs,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	// api latencies spread evenly over 1..100ms; billing is uniformly slow
+	for i := int64(1); i <= 100; i++ {
+		insert(fmt.Sprintf("api-%d", i), "api", i)
+	}
+	for i := 0; i < 5; i++ {
+		insert(fmt.Sprintf("billing-%d", i), "billing", 1000)
+	}
+
+	traces, threshold, err := store.Traces.GetSlowestTraces(ctx, "api", 0.9, 100)
+	if err != nil {
+		t.Fatalf("Failed to get slowest traces: %v", err)
+	}
+	// quantile_cont interpolates: 1 + 0.9 * 99 = 90.1
+	if math.Abs(threshold-90.1) > 1e-9 {
+		t.Errorf("Expected p90 threshold 90.1ms, got %v", threshold)
+	}
+	if len(traces) != 10 {
+		t.Fatalf("Expected the 10 traces above p90, got %d", len(traces))
+	}
+	if traces[0].TraceID != "api-100" || traces[9].TraceID != "api-91" {
+		t.Errorf("Expected api-100 ... api-91 slowest first, got %s ... %s", traces[0].TraceID, traces[9].TraceID)
+	}
+
+	traces, _, err = store.Traces.GetSlowestTraces(ctx, "api", 0.9, 3)
+	if err != nil || len(traces) != 3 {
+		t.Errorf("Expected the limit to cap results at 3, got %d (err: %v)", len(traces), err)
+	}
+
+	// Across services billing's outliers dominate the tail
+	traces, _, err = store.Traces.GetSlowestTraces(ctx, "", 0.99, 100)
+	if err != nil {
+		t.Fatalf("Failed to get slowest traces: %v", err)
+	}
+	for _, trace := range traces {
+		if trace.ServiceName != "billing" {
+			t.Errorf("Expected only billing traces above the global p99, got %s", trace.TraceID)
+		}
+	}
+
+	traces, threshold, err = store.Traces.GetSlowestTraces(ctx, "missing", 0.99, 100)
+	if err != nil || len(traces) != 0 || threshold != 0 {
+		t.Errorf("Expected no traces for an unknown service, got %v, %v (err: %v)", traces, threshold, err)
+	}
+	if _, _, err := store.Traces.GetSlowestTraces(ctx, "api", 1, 100); err == nil {
+		t.Errorf("Expected an error for percentile 1")
 	}
 }
 
@@ -520,3 +1293,444 @@ func TestInsertTrace_ParentsDiffer(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestGetTraceByID_OrphanedParent(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	trace := &Trace{
+		TraceID:       "trace-orphan-test",
+		ServiceName:   "svc",
+		OperationName: "GET /api/items",
+		StartTime:     now.Add(-5 * time.Millisecond),
+		EndTime:       now,
+		DurationMs:    5,
+		SpanCount:     2,
+		Spans: []Span{
+			{
+				SpanID:        "span-root",
+				TraceID:       "trace-orphan-test",
+				ServiceName:   "svc",
+				OperationName: "GET /api/items",
+				SpanKind:      "server",
+				StartTime:     now.Add(-5 * time.Millisecond),
+				EndTime:       now,
+				DurationMs:    5,
+			},
+			{
+				SpanID:        "span-orphan",
+				TraceID:       "trace-orphan-test",
+				ParentSpanID:  strPtr("span-does-not-exist"),
+				ServiceName:   "svc",
+				OperationName: "SELECT db.items",
+				SpanKind:      "client",
+				StartTime:     now.Add(-3 * time.Millisecond),
+				EndTime:       now.Add(-1 * time.Millisecond),
+				DurationMs:    2,
+			},
+		},
+	}
+
+	if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	retrieved, err := store.Traces.GetTraceByID(ctx, "trace-orphan-test")
+	if err != nil {
+		t.Fatalf("Failed to get trace: %v", err)
+	}
+
+	for _, span := range retrieved.Spans {
+		switch span.SpanID {
+		case "span-root":
+			if span.OrphanedParent {
+				t.Errorf("Root span should not be marked as orphaned")
+			}
+		case "span-orphan":
+			if !span.OrphanedParent {
+				t.Errorf("Expected span-orphan to be marked as orphaned")
+			}
+		}
+	}
+}
+
+func TestGetOperationStats(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Ten "GET /users" traces of 10..100ms (one with errors) and one "POST /orders"
+	for i := 1; i <= 10; i++ {
+		errorCount := 0
+		if i == 10 {
+			errorCount = 2
+		}
+		trace := &Trace{
+			TraceID:       fmt.Sprintf("users-%d", i),
+			ServiceName:   "api",
+			OperationName: "GET /users",
+			StartTime:     now.Add(-time.Duration(i) * time.Second),
+			EndTime:       now,
+			DurationMs:    int64(i * 10),
+			SpanCount:     1,
+			ErrorCount:    errorCount,
+		}
+		if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:       "orders-1",
+		ServiceName:   "api",
+		OperationName: "POST /orders",
+		StartTime:     now,
+		EndTime:       now,
+		DurationMs:    5,
+		SpanCount:     1,
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	stats, err := store.Traces.GetOperationStats(ctx, "api", now.Add(-time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to get operation stats: %v", err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(stats))
+	}
+
+	users := stats[0]
+	if users.OperationName != "GET /users" || users.Count != 10 {
+		t.Fatalf("Expected busiest operation 'GET /users' with 10 traces, got %+v", users)
+	}
+	if users.ErrorCount != 2 {
+		t.Errorf("Expected error_count 2, got %d", users.ErrorCount)
+	}
+	if users.P50Ms != 55 {
+		t.Errorf("Expected p50 55ms, got %v", users.P50Ms)
+	}
+	if users.P99Ms < users.P95Ms || users.P95Ms < users.P50Ms || users.P99Ms > 100 {
+		t.Errorf("Expected ordered percentiles up to 100ms, got p50=%v p95=%v p99=%v", users.P50Ms, users.P95Ms, users.P99Ms)
+	}
+	// 10 traces over a 2 minute window
+	if want := 10.0 / 120; users.Throughput != want {
+		t.Errorf("Expected throughput %v/s, got %v", want, users.Throughput)
+	}
+}
+
+func TestGetErrorRateTimeSeries(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	// Offsets within one minute so the traces share a bucket
+	bucketStart := time.Now().Truncate(time.Minute).Add(-2 * time.Minute)
+
+	for i, errorCount := range []int{0, 1, 0, 1} {
+		trace := &Trace{
+			TraceID:       fmt.Sprintf("error-rate-%d", i),
+			ServiceName:   "api",
+			OperationName: "op",
+			StartTime:     bucketStart.Add(time.Duration(i+1) * time.Second),
+			EndTime:       bucketStart.Add(time.Duration(i+1) * time.Second),
+			SpanCount:     1,
+			ErrorCount:    errorCount,
+		}
+		if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+	// Other services are excluded
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:     "error-rate-other",
+		ServiceName: "worker",
+		StartTime:   bucketStart,
+		EndTime:     bucketStart,
+		ErrorCount:  1,
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	points, err := store.Traces.GetErrorRateTimeSeries(ctx, "api", "1 minute", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to get error rate: %v", err)
+	}
+
+	if len(points) != 1 {
+		t.Fatalf("Expected 1 bucket, got %d", len(points))
+	}
+	if !points[0].TimeBucket.Equal(bucketStart) {
+		t.Errorf("Expected bucket %v, got %v", bucketStart, points[0].TimeBucket)
+	}
+	if points[0].TraceCount != 4 || points[0].ErrorCount != 2 {
+		t.Errorf("Expected 4 traces and 2 errors, got %d and %d", points[0].TraceCount, points[0].ErrorCount)
+	}
+	if points[0].ErrorRate != 0.5 {
+		t.Errorf("Expected error rate 0.5, got %f", points[0].ErrorRate)
+	}
+}
+
+func TestCountTracesAndSpans(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, errorCount := range []int{0, 1} {
+		traceID := fmt.Sprintf("count-trace-%d", i)
+		trace := &Trace{
+			TraceID:       traceID,
+			ServiceName:   "svc",
+			OperationName: "op",
+			StartTime:     now,
+			EndTime:       now,
+			SpanCount:     2,
+			ErrorCount:    errorCount,
+			Spans: []Span{
+				{SpanID: traceID + "-a", TraceID: traceID, ServiceName: "svc", OperationName: "op", SpanKind: "server", StartTime: now, EndTime: now},
+				{SpanID: traceID + "-b", TraceID: traceID, ServiceName: "svc", OperationName: "op", SpanKind: "client", StartTime: now, EndTime: now},
+			},
+		}
+		if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	if count, err := store.Traces.CountTraces(ctx, TraceFilters{}); err != nil || count != 2 {
+		t.Errorf("Expected 2 traces, got %d (err: %v)", count, err)
+	}
+	if count, err := store.Traces.CountErrorTraces(ctx); err != nil || count != 1 {
+		t.Errorf("Expected 1 error trace, got %d (err: %v)", count, err)
+	}
+	if count, err := store.Traces.CountSpans(ctx); err != nil || count != 4 {
+		t.Errorf("Expected 4 spans, got %d (err: %v)", count, err)
+	}
+}
+
+func TestGetTraceDurationHistogram(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, duration := range []int64{0, 5, 10, 49, 50, 700, 700, 6000, 12000} {
+		service := "api"
+		if i == 0 {
+			service = "worker"
+		}
+		if err := store.Traces.InsertTrace(ctx, &Trace{
+			TraceID:       fmt.Sprintf("histogram-trace-%d", i),
+			ServiceName:   service,
+			OperationName: "op",
+			StartTime:     now,
+			EndTime:       now,
+			DurationMs:    duration,
+		}); err != nil {
+			t.Fatalf("Failed to insert trace: %v", err)
+		}
+	}
+
+	buckets, err := store.Traces.GetTraceDurationHistogram(ctx, TraceFilters{ServiceName: "api"}, DefaultDurationBuckets)
+	if err != nil {
+		t.Fatalf("Failed to get duration histogram: %v", err)
+	}
+
+	expected := []int64{1, 2, 1, 0, 2, 0, 2}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d", len(expected), len(buckets))
+	}
+	for i, bucket := range buckets {
+		if bucket.MinMs != DefaultDurationBuckets[i] {
+			t.Errorf("Expected bucket %d to start at %d, got %d", i, DefaultDurationBuckets[i], bucket.MinMs)
+		}
+		if bucket.Count != expected[i] {
+			t.Errorf("Expected %d traces in bucket %d, got %d", expected[i], bucket.MinMs, bucket.Count)
+		}
+	}
+	if last := buckets[len(buckets)-1]; last.MaxMs != nil {
+		t.Errorf("Expected the last bucket to be open-ended, got max %d", *last.MaxMs)
+	}
+
+	// Traces below the first bound are not counted
+	buckets, err = store.Traces.GetTraceDurationHistogram(ctx, TraceFilters{}, []int64{100, 1000})
+	if err != nil {
+		t.Fatalf("Failed to get duration histogram: %v", err)
+	}
+	if buckets[0].Count != 2 || buckets[1].Count != 2 {
+		t.Errorf("Expected counts [2 2], got [%d %d]", buckets[0].Count, buckets[1].Count)
+	}
+}
+
+func TestSearchSpanEvents(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	span := func(id string, start time.Time, events ...string) Span {
+		s := Span{
+			SpanID:        id,
+			TraceID:       "events-trace",
+			ServiceName:   "api",
+			OperationName: "op",
+			StartTime:     start,
+			EndTime:       start,
+		}
+		for _, name := range events {
+			s.Events = append(s.Events, SpanEvent{Name: name, Timestamp: start})
+		}
+		return s
+	}
+
+	trace := &Trace{
+		TraceID:       "events-trace",
+		ServiceName:   "api",
+		OperationName: "op",
+		StartTime:     now.Add(-3 * time.Hour),
+		EndTime:       now,
+		Spans: []Span{
+			span("retried", now.Add(-time.Minute), "cache.miss", "retry"),
+			span("retried-again", now.Add(-2*time.Minute), "retry"),
+			span("cached", now.Add(-time.Minute), "cache.hit"),
+			span("no-events", now.Add(-time.Minute)),
+			span("old-retry", now.Add(-3*time.Hour), "retry"),
+		},
+	}
+	if err := store.Traces.InsertTrace(ctx, trace); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	spans, err := store.Traces.SearchSpanEvents(ctx, "retry", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to search span events: %v", err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].SpanID != "retried" || spans[1].SpanID != "retried-again" {
+		t.Errorf("Expected retried then retried-again, got %s and %s", spans[0].SpanID, spans[1].SpanID)
+	}
+	if len(spans[0].Events) != 2 {
+		t.Errorf("Expected matching spans to include all their events, got %d", len(spans[0].Events))
+	}
+
+	spans, err = store.Traces.SearchSpanEvents(ctx, "cache", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to search span events: %v", err)
+	}
+	if len(spans) != 0 {
+		t.Errorf("Expected event names to match exactly, got %d spans", len(spans))
+	}
+}
+
+func TestGetOperationsByService(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	spans := []Span{}
+	for i, op := range []struct{ service, name string }{
+		{"api", "GET /users"},
+		{"api", "SELECT users"},
+		{"api", "GET /users"},
+		{"api", "POST /orders"},
+		{"api", "GET /users"},
+		{"api", "POST /orders"},
+		{"worker", "process"},
+	} {
+		spans = append(spans, Span{
+			SpanID:        fmt.Sprintf("ops-span-%d", i),
+			TraceID:       "ops-trace",
+			ServiceName:   op.service,
+			OperationName: op.name,
+			StartTime:     now,
+			EndTime:       now,
+		})
+	}
+	if err := store.Traces.InsertTrace(ctx, &Trace{
+		TraceID:       "ops-trace",
+		ServiceName:   "api",
+		OperationName: "GET /users",
+		StartTime:     now,
+		EndTime:       now,
+		Spans:         spans,
+	}); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	operations, err := store.Traces.GetOperationsByService(ctx, "api")
+	if err != nil {
+		t.Fatalf("Failed to get operations: %v", err)
+	}
+
+	expected := []OperationCount{
+		{OperationName: "GET /users", Count: 3},
+		{OperationName: "POST /orders", Count: 2},
+		{OperationName: "SELECT users", Count: 1},
+	}
+	if len(operations) != len(expected) {
+		t.Fatalf("Expected %d operations, got %d: %+v", len(expected), len(operations), operations)
+	}
+	for i, op := range operations {
+		if op != expected[i] {
+			t.Errorf("Expected operation %d to be %+v, got %+v", i, expected[i], op)
+		}
+	}
+}
+
+func TestTraceTruncatedFlag(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	trace := func(spanID string, truncated bool) *Trace {
+		return &Trace{
+			TraceID:       "truncated-trace",
+			ServiceName:   "api",
+			OperationName: "batch",
+			StartTime:     now,
+			EndTime:       now,
+			Truncated:     truncated,
+			Spans: []Span{{
+				SpanID: spanID, TraceID: "truncated-trace", ServiceName: "api",
+				OperationName: "batch", StartTime: now, EndTime: now,
+			}},
+		}
+	}
+
+	// A later, complete batch must not clear the flag set by an earlier one
+	if err := store.Traces.InsertTrace(ctx, trace("span-1", true)); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+	if err := store.Traces.InsertTrace(ctx, trace("span-2", false)); err != nil {
+		t.Fatalf("Failed to insert trace: %v", err)
+	}
+
+	got, err := store.Traces.GetTraceByID(ctx, "truncated-trace")
+	if err != nil {
+		t.Fatalf("Failed to get trace: %v", err)
+	}
+	if !got.Truncated {
+		t.Errorf("Expected trace to stay truncated")
+	}
+
+	list, err := store.Traces.GetTraces(ctx, TraceFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get traces: %v", err)
+	}
+	if len(list) != 1 || !list[0].Truncated {
+		t.Errorf("Expected truncated flag in trace list, got %+v", list)
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import "time"
+
+// SyntheticRootSpanID is the span ID of the placeholder node that adopts
+// spans whose parent is not present in the trace
+const SyntheticRootSpanID = "synthetic-root"
+
+// SpanNode is a span positioned within its trace hierarchy
+type SpanNode struct {
+	Span
+	Depth     int         `json:"depth"`
+	OffsetMs  int64       `json:"offset_ms"` // Start offset relative to the earliest span in the trace
+	Synthetic bool        `json:"synthetic,omitempty"`
+	Children  []*SpanNode `json:"children"`
+}
+
+// BuildSpanTree arranges a flat span list into parent→children nodes.
+// Spans with no parent become roots. Spans whose parent is missing from the
+// list (or that form a parent cycle) are attached to a single synthetic root
+// instead of being dropped. Children keep the order of the input slice.
+func BuildSpanTree(spans []Span) []*SpanNode {
+	if len(spans) == 0 {
+		return []*SpanNode{}
+	}
+
+	traceStart := spans[0].StartTime
+	nodes := make(map[string]*SpanNode, len(spans))
+	for _, span := range spans {
+		if span.StartTime.Before(traceStart) {
+			traceStart = span.StartTime
+		}
+		nodes[span.SpanID] = &SpanNode{Span: span, Children: []*SpanNode{}}
+	}
+
+	roots := []*SpanNode{}
+	var syntheticRoot *SpanNode
+	adoptOrphan := func(node *SpanNode) {
+		if syntheticRoot == nil {
+			syntheticRoot = newSyntheticRoot(node.TraceID, traceStart)
+		}
+		syntheticRoot.Children = append(syntheticRoot.Children, node)
+	}
+
+	for _, span := range spans {
+		node := nodes[span.SpanID]
+		node.OffsetMs = span.StartTime.Sub(traceStart).Milliseconds()
+
+		if span.ParentSpanID == nil || *span.ParentSpanID == "" {
+			roots = append(roots, node)
+			continue
+		}
+
+		parent, ok := nodes[*span.ParentSpanID]
+		if !ok || parent == node {
+			adoptOrphan(node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	// Assign depths from the roots. Each node has a single parent, so a child
+	// that was already visited can only be reached through a cycle; pruning it
+	// keeps the result acyclic.
+	visited := make(map[*SpanNode]bool, len(nodes)+1)
+	var assignDepth func(node *SpanNode, depth int)
+	assignDepth = func(node *SpanNode, depth int) {
+		visited[node] = true
+		node.Depth = depth
+		kept := node.Children[:0]
+		for _, child := range node.Children {
+			if !visited[child] {
+				kept = append(kept, child)
+				assignDepth(child, depth+1)
+			}
+		}
+		node.Children = kept
+	}
+	for _, root := range roots {
+		assignDepth(root, 0)
+	}
+	if syntheticRoot != nil {
+		assignDepth(syntheticRoot, 0)
+	}
+
+	// Spans still unvisited sit on a parent cycle; re-home them under the synthetic root
+	for _, span := range spans {
+		node := nodes[span.SpanID]
+		if visited[node] {
+			continue
+		}
+		if syntheticRoot == nil {
+			syntheticRoot = newSyntheticRoot(node.TraceID, traceStart)
+			visited[syntheticRoot] = true
+		}
+		syntheticRoot.Children = append(syntheticRoot.Children, node)
+		assignDepth(node, 1)
+	}
+
+	if syntheticRoot != nil {
+		roots = append(roots, syntheticRoot)
+	}
+
+	return roots
+}
+
+// newSyntheticRoot creates the placeholder node for orphaned spans
+func newSyntheticRoot(traceID string, start time.Time) *SpanNode {
+	return &SpanNode{
+		Span: Span{
+			SpanID:        SyntheticRootSpanID,
+			TraceID:       traceID,
+			OperationName: "(missing parent)",
+			StartTime:     start,
+		},
+		Synthetic: true,
+		Children:  []*SpanNode{},
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBuildSpanTree(t *testing.T) {
+	now := time.Now()
+	spans := []Span{
+		{SpanID: "root", TraceID: "t1", OperationName: "GET /", StartTime: now},
+		{SpanID: "child", TraceID: "t1", ParentSpanID: strPtr("root"), OperationName: "db", StartTime: now.Add(10 * time.Millisecond)},
+		{SpanID: "grandchild", TraceID: "t1", ParentSpanID: strPtr("child"), OperationName: "io", StartTime: now.Add(15 * time.Millisecond)},
+		{SpanID: "orphan", TraceID: "t1", ParentSpanID: strPtr("missing"), OperationName: "late", StartTime: now.Add(20 * time.Millisecond)},
+	}
+
+	roots := BuildSpanTree(spans)
+	if len(roots) != 2 {
+		t.Fatalf("Expected 2 roots (real + synthetic), got %d", len(roots))
+	}
+
+	root := roots[0]
+	if root.SpanID != "root" || root.Depth != 0 || root.OffsetMs != 0 {
+		t.Errorf("Unexpected root node: %+v", root)
+	}
+	if len(root.Children) != 1 || root.Children[0].SpanID != "child" {
+		t.Fatalf("Expected root to have child 'child', got %+v", root.Children)
+	}
+	grandchild := root.Children[0].Children[0]
+	if grandchild.Depth != 2 || grandchild.OffsetMs != 15 {
+		t.Errorf("Expected grandchild depth 2 offset 15ms, got depth %d offset %d", grandchild.Depth, grandchild.OffsetMs)
+	}
+
+	synthetic := roots[1]
+	if !synthetic.Synthetic || synthetic.SpanID != SyntheticRootSpanID {
+		t.Errorf("Expected synthetic root, got %+v", synthetic)
+	}
+	if len(synthetic.Children) != 1 || synthetic.Children[0].SpanID != "orphan" || synthetic.Children[0].Depth != 1 {
+		t.Errorf("Expected orphan under synthetic root at depth 1, got %+v", synthetic.Children)
+	}
+}
+
+func TestBuildSpanTreeParentCycle(t *testing.T) {
+	now := time.Now()
+	spans := []Span{
+		{SpanID: "a", TraceID: "t1", ParentSpanID: strPtr("b"), StartTime: now},
+		{SpanID: "b", TraceID: "t1", ParentSpanID: strPtr("a"), StartTime: now.Add(time.Millisecond)},
+	}
+
+	roots := BuildSpanTree(spans)
+	if len(roots) != 1 || !roots[0].Synthetic {
+		t.Fatalf("Expected spans in a cycle under a synthetic root, got %+v", roots)
+	}
+
+	// Must serialize without infinite recursion
+	if _, err := json.Marshal(roots); err != nil {
+		t.Fatalf("Failed to marshal tree: %v", err)
+	}
+}
@@ -54,7 +54,7 @@ func main() {
 	logger.Info("Generating logs...")
 	for i := 0; i < 50; i++ {
 		logs := generateTestLogs(services, 5)
-		if err := dataStore.Logs.InsertLogs(ctx, logs); err != nil {
+		if _, err := dataStore.Logs.InsertLogs(ctx, logs); err != nil {
 			logger.Error("Failed to insert logs", zap.Error(err))
 		} else {
 			logger.Info("Inserted logs", zap.Int("count", len(logs)))
@@ -65,7 +65,7 @@ func main() {
 	logger.Info("Generating metrics...")
 	for i := 0; i < 100; i++ {
 		metrics := generateTestMetrics(services, 10)
-		if err := dataStore.Metrics.InsertMetrics(ctx, metrics); err != nil {
+		if _, err := dataStore.Metrics.InsertMetrics(ctx, metrics); err != nil {
 			logger.Error("Failed to insert metrics", zap.Error(err))
 		} else {
 			logger.Info("Inserted metrics", zap.Int("count", len(metrics)))